@@ -3,13 +3,15 @@ module github.com/23technologies/machine-controller-manager-provider-ionos
 go 1.17
 
 require (
-	github.com/23technologies/ionos-api-wrapper v0.3.0
 	github.com/gardener/machine-controller-manager v0.45.0
 	github.com/google/uuid v1.2.0
 	github.com/ionos-cloud/sdk-go/v6 v6.0.4
 	github.com/onsi/ginkgo/v2 v2.1.3
 	github.com/onsi/gomega v1.17.0
+	github.com/prometheus/client_golang v1.12.1
+	github.com/prometheus/client_model v0.2.0
 	github.com/spf13/pflag v1.0.5
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 	k8s.io/api v0.22.9
 	k8s.io/apimachinery v0.22.9
 	k8s.io/component-base v0.22.9
@@ -35,8 +37,6 @@ require (
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
-	github.com/prometheus/client_golang v1.12.1 // indirect
-	github.com/prometheus/client_model v0.2.0 // indirect
 	github.com/prometheus/common v0.32.1 // indirect
 	github.com/prometheus/procfs v0.7.3 // indirect
 	github.com/spf13/cobra v1.1.3 // indirect
@@ -50,7 +50,6 @@ require (
 	google.golang.org/protobuf v1.26.0 // indirect
 	gopkg.in/inf.v0 v0.9.1 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
 	k8s.io/apiserver v0.22.9 // indirect
 	k8s.io/client-go v0.22.9 // indirect
 	k8s.io/cluster-bootstrap v0.22.9 // indirect
@@ -98,7 +98,7 @@ else
   if [[ $TEST_COVERAGE == true ]]; then
     test_with_coverage
   else
-    ginkgo $GINKGO_COMMON_FLAGS ${TEST_PACKAGES}
+    ginkgo $GINKGO_COMMON_FLAGS --race ${TEST_PACKAGES}
   fi
 
   echo ">>>>> Finished executing unit tests"
@@ -35,8 +35,10 @@ import (
 // args *pflag.FlagSet Command line arguments
 func RunProviderIonosManager(args *pflag.FlagSet) error {
 	s := options.NewMCServer()
+	clientOptions := &spi.ClientOptions{}
 
 	s.AddFlags(args)
+	clientOptions.AddFlags(args)
 	flag.InitFlags()
 
 	verflag.PrintAndExitIfRequested()
@@ -44,5 +46,11 @@ func RunProviderIonosManager(args *pflag.FlagSet) error {
 	logs.InitLogs()
 	defer logs.FlushLogs()
 
-	return app.Run(s, ionos.NewIonosProvider(&spi.PluginSPIImpl{}))
+	// Client settings including the CA bundle are loaded once and reused for all sessions
+	_, err := clientOptions.GetClientSettings()
+	if nil != err {
+		return err
+	}
+
+	return app.Run(s, ionos.NewIonosProvider(&spi.PluginSPIImpl{ClientOptions: clientOptions}))
 }
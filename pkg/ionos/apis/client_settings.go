@@ -0,0 +1,131 @@
+/*
+Copyright (c) 2021 SAP SE or an SAP affiliate company. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apis is the main package for provider specific APIs
+package apis
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// SecretKeyAPIURL is the secret key containing the IONOS API URL to use
+	SecretKeyAPIURL = "apiURL"
+	// SecretKeyCABundle is the secret key containing PEM encoded CA certificates trusted for the IONOS API
+	SecretKeyCABundle = "caBundle"
+	// SecretKeyProxyURL is the secret key containing the URL of the proxy used to access the IONOS API
+	SecretKeyProxyURL = "proxyURL"
+	// SecretKeyRequestTimeout is the secret key containing the timeout of IONOS API requests (e.g. "30s")
+	SecretKeyRequestTimeout = "requestTimeout"
+)
+
+// ClientSettings contains the IONOS API client settings
+type ClientSettings struct {
+	APIURL         string
+	CABundle       []byte
+	ProxyURL       string
+	RequestTimeout time.Duration
+}
+
+// GetClientSettingsFromSecret returns the validated IONOS API client settings of the secret given. Settings not
+// contained in the secret are left empty.
+//
+// PARAMETERS
+// secret *corev1.Secret Kubernetes secret that contains any sensitive data/credentials
+func GetClientSettingsFromSecret(secret *corev1.Secret) (*ClientSettings, error) {
+	settings := &ClientSettings{}
+
+	if nil == secret {
+		return settings, nil
+	}
+
+	settings.APIURL = getSecretValue(secret, SecretKeyAPIURL)
+	settings.ProxyURL = getSecretValue(secret, SecretKeyProxyURL)
+
+	if len(secret.Data[SecretKeyCABundle]) > 0 {
+		settings.CABundle = secret.Data[SecretKeyCABundle]
+	}
+
+	requestTimeout := getSecretValue(secret, SecretKeyRequestTimeout)
+
+	if "" != requestTimeout {
+		timeout, err := time.ParseDuration(requestTimeout)
+		if nil != err || timeout <= 0 {
+			return nil, fmt.Errorf("secret key %q must contain a positive duration", SecretKeyRequestTimeout)
+		}
+
+		settings.RequestTimeout = timeout
+	}
+
+	err := settings.Validate()
+	if nil != err {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// Validate checks the IONOS API client settings for validity.
+func (settings *ClientSettings) Validate() error {
+	if "" != settings.APIURL && !isURLValid(settings.APIURL, true, "http", "https") {
+		return fmt.Errorf("IONOS API URL %q is invalid", settings.APIURL)
+	}
+
+	if "" != settings.ProxyURL && !isURLValid(settings.ProxyURL, false, "http", "https", "socks5") {
+		return fmt.Errorf("IONOS API proxy URL %q is invalid", settings.ProxyURL)
+	}
+
+	if len(settings.CABundle) > 0 && !x509.NewCertPool().AppendCertsFromPEM(settings.CABundle) {
+		return fmt.Errorf("IONOS API CA bundle does not contain any PEM encoded certificate")
+	}
+
+	if settings.RequestTimeout < 0 {
+		return fmt.Errorf("IONOS API request timeout must not be negative")
+	}
+
+	return nil
+}
+
+// isURLValid returns true if the value given is an absolute URL with one of the schemes given.
+//
+// PARAMETERS
+// value            string   URL to check
+// isSchemeOptional bool     True to accept values without scheme
+// schemes          []string Supported URL schemes
+func isURLValid(value string, isSchemeOptional bool, schemes ...string) bool {
+	if isSchemeOptional && !strings.Contains(value, "://") {
+		value = fmt.Sprintf("https://%s", value)
+	}
+
+	parsedURL, err := url.Parse(value)
+	if nil != err || "" == parsedURL.Host {
+		return false
+	}
+
+	for _, scheme := range schemes {
+		if scheme == parsedURL.Scheme {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,115 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apis is the main package for provider specific APIs
+package apis
+
+const (
+	// ServerTypeEnterprise is the IONOS server type with freely configurable cores and memory
+	ServerTypeEnterprise = "ENTERPRISE"
+	// ServerTypeCube is the IONOS server type with cores, memory and DAS root volume defined by a template
+	ServerTypeCube = "CUBE"
+)
+
+const (
+	// CPUFamilyAMDOpteron is the AMD Opteron CPU family
+	CPUFamilyAMDOpteron = "AMD_OPTERON"
+	// CPUFamilyIntelXeon is the Intel Xeon CPU family
+	CPUFamilyIntelXeon = "INTEL_XEON"
+	// CPUFamilyIntelSkylake is the Intel Skylake CPU family
+	CPUFamilyIntelSkylake = "INTEL_SKYLAKE"
+	// CPUFamilyIntelIcelake is the Intel Icelake CPU family
+	CPUFamilyIntelIcelake = "INTEL_ICELAKE"
+)
+
+const (
+	// VolumeTypeHDD is the IONOS HDD volume type
+	VolumeTypeHDD = "HDD"
+	// VolumeTypeSSDStandard is the IONOS SSD Standard volume type
+	VolumeTypeSSDStandard = "SSD Standard"
+	// VolumeTypeSSDPremium is the IONOS SSD Premium volume type
+	VolumeTypeSSDPremium = "SSD Premium"
+	// VolumeTypeDAS is the IONOS direct attached storage volume type
+	VolumeTypeDAS = "DAS"
+)
+
+const (
+	// VolumeBusVirtIO is the VIRTIO volume bus type
+	VolumeBusVirtIO = "VIRTIO"
+	// VolumeBusIDE is the IDE volume bus type
+	VolumeBusIDE = "IDE"
+)
+
+const (
+	// AvailabilityZoneAuto lets IONOS select the availability zone
+	AvailabilityZoneAuto = "AUTO"
+	// AvailabilityZone1 is the first IONOS availability zone
+	AvailabilityZone1 = "ZONE_1"
+	// AvailabilityZone2 is the second IONOS availability zone
+	AvailabilityZone2 = "ZONE_2"
+	// AvailabilityZone3 is the third IONOS availability zone (storage only)
+	AvailabilityZone3 = "ZONE_3"
+)
+
+const (
+	// FirewallTypeIngress is the firewall type filtering incoming traffic
+	FirewallTypeIngress = "INGRESS"
+	// FirewallTypeEgress is the firewall type filtering outgoing traffic
+	FirewallTypeEgress = "EGRESS"
+	// FirewallTypeBidirectional is the firewall type filtering incoming and outgoing traffic
+	FirewallTypeBidirectional = "BIDIRECTIONAL"
+)
+
+const (
+	// FirewallRuleProtocolTCP is the TCP firewall rule protocol
+	FirewallRuleProtocolTCP = "TCP"
+	// FirewallRuleProtocolUDP is the UDP firewall rule protocol
+	FirewallRuleProtocolUDP = "UDP"
+	// FirewallRuleProtocolICMP is the ICMP firewall rule protocol
+	FirewallRuleProtocolICMP = "ICMP"
+	// FirewallRuleProtocolAny is the firewall rule protocol matching all protocols
+	FirewallRuleProtocolAny = "ANY"
+)
+
+// FirewallRuleProtocols contains all supported firewall rule protocols
+var FirewallRuleProtocols = []string{FirewallRuleProtocolTCP, FirewallRuleProtocolUDP, FirewallRuleProtocolICMP, FirewallRuleProtocolAny}
+
+// FirewallRuleDirections contains all supported firewall rule directions
+var FirewallRuleDirections = []string{FirewallTypeIngress, FirewallTypeEgress}
+
+// FirewallTypes contains all supported firewall types
+var FirewallTypes = []string{FirewallTypeIngress, FirewallTypeEgress, FirewallTypeBidirectional}
+
+// ServerTypes contains all supported server types
+var ServerTypes = []string{ServerTypeEnterprise, ServerTypeCube}
+
+// CPUFamilies contains all supported CPU families
+var CPUFamilies = []string{CPUFamilyAMDOpteron, CPUFamilyIntelXeon, CPUFamilyIntelSkylake, CPUFamilyIntelIcelake}
+
+// DataVolumeTypes contains all volume types supported for data volumes
+var DataVolumeTypes = []string{VolumeTypeHDD, VolumeTypeSSDStandard, VolumeTypeSSDPremium}
+
+// RootVolumeTypes contains all volume types supported for root volumes
+var RootVolumeTypes = []string{VolumeTypeHDD, VolumeTypeSSDStandard, VolumeTypeSSDPremium, VolumeTypeDAS}
+
+// VolumeBusTypes contains all supported volume bus types
+var VolumeBusTypes = []string{VolumeBusVirtIO, VolumeBusIDE}
+
+// ServerAvailabilityZones contains all supported server availability zones
+var ServerAvailabilityZones = []string{AvailabilityZoneAuto, AvailabilityZone1, AvailabilityZone2}
+
+// VolumeAvailabilityZones contains all supported volume availability zones
+var VolumeAvailabilityZones = []string{AvailabilityZoneAuto, AvailabilityZone1, AvailabilityZone2, AvailabilityZone3}
@@ -0,0 +1,133 @@
+/*
+Copyright (c) 2021 SAP SE or an SAP affiliate company. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apis is the main package for provider specific APIs
+package apis
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// SecretKeyToken is the secret key containing an IONOS API token
+	SecretKeyToken = "token"
+	// SecretKeyUser is the secret key containing an IONOS user name
+	SecretKeyUser = "user"
+	// SecretKeyUserAlias is the documented alias of SecretKeyUser
+	SecretKeyUserAlias = "ionosUser"
+	// SecretKeyPassword is the secret key containing the password of the IONOS user
+	SecretKeyPassword = "password"
+	// SecretKeyPasswordAlias is the documented alias of SecretKeyPassword
+	SecretKeyPasswordAlias = "ionosPassword"
+)
+
+// Credentials contains the IONOS API credentials resolved from a secret
+type Credentials struct {
+	Token    string
+	User     string
+	Password string
+}
+
+// IsTokenBased returns true if the credentials contain an IONOS API token.
+func (credentials *Credentials) IsTokenBased() bool {
+	return "" != credentials.Token
+}
+
+// GetCredentialsFromSecret resolves the IONOS API credentials of the secret given. An API token takes precedence
+// over an user name and password pair.
+//
+// PARAMETERS
+// secret *corev1.Secret Kubernetes secret that contains any sensitive data/credentials
+func GetCredentialsFromSecret(secret *corev1.Secret) (*Credentials, error) {
+	if nil == secret {
+		return nil, errors.New("secret containing IONOS credentials is required")
+	}
+
+	token := getSecretValue(secret, SecretKeyToken)
+	if "" != token {
+		return &Credentials{Token: token}, nil
+	}
+
+	user, err := getSecretValueWithAlias(secret, SecretKeyUser, SecretKeyUserAlias, getSecretValue)
+	if nil != err {
+		return nil, err
+	}
+
+	password, err := getSecretValueWithAlias(secret, SecretKeyPassword, SecretKeyPasswordAlias, getSecretPassword)
+	if nil != err {
+		return nil, err
+	}
+
+	if "" == user && "" == password {
+		return nil, fmt.Errorf("secret must contain either %q or %q (%q) and %q (%q)", SecretKeyToken, SecretKeyUser, SecretKeyUserAlias, SecretKeyPassword, SecretKeyPasswordAlias)
+	} else if "" == user {
+		return nil, fmt.Errorf("secret must contain %q (%q) if a password is given", SecretKeyUser, SecretKeyUserAlias)
+	} else if "" == password {
+		return nil, fmt.Errorf("secret must contain %q (%q) if an user is given", SecretKeyPassword, SecretKeyPasswordAlias)
+	}
+
+	return &Credentials{User: user, Password: password}, nil
+}
+
+// getSecretValue returns the value of the secret key given without surrounding whitespace.
+//
+// PARAMETERS
+// secret *corev1.Secret Kubernetes secret to read
+// key    string         Secret key
+func getSecretValue(secret *corev1.Secret, key string) string {
+	return strings.TrimSpace(string(secret.Data[key]))
+}
+
+// getSecretPassword returns the password of the secret key given. Whitespace is significant in passwords and is
+// kept except for a trailing line break added by editors or "echo".
+//
+// PARAMETERS
+// secret *corev1.Secret Kubernetes secret to read
+// key    string         Secret key
+func getSecretPassword(secret *corev1.Secret, key string) string {
+	password := string(secret.Data[key])
+
+	if strings.HasSuffix(password, "\r\n") {
+		return strings.TrimSuffix(password, "\r\n")
+	}
+
+	return strings.TrimSuffix(password, "\n")
+}
+
+// getSecretValueWithAlias returns the value of the secret key or its alias read with the function given. It fails if
+// both are set to different values.
+//
+// PARAMETERS
+// secret   *corev1.Secret                                 Kubernetes secret to read
+// key      string                                         Secret key
+// alias    string                                         Alias of the secret key
+// getValue func(secret *corev1.Secret, key string) string Function to read a secret value with
+func getSecretValueWithAlias(secret *corev1.Secret, key, alias string, getValue func(secret *corev1.Secret, key string) string) (string, error) {
+	value := getValue(secret, key)
+	aliasValue := getValue(secret, alias)
+
+	if "" == value {
+		return aliasValue, nil
+	} else if "" != aliasValue && value != aliasValue {
+		return "", fmt.Errorf("secret keys %q and %q must not contain different values", key, alias)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apis is the main package for provider specific APIs
+package apis
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Constant labelValueEscapeCharacter introduces an escaped byte in an encoded label value
+const labelValueEscapeCharacter = '_'
+
+// EncodeLabelValue returns the label value for the string given. IONOS label values are encoded to be readable in
+// the Data Center Designer:
+//
+// - ASCII letters, digits and "-" are kept as they are
+// - all other bytes are replaced by "_" followed by their value as two lowercase hexadecimal digits
+//
+// As an example "shoot--dev/eu.1" is encoded as "shoot--dev_2feu_2e1". The encoding is reversible by DecodeLabelValue.
+//
+// PARAMETERS
+// value string Value to encode
+func EncodeLabelValue(value string) string {
+	var encoded strings.Builder
+
+	for _, character := range []byte(value) {
+		if isLabelValueCharacterKept(character) {
+			encoded.WriteByte(character)
+		} else {
+			fmt.Fprintf(&encoded, "%c%02x", labelValueEscapeCharacter, character)
+		}
+	}
+
+	return encoded.String()
+}
+
+// DecodeLabelValue returns the string encoded by EncodeLabelValue.
+//
+// PARAMETERS
+// value string Label value to decode
+func DecodeLabelValue(value string) (string, error) {
+	var decoded strings.Builder
+
+	for index := 0; index < len(value); index++ {
+		character := value[index]
+
+		if labelValueEscapeCharacter != character {
+			if !isLabelValueCharacterKept(character) {
+				return "", fmt.Errorf("label value %q contains the invalid character %q", value, character)
+			}
+
+			decoded.WriteByte(character)
+			continue
+		}
+
+		if index+2 >= len(value) {
+			return "", fmt.Errorf("label value %q ends with an incomplete escape sequence", value)
+		}
+
+		escaped, err := hex.DecodeString(value[index+1 : index+3])
+		if nil != err {
+			return "", fmt.Errorf("label value %q contains an invalid escape sequence: %v", value, err)
+		}
+
+		decoded.Write(escaped)
+		index += 2
+	}
+
+	return decoded.String(), nil
+}
+
+// IsLabelValueMatching returns true if the label value given encodes the string given. Previous versions labeled
+// resources with lowercase hex encoded values. For resources labeled by them a label value being valid lowercase hex
+// only matches if it decodes to exactly the string given.
+//
+// PARAMETERS
+// labelValue string Label value read
+// value      string Value expected
+// isLegacy   bool   True if the resource has been labeled by a previous version
+func IsLabelValueMatching(labelValue, value string, isLegacy bool) bool {
+	if isLegacy {
+		legacyValue, err := hex.DecodeString(labelValue)
+
+		if nil == err && hex.EncodeToString(legacyValue) == labelValue {
+			return value == string(legacyValue)
+		}
+	}
+
+	return EncodeLabelValue(value) == labelValue
+}
+
+// isLabelValueCharacterKept returns true if the character given is not escaped in label values.
+//
+// PARAMETERS
+// character byte Character to check
+func isLabelValueCharacterKept(character byte) bool {
+	return ('a' <= character && character <= 'z') || ('A' <= character && character <= 'Z') || ('0' <= character && character <= '9') || '-' == character
+}
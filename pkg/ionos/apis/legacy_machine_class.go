@@ -0,0 +1,57 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apis is the main package for provider specific APIs
+package apis
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Constant IonosMachineClassKind is the kind of the legacy provider specific machine class
+const IonosMachineClassKind = "IonosMachineClass"
+
+// Constant ProviderName is the provider name used for generic machine classes
+const ProviderName = "IONOS"
+
+// IonosMachineClass is the legacy provider specific machine class used by older clusters.
+type IonosMachineClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec IonosMachineClassSpec `json:"spec,omitempty"`
+}
+
+// IonosMachineClassSpec is the spec of the legacy provider specific machine class.
+type IonosMachineClassSpec struct {
+	DatacenterID string `json:"datacenterID,omitempty"`
+	Cluster      string `json:"cluster"`
+	Zone         string `json:"zone"`
+	Cores        uint   `json:"cores"`
+	Memory       uint   `json:"memory"`
+	ImageID      string `json:"imageID"`
+	SSHKey       string `json:"sshKey"`
+
+	FloatingPoolID string `json:"floatingPoolID,omitempty"`
+	// NetworkID is the deprecated network ID for the public facing network interface.
+	NetworkID  string      `json:"networkID,omitempty"`
+	NetworkIDs *NetworkIDs `json:"networkIDs,omitempty"`
+	VolumeSize float32     `json:"volumeSize,omitempty"`
+
+	SecretRef            *corev1.SecretReference `json:"secretRef,omitempty"`
+	CredentialsSecretRef *corev1.SecretReference `json:"credentialsSecretRef,omitempty"`
+}
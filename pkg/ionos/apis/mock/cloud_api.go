@@ -0,0 +1,672 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mock provides all methods required to simulate a driver
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
+)
+
+// Fault describes an error injected into all fake Cloud API requests matching its method and path.
+type Fault struct {
+	// Method of requests to match or empty for all methods
+	Method string
+	// Path pattern relative to the API base path as used by path.Match, e.g. "/datacenters/*/servers"
+	Path string
+	// StatusCode returned instead of executing the request
+	StatusCode int
+	// Message returned with the error
+	Message string
+	// Async accepts the request but lets its asynchronous execution fail
+	Async bool
+	// Count limits the number of requests affected or 0 for all requests
+	Count int
+
+	hits int
+}
+
+// FakeCloudAPI simulates the IONOS Cloud API v6 based on an in-memory store. Modified resources are BUSY until
+// their request has been executed asynchronously after TransitionDelay.
+type FakeCloudAPI struct {
+	mutex sync.Mutex
+
+	// TransitionDelay is the time modified resources stay BUSY. Requests are executed immediately if it is 0.
+	TransitionDelay time.Duration
+
+	datacenters map[string]*fakeDatacenter
+	images      map[string]*ionossdk.Image
+	ipBlocks    map[string]*ionossdk.IpBlock
+	requests    map[string]*ionossdk.RequestStatus
+	snapshots   map[string]*ionossdk.Snapshot
+	faults      []*Fault
+	requestLog  []string
+	ipCount     int
+}
+
+// fakeDatacenter holds all resources of a datacenter
+type fakeDatacenter struct {
+	datacenter *ionossdk.Datacenter
+	servers    map[string]*fakeServer
+	volumes    map[string]*fakeVolume
+}
+
+// fakeServer holds a server together with its attached resources
+type fakeServer struct {
+	server    *ionossdk.Server
+	volumeIDs []string
+	nics      []*fakeNic
+	labels    map[string]string
+}
+
+// fakeNic holds a NIC together with its firewall rules
+type fakeNic struct {
+	nic           *ionossdk.Nic
+	firewallRules []*ionossdk.FirewallRule
+}
+
+// fakeVolume holds a volume together with its labels
+type fakeVolume struct {
+	volume *ionossdk.Volume
+	labels map[string]string
+}
+
+// fakeRequest holds the state of a fake Cloud API request being processed
+type fakeRequest struct {
+	api       *FakeCloudAPI
+	req       *http.Request
+	path      []string
+	body      []byte
+	fault     *Fault
+	requestID string
+}
+
+// NewFakeCloudAPI returns a new fake Cloud API without any resources.
+func NewFakeCloudAPI() *FakeCloudAPI {
+	return &FakeCloudAPI{
+		datacenters: map[string]*fakeDatacenter{},
+		images:      map[string]*ionossdk.Image{},
+		ipBlocks:    map[string]*ionossdk.IpBlock{},
+		requests:    map[string]*ionossdk.RequestStatus{},
+		snapshots:   map[string]*ionossdk.Snapshot{},
+		faults:      []*Fault{},
+		requestLog:  []string{},
+	}
+}
+
+// SetupOnMux registers the fake Cloud API for all API paths of the mux given.
+//
+// PARAMETERS
+// mux *http.ServeMux Mux to add handler to
+func (api *FakeCloudAPI) SetupOnMux(mux *http.ServeMux) {
+	mux.Handle(apiBasePath+"/", api)
+}
+
+// newFakeMetadata returns new metadata of a resource in the state given.
+//
+// PARAMETERS
+// state string Resource state
+func newFakeMetadata(state string) *ionossdk.DatacenterElementMetadata {
+	now := ionossdk.IonosTime{Time: time.Now()}
+
+	return &ionossdk.DatacenterElementMetadata{
+		Etag:             ionossdk.PtrString(uuid.NewString()),
+		CreatedDate:      &now,
+		LastModifiedDate: &now,
+		State:            ionossdk.PtrString(state),
+	}
+}
+
+// newFakeType returns a pointer to the resource type given.
+//
+// PARAMETERS
+// resourceType ionossdk.Type Resource type
+func newFakeType(resourceType ionossdk.Type) *ionossdk.Type {
+	return &resourceType
+}
+
+// copyByJSON copies the JSON representation of the source to the destination given.
+//
+// PARAMETERS
+// source      interface{} Source value
+// destination interface{} Pointer to the destination value
+func copyByJSON(source, destination interface{}) {
+	data, err := json.Marshal(source)
+	if nil != err {
+		panic(err)
+	}
+
+	err = json.Unmarshal(data, destination)
+	if nil != err {
+		panic(err)
+	}
+}
+
+// getSortedKeys returns the keys of the map given sorted.
+//
+// PARAMETERS
+// values map[string]string Map to return keys for
+func getSortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// AddDatacenter adds an empty datacenter.
+//
+// PARAMETERS
+// datacenterID string Datacenter ID
+// location     string Datacenter location, e.g. "de/fra"
+func (api *FakeCloudAPI) AddDatacenter(datacenterID, location string) {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	api.datacenters[datacenterID] = &fakeDatacenter{
+		datacenter: &ionossdk.Datacenter{
+			Id:         ionossdk.PtrString(datacenterID),
+			Type:       newFakeType(ionossdk.DATACENTER),
+			Href:       ionossdk.PtrString(fmt.Sprintf("%s/datacenters/%s", apiBasePath, datacenterID)),
+			Metadata:   newFakeMetadata("AVAILABLE"),
+			Properties: &ionossdk.DatacenterProperties{Name: ionossdk.PtrString(datacenterID), Location: ionossdk.PtrString(location)},
+		},
+		servers: map[string]*fakeServer{},
+		volumes: map[string]*fakeVolume{},
+	}
+}
+
+// AddImage adds an image.
+//
+// PARAMETERS
+// imageID    string                   Image ID
+// properties ionossdk.ImageProperties Image properties
+func (api *FakeCloudAPI) AddImage(imageID string, properties ionossdk.ImageProperties) {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	api.images[imageID] = &ionossdk.Image{
+		Id:         ionossdk.PtrString(imageID),
+		Type:       newFakeType(ionossdk.IMAGE),
+		Href:       ionossdk.PtrString(fmt.Sprintf("%s/images/%s", apiBasePath, imageID)),
+		Metadata:   newFakeMetadata("AVAILABLE"),
+		Properties: &properties,
+	}
+}
+
+// SetImageCreatedDate sets the creation date of the image with the ID given.
+//
+// PARAMETERS
+// imageID     string    Image ID
+// createdDate time.Time Creation date to set
+func (api *FakeCloudAPI) SetImageCreatedDate(imageID string, createdDate time.Time) {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	image, ok := api.images[imageID]
+	if !ok {
+		panic(fmt.Sprintf("image %q does not exist", imageID))
+	}
+
+	image.Metadata.CreatedDate = &ionossdk.IonosTime{Time: createdDate}
+}
+
+// AddSnapshot adds a snapshot.
+//
+// PARAMETERS
+// snapshotID string                      Snapshot ID
+// properties ionossdk.SnapshotProperties Snapshot properties
+func (api *FakeCloudAPI) AddSnapshot(snapshotID string, properties ionossdk.SnapshotProperties) {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	api.snapshots[snapshotID] = &ionossdk.Snapshot{
+		Id:         ionossdk.PtrString(snapshotID),
+		Type:       newFakeType(ionossdk.SNAPSHOT),
+		Href:       ionossdk.PtrString(fmt.Sprintf("%s/snapshots/%s", apiBasePath, snapshotID)),
+		Metadata:   newFakeMetadata("AVAILABLE"),
+		Properties: &properties,
+	}
+}
+
+// AddIPBlock adds an IP block reserving the IPs given.
+//
+// PARAMETERS
+// ipBlockID string   IP block ID
+// location  string   IP block location, e.g. "de/fra"
+// ips       []string IPs reserved
+func (api *FakeCloudAPI) AddIPBlock(ipBlockID, location string, ips ...string) {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	api.ipBlocks[ipBlockID] = &ionossdk.IpBlock{
+		Id:       ionossdk.PtrString(ipBlockID),
+		Type:     newFakeType(ionossdk.IPBLOCK),
+		Href:     ionossdk.PtrString(fmt.Sprintf("%s/ipblocks/%s", apiBasePath, ipBlockID)),
+		Metadata: newFakeMetadata("AVAILABLE"),
+		Properties: &ionossdk.IpBlockProperties{
+			Ips:      &ips,
+			Location: ionossdk.PtrString(location),
+			Size:     ionossdk.PtrInt32(int32(len(ips))),
+		},
+	}
+}
+
+// AddServer adds an available server with the labels given and returns its ID.
+//
+// PARAMETERS
+// datacenterID string            Datacenter ID
+// server       ionossdk.Server   Server to add
+// labels       map[string]string Server labels
+func (api *FakeCloudAPI) AddServer(datacenterID string, server ionossdk.Server, labels map[string]string) string {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	datacenter, ok := api.datacenters[datacenterID]
+	if !ok {
+		panic(fmt.Sprintf("datacenter %q does not exist", datacenterID))
+	}
+
+	entry := newFakeServer(datacenterID, server, "AVAILABLE")
+	entry.server.Properties.VmState = ionossdk.PtrString("RUNNING")
+
+	for key, value := range labels {
+		entry.labels[key] = value
+	}
+
+	datacenter.servers[*entry.server.Id] = entry
+
+	return *entry.server.Id
+}
+
+// InjectFault adds the fault given to all matching requests.
+//
+// PARAMETERS
+// fault *Fault Fault to inject
+func (api *FakeCloudAPI) InjectFault(fault *Fault) {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	api.faults = append(api.faults, fault)
+}
+
+// ClearFaults removes all faults injected.
+func (api *FakeCloudAPI) ClearFaults() {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	api.faults = []*Fault{}
+}
+
+// GetRequestCount returns the number of requests received matching the method and path pattern given.
+//
+// PARAMETERS
+// method      string HTTP method or empty for all methods
+// pathPattern string Path pattern relative to the API base path as used by path.Match
+func (api *FakeCloudAPI) GetRequestCount(method, pathPattern string) int {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	count := 0
+
+	for _, entry := range api.requestLog {
+		entryData := strings.SplitN(entry, " ", 2)
+
+		if "" != method && method != entryData[0] {
+			continue
+		}
+
+		if ok, _ := path.Match(pathPattern, entryData[1]); ok {
+			count++
+		}
+	}
+
+	return count
+}
+
+// GetServer returns a copy of the server with the ID given including its attached resources.
+//
+// PARAMETERS
+// datacenterID string Datacenter ID
+// serverID     string Server ID
+func (api *FakeCloudAPI) GetServer(datacenterID, serverID string) (ionossdk.Server, bool) {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	var server ionossdk.Server
+
+	datacenter, ok := api.datacenters[datacenterID]
+	if !ok {
+		return server, false
+	}
+
+	entry, ok := datacenter.servers[serverID]
+	if !ok {
+		return server, false
+	}
+
+	copyByJSON(api.renderServer(datacenter, entry), &server)
+
+	return server, true
+}
+
+// GetServerIDs returns the IDs of all servers of the datacenter given.
+//
+// PARAMETERS
+// datacenterID string Datacenter ID
+func (api *FakeCloudAPI) GetServerIDs(datacenterID string) []string {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	serverIDs := []string{}
+
+	if datacenter, ok := api.datacenters[datacenterID]; ok {
+		for serverID := range datacenter.servers {
+			serverIDs = append(serverIDs, serverID)
+		}
+	}
+
+	sort.Strings(serverIDs)
+
+	return serverIDs
+}
+
+// GetServerLabels returns a copy of the labels of the server with the ID given.
+//
+// PARAMETERS
+// datacenterID string Datacenter ID
+// serverID     string Server ID
+func (api *FakeCloudAPI) GetServerLabels(datacenterID, serverID string) map[string]string {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	labels := map[string]string{}
+
+	if datacenter, ok := api.datacenters[datacenterID]; ok {
+		if entry, ok := datacenter.servers[serverID]; ok {
+			for key, value := range entry.labels {
+				labels[key] = value
+			}
+		}
+	}
+
+	return labels
+}
+
+// GetVolume returns a copy of the volume with the ID given.
+//
+// PARAMETERS
+// datacenterID string Datacenter ID
+// volumeID     string Volume ID
+func (api *FakeCloudAPI) GetVolume(datacenterID, volumeID string) (ionossdk.Volume, bool) {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	var volume ionossdk.Volume
+
+	datacenter, ok := api.datacenters[datacenterID]
+	if !ok {
+		return volume, false
+	}
+
+	entry, ok := datacenter.volumes[volumeID]
+	if !ok {
+		return volume, false
+	}
+
+	copyByJSON(entry.volume, &volume)
+
+	return volume, true
+}
+
+// GetVolumeIDs returns the IDs of all volumes of the datacenter given.
+//
+// PARAMETERS
+// datacenterID string Datacenter ID
+func (api *FakeCloudAPI) GetVolumeIDs(datacenterID string) []string {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	volumeIDs := []string{}
+
+	if datacenter, ok := api.datacenters[datacenterID]; ok {
+		for volumeID := range datacenter.volumes {
+			volumeIDs = append(volumeIDs, volumeID)
+		}
+	}
+
+	sort.Strings(volumeIDs)
+
+	return volumeIDs
+}
+
+// ServeHTTP handles a request of the fake Cloud API.
+//
+// PARAMETERS
+// res http.ResponseWriter HTTP response writer
+// req *http.Request       HTTP request
+func (api *FakeCloudAPI) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	relativePath := "/" + strings.Trim(strings.TrimPrefix(req.URL.Path, apiBasePath), "/")
+
+	request := &fakeRequest{
+		api:  api,
+		req:  req,
+		path: strings.Split(strings.TrimPrefix(relativePath, "/"), "/"),
+	}
+
+	if nil != req.Body {
+		body, err := io.ReadAll(req.Body)
+		if nil != err {
+			writeFakeResponse(res, http.StatusBadRequest, newFakeError(http.StatusBadRequest, err.Error()))
+			return
+		}
+
+		request.body = body
+	}
+
+	api.mutex.Lock()
+
+	api.requestLog = append(api.requestLog, fmt.Sprintf("%s %s", req.Method, relativePath))
+	fault := api.getFault(req.Method, relativePath)
+
+	if nil != fault && !fault.Async {
+		api.mutex.Unlock()
+		writeFakeResponse(res, fault.StatusCode, newFakeError(fault.StatusCode, fault.Message))
+
+		return
+	}
+
+	request.fault = fault
+
+	statusCode, body := request.route()
+	data, err := json.Marshal(body)
+
+	api.mutex.Unlock()
+
+	if nil != err {
+		writeFakeResponse(res, http.StatusInternalServerError, newFakeError(http.StatusInternalServerError, err.Error()))
+		return
+	}
+
+	if "" != request.requestID {
+		res.Header().Set("Location", fmt.Sprintf("http://%s%s/requests/%s/status", req.Host, apiBasePath, request.requestID))
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(statusCode)
+
+	if nil != body {
+		_, _ = res.Write(data)
+	}
+}
+
+// getFault returns the first injected fault matching the request given and counts it. The mutex must be held.
+//
+// PARAMETERS
+// method       string HTTP method
+// relativePath string Path relative to the API base path
+func (api *FakeCloudAPI) getFault(method, relativePath string) *Fault {
+	for _, fault := range api.faults {
+		if "" != fault.Method && method != fault.Method {
+			continue
+		}
+
+		if ok, _ := path.Match(fault.Path, relativePath); !ok {
+			continue
+		}
+
+		if fault.Count > 0 && fault.hits >= fault.Count {
+			continue
+		}
+
+		fault.hits++
+
+		return fault
+	}
+
+	return nil
+}
+
+// newFakeError returns an IONOS API error body.
+//
+// PARAMETERS
+// statusCode int    HTTP status code
+// message    string Error message
+func newFakeError(statusCode int, message string) *ionossdk.Error {
+	return &ionossdk.Error{
+		HttpStatus: ionossdk.PtrInt32(int32(statusCode)),
+		Messages: &[]ionossdk.ErrorMessage{
+			{ErrorCode: ionossdk.PtrString(fmt.Sprintf("%d", statusCode)), Message: ionossdk.PtrString(message)},
+		},
+	}
+}
+
+// writeFakeResponse writes the JSON encoded body given.
+//
+// PARAMETERS
+// res        http.ResponseWriter HTTP response writer
+// statusCode int                 HTTP status code
+// body       interface{}         Response body
+func writeFakeResponse(res http.ResponseWriter, statusCode int, body interface{}) {
+	data, _ := json.Marshal(body)
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(statusCode)
+	_, _ = res.Write(data)
+}
+
+// errorResponse returns an error response.
+//
+// PARAMETERS
+// statusCode int    HTTP status code
+// format     string Error message format
+// args       ...interface{} Error message arguments
+func (r *fakeRequest) errorResponse(statusCode int, format string, args ...interface{}) (int, interface{}) {
+	return statusCode, newFakeError(statusCode, fmt.Sprintf(format, args...))
+}
+
+// decodeBody decodes the JSON request body into the value given.
+//
+// PARAMETERS
+// value interface{} Pointer to the value to decode into
+func (r *fakeRequest) decodeBody(value interface{}) error {
+	return json.Unmarshal(r.body, value)
+}
+
+// startRequest registers an asynchronous request setting the metadata given BUSY. Once executed the metadata is set
+// AVAILABLE and the completion callback given is called with the mutex held.
+//
+// PARAMETERS
+// complete func()                                Completion callback or nil
+// metadata ...*ionossdk.DatacenterElementMetadata Metadata of resources modified
+func (r *fakeRequest) startRequest(complete func(), metadata ...*ionossdk.DatacenterElementMetadata) {
+	api := r.api
+	requestID := uuid.NewString()
+
+	requestStatus := &ionossdk.RequestStatus{
+		Id:       ionossdk.PtrString(requestID),
+		Type:     newFakeType(ionossdk.REQUEST_STATUS),
+		Href:     ionossdk.PtrString(fmt.Sprintf("%s/requests/%s/status", apiBasePath, requestID)),
+		Metadata: &ionossdk.RequestStatusMetadata{Status: ionossdk.PtrString("QUEUED"), Message: ionossdk.PtrString("Request has been queued")},
+	}
+
+	api.requests[requestID] = requestStatus
+	r.requestID = requestID
+
+	for _, entry := range metadata {
+		entry.State = ionossdk.PtrString("BUSY")
+	}
+
+	fault := r.fault
+
+	execute := func() {
+		for _, entry := range metadata {
+			entry.LastModifiedDate = &ionossdk.IonosTime{Time: time.Now()}
+		}
+
+		if nil != fault {
+			requestStatus.Metadata.Status = ionossdk.PtrString("FAILED")
+			requestStatus.Metadata.Message = ionossdk.PtrString(fault.Message)
+
+			for _, entry := range metadata {
+				entry.State = ionossdk.PtrString("FAILED")
+			}
+
+			return
+		}
+
+		for _, entry := range metadata {
+			entry.State = ionossdk.PtrString("AVAILABLE")
+		}
+
+		if nil != complete {
+			complete()
+		}
+
+		requestStatus.Metadata.Status = ionossdk.PtrString("DONE")
+		requestStatus.Metadata.Message = ionossdk.PtrString("Request has been successfully executed")
+	}
+
+	if 0 == api.TransitionDelay {
+		execute()
+		return
+	}
+
+	requestStatus.Metadata.Status = ionossdk.PtrString("RUNNING")
+	requestStatus.Metadata.Message = ionossdk.PtrString("Request is being executed")
+
+	time.AfterFunc(api.TransitionDelay, func() {
+		api.mutex.Lock()
+		defer api.mutex.Unlock()
+
+		execute()
+	})
+}
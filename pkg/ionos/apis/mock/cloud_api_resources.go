@@ -0,0 +1,1021 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mock provides all methods required to simulate a driver
+package mock
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
+)
+
+// route dispatches the request to the handler of the resource addressed. The mutex must be held.
+func (r *fakeRequest) route() (int, interface{}) {
+	segments := r.path
+	method := r.req.Method
+
+	switch {
+	case 1 == len(segments) && "datacenters" == segments[0] && http.MethodGet == method:
+		return r.listDatacenters()
+	case 1 == len(segments) && "images" == segments[0] && http.MethodGet == method:
+		return r.listImages()
+	case 2 == len(segments) && "images" == segments[0] && http.MethodGet == method:
+		return r.getImage(segments[1])
+	case 1 == len(segments) && "snapshots" == segments[0] && http.MethodGet == method:
+		return r.listSnapshots()
+	case 2 == len(segments) && "snapshots" == segments[0] && http.MethodGet == method:
+		return r.getSnapshot(segments[1])
+	case 1 == len(segments) && "ipblocks" == segments[0] && http.MethodGet == method:
+		return r.listIPBlocks()
+	case 2 == len(segments) && "ipblocks" == segments[0] && http.MethodGet == method:
+		return r.getIPBlock(segments[1])
+	case 1 == len(segments) && "labels" == segments[0] && http.MethodGet == method:
+		return r.listLabels()
+	case 3 == len(segments) && "requests" == segments[0] && "status" == segments[2] && http.MethodGet == method:
+		return r.getRequestStatus(segments[1])
+	case len(segments) >= 2 && "datacenters" == segments[0]:
+		datacenter, ok := r.api.datacenters[segments[1]]
+		if !ok {
+			return r.errorResponse(http.StatusNotFound, "Datacenter %q does not exist", segments[1])
+		}
+
+		return r.routeDatacenter(datacenter, segments[2:])
+	}
+
+	return r.errorResponse(http.StatusNotFound, "Resource %q is not supported", r.req.URL.Path)
+}
+
+// routeDatacenter dispatches the request to the handler of the datacenter resource addressed.
+//
+// PARAMETERS
+// datacenter *fakeDatacenter Datacenter addressed
+// segments   []string        Path segments relative to the datacenter
+func (r *fakeRequest) routeDatacenter(datacenter *fakeDatacenter, segments []string) (int, interface{}) {
+	method := r.req.Method
+
+	if 0 == len(segments) {
+		if http.MethodGet == method {
+			return http.StatusOK, datacenter.datacenter
+		}
+
+		return r.errorResponse(http.StatusMethodNotAllowed, "Method %s is not supported", method)
+	}
+
+	switch segments[0] {
+	case "servers":
+		if 1 == len(segments) {
+			switch method {
+			case http.MethodGet:
+				return r.listServers(datacenter)
+			case http.MethodPost:
+				return r.createServer(datacenter)
+			}
+
+			break
+		}
+
+		server, ok := datacenter.servers[segments[1]]
+		if !ok {
+			return r.errorResponse(http.StatusNotFound, "Server %q does not exist", segments[1])
+		}
+
+		return r.routeServer(datacenter, server, segments[2:])
+	case "volumes":
+		if 1 == len(segments) {
+			switch method {
+			case http.MethodGet:
+				return r.listVolumes(datacenter)
+			case http.MethodPost:
+				return r.createVolume(datacenter)
+			}
+
+			break
+		}
+
+		volume, ok := datacenter.volumes[segments[1]]
+		if !ok {
+			return r.errorResponse(http.StatusNotFound, "Volume %q does not exist", segments[1])
+		}
+
+		if 2 == len(segments) {
+			switch method {
+			case http.MethodGet:
+				return http.StatusOK, volume.volume
+			case http.MethodDelete:
+				return r.deleteVolume(datacenter, volume)
+			}
+
+			break
+		}
+
+		if "labels" == segments[2] {
+			return r.routeLabels(volume.labels, segments[3:])
+		}
+	}
+
+	return r.errorResponse(http.StatusNotFound, "Resource %q is not supported", r.req.URL.Path)
+}
+
+// routeServer dispatches the request to the handler of the server resource addressed.
+//
+// PARAMETERS
+// datacenter *fakeDatacenter Datacenter of the server
+// server     *fakeServer     Server addressed
+// segments   []string        Path segments relative to the server
+func (r *fakeRequest) routeServer(datacenter *fakeDatacenter, server *fakeServer, segments []string) (int, interface{}) {
+	method := r.req.Method
+
+	if 0 == len(segments) {
+		switch method {
+		case http.MethodGet:
+			return http.StatusOK, r.api.renderServer(datacenter, server)
+		case http.MethodDelete:
+			return r.deleteServer(datacenter, server)
+		}
+
+		return r.errorResponse(http.StatusMethodNotAllowed, "Method %s is not supported", method)
+	}
+
+	switch {
+	case 1 == len(segments) && http.MethodPost == method && ("start" == segments[0] || "stop" == segments[0] || "reboot" == segments[0]):
+		return r.setServerVMState(server, segments[0])
+	case "labels" == segments[0]:
+		return r.routeLabels(server.labels, segments[1:])
+	case "volumes" == segments[0] && 1 == len(segments):
+		switch method {
+		case http.MethodGet:
+			return http.StatusOK, r.api.renderAttachedVolumes(datacenter, server)
+		case http.MethodPost:
+			return r.attachVolume(datacenter, server)
+		}
+	case "nics" == segments[0] && 1 == len(segments):
+		switch method {
+		case http.MethodGet:
+			return http.StatusOK, r.api.renderNics(server)
+		case http.MethodPost:
+			return r.createNic(datacenter, server)
+		}
+	case "nics" == segments[0]:
+		nic := server.getNic(segments[1])
+		if nil == nic {
+			return r.errorResponse(http.StatusNotFound, "NIC %q does not exist", segments[1])
+		}
+
+		switch {
+		case 2 == len(segments) && http.MethodGet == method:
+			return http.StatusOK, r.api.renderNic(nic)
+		case 2 == len(segments) && http.MethodDelete == method:
+			return r.deleteNic(server, nic)
+		case 3 == len(segments) && "firewallrules" == segments[2] && http.MethodGet == method:
+			return http.StatusOK, r.api.renderFirewallRules(nic)
+		case 3 == len(segments) && "firewallrules" == segments[2] && http.MethodPost == method:
+			return r.createFirewallRule(nic)
+		}
+	}
+
+	return r.errorResponse(http.StatusNotFound, "Resource %q is not supported", r.req.URL.Path)
+}
+
+// routeLabels dispatches the request to the label handler of a resource.
+//
+// PARAMETERS
+// labels   map[string]string Labels of the resource addressed
+// segments []string          Path segments relative to the labels
+func (r *fakeRequest) routeLabels(labels map[string]string, segments []string) (int, interface{}) {
+	method := r.req.Method
+
+	if 0 == len(segments) {
+		switch method {
+		case http.MethodGet:
+			items := []ionossdk.LabelResource{}
+
+			for _, key := range getSortedKeys(labels) {
+				items = append(items, newFakeLabelResource(key, labels[key]))
+			}
+
+			return http.StatusOK, &ionossdk.LabelResources{Type: ionossdk.PtrString("collection"), Items: &items}
+		case http.MethodPost:
+			var label ionossdk.LabelResource
+
+			err := r.decodeBody(&label)
+			if nil != err || !label.HasProperties() || !label.Properties.HasKey() || !label.Properties.HasValue() {
+				return r.errorResponse(http.StatusBadRequest, "Label key and value are required")
+			}
+
+			key := *label.Properties.Key
+
+			if _, ok := labels[key]; ok {
+				return r.errorResponse(http.StatusUnprocessableEntity, "Label %q already exists", key)
+			}
+
+			labels[key] = *label.Properties.Value
+
+			return http.StatusCreated, newFakeLabelResource(key, labels[key])
+		}
+	} else if 1 == len(segments) {
+		value, ok := labels[segments[0]]
+		if !ok {
+			return r.errorResponse(http.StatusNotFound, "Label %q does not exist", segments[0])
+		}
+
+		switch method {
+		case http.MethodGet:
+			return http.StatusOK, newFakeLabelResource(segments[0], value)
+		case http.MethodDelete:
+			delete(labels, segments[0])
+			return http.StatusAccepted, nil
+		}
+	}
+
+	return r.errorResponse(http.StatusNotFound, "Resource %q is not supported", r.req.URL.Path)
+}
+
+// newFakeLabelResource returns the label resource given.
+//
+// PARAMETERS
+// key   string Label key
+// value string Label value
+func newFakeLabelResource(key, value string) ionossdk.LabelResource {
+	return ionossdk.LabelResource{
+		Id:         ionossdk.PtrString(key),
+		Type:       ionossdk.PtrString("label"),
+		Properties: &ionossdk.LabelResourceProperties{Key: ionossdk.PtrString(key), Value: ionossdk.PtrString(value)},
+	}
+}
+
+// listDatacenters returns all datacenters.
+func (r *fakeRequest) listDatacenters() (int, interface{}) {
+	items := []ionossdk.Datacenter{}
+
+	for _, datacenter := range r.api.datacenters {
+		items = append(items, *datacenter.datacenter)
+	}
+
+	sort.Slice(items, func(i, j int) bool { return *items[i].Id < *items[j].Id })
+
+	return http.StatusOK, &ionossdk.Datacenters{Type: newFakeType("collection"), Items: &items}
+}
+
+// listImages returns all images.
+func (r *fakeRequest) listImages() (int, interface{}) {
+	items := []ionossdk.Image{}
+
+	for _, image := range r.api.images {
+		items = append(items, *image)
+	}
+
+	sort.Slice(items, func(i, j int) bool { return *items[i].Id < *items[j].Id })
+
+	return http.StatusOK, &ionossdk.Images{Type: newFakeType("collection"), Items: &items}
+}
+
+// getImage returns the image with the ID given.
+//
+// PARAMETERS
+// imageID string Image ID
+func (r *fakeRequest) getImage(imageID string) (int, interface{}) {
+	image, ok := r.api.images[imageID]
+	if !ok {
+		return r.errorResponse(http.StatusNotFound, "Image %q does not exist", imageID)
+	}
+
+	return http.StatusOK, image
+}
+
+// listSnapshots returns all snapshots.
+func (r *fakeRequest) listSnapshots() (int, interface{}) {
+	items := []ionossdk.Snapshot{}
+
+	for _, snapshot := range r.api.snapshots {
+		items = append(items, *snapshot)
+	}
+
+	sort.Slice(items, func(i, j int) bool { return *items[i].Id < *items[j].Id })
+
+	return http.StatusOK, &ionossdk.Snapshots{Type: newFakeType("collection"), Items: &items}
+}
+
+// getSnapshot returns the snapshot with the ID given.
+//
+// PARAMETERS
+// snapshotID string Snapshot ID
+func (r *fakeRequest) getSnapshot(snapshotID string) (int, interface{}) {
+	snapshot, ok := r.api.snapshots[snapshotID]
+	if !ok {
+		return r.errorResponse(http.StatusNotFound, "Snapshot %q does not exist", snapshotID)
+	}
+
+	return http.StatusOK, snapshot
+}
+
+// listIPBlocks returns all IP blocks.
+func (r *fakeRequest) listIPBlocks() (int, interface{}) {
+	items := []ionossdk.IpBlock{}
+
+	for ipBlockID := range r.api.ipBlocks {
+		items = append(items, r.api.renderIPBlock(ipBlockID))
+	}
+
+	sort.Slice(items, func(i, j int) bool { return *items[i].Id < *items[j].Id })
+
+	return http.StatusOK, &ionossdk.IpBlocks{Type: newFakeType("collection"), Items: &items}
+}
+
+// getIPBlock returns the IP block with the ID given.
+//
+// PARAMETERS
+// ipBlockID string IP block ID
+func (r *fakeRequest) getIPBlock(ipBlockID string) (int, interface{}) {
+	if _, ok := r.api.ipBlocks[ipBlockID]; !ok {
+		return r.errorResponse(http.StatusNotFound, "IP block %q does not exist", ipBlockID)
+	}
+
+	return http.StatusOK, r.api.renderIPBlock(ipBlockID)
+}
+
+// listLabels returns the labels of all servers and volumes matching the "filter.<property>" query parameters. As
+// with the IONOS Cloud API filters match case-insensitive parts of the label properties.
+func (r *fakeRequest) listLabels() (int, interface{}) {
+	items := []ionossdk.Label{}
+	query := r.req.URL.Query()
+
+	isMatching := func(properties map[string]string) bool {
+		for parameter := range query {
+			if !strings.HasPrefix(parameter, "filter.") {
+				continue
+			}
+
+			value := strings.ToLower(properties[strings.TrimPrefix(parameter, "filter.")])
+
+			if !strings.Contains(value, strings.ToLower(query.Get(parameter))) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	addLabels := func(resourceType, resourceID, resourceHref string, labels map[string]string) {
+		for _, key := range getSortedKeys(labels) {
+			properties := map[string]string{
+				"key":          key,
+				"value":        labels[key],
+				"resourceId":   resourceID,
+				"resourceType": resourceType,
+				"resourceHref": resourceHref,
+			}
+
+			if !isMatching(properties) {
+				continue
+			}
+
+			items = append(items, ionossdk.Label{
+				Id:   ionossdk.PtrString(fmt.Sprintf("urn:label:%s:%s:%s", resourceType, resourceID, key)),
+				Type: ionossdk.PtrString("label"),
+				Properties: &ionossdk.LabelProperties{
+					Key:          ionossdk.PtrString(key),
+					Value:        ionossdk.PtrString(labels[key]),
+					ResourceId:   ionossdk.PtrString(resourceID),
+					ResourceType: ionossdk.PtrString(resourceType),
+					ResourceHref: ionossdk.PtrString(resourceHref),
+				},
+			})
+		}
+	}
+
+	for _, datacenter := range r.api.datacenters {
+		for _, server := range datacenter.servers {
+			addLabels("server", *server.server.Id, *server.server.Href, server.labels)
+		}
+
+		for _, volume := range datacenter.volumes {
+			addLabels("volume", *volume.volume.Id, *volume.volume.Href, volume.labels)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool { return *items[i].Id < *items[j].Id })
+
+	return http.StatusOK, &ionossdk.Labels{Type: ionossdk.PtrString("collection"), Items: &items}
+}
+
+// getRequestStatus returns the status of the request with the ID given.
+//
+// PARAMETERS
+// requestID string Request ID
+func (r *fakeRequest) getRequestStatus(requestID string) (int, interface{}) {
+	requestStatus, ok := r.api.requests[requestID]
+	if !ok {
+		return r.errorResponse(http.StatusNotFound, "Request %q does not exist", requestID)
+	}
+
+	return http.StatusOK, requestStatus
+}
+
+// newFakeServer returns a new server entry based on the server given.
+//
+// PARAMETERS
+// datacenterID string          Datacenter ID
+// server       ionossdk.Server Server requested
+// state        string          Initial resource state
+func newFakeServer(datacenterID string, server ionossdk.Server, state string) *fakeServer {
+	serverID := uuid.NewString()
+	properties := ionossdk.ServerProperties{}
+
+	if server.HasProperties() {
+		properties = *server.Properties
+	}
+
+	if !properties.HasType() {
+		properties.Type = ionossdk.PtrString("ENTERPRISE")
+	}
+
+	properties.VmState = ionossdk.PtrString("SHUTOFF")
+
+	return &fakeServer{
+		server: &ionossdk.Server{
+			Id:         ionossdk.PtrString(serverID),
+			Type:       newFakeType(ionossdk.SERVER),
+			Href:       ionossdk.PtrString(fmt.Sprintf("%s/datacenters/%s/servers/%s", apiBasePath, datacenterID, serverID)),
+			Metadata:   newFakeMetadata(state),
+			Properties: &properties,
+		},
+		volumeIDs: []string{},
+		nics:      []*fakeNic{},
+		labels:    map[string]string{},
+	}
+}
+
+// getNic returns the NIC with the ID given or nil if it does not exist.
+//
+// PARAMETERS
+// nicID string NIC ID
+func (server *fakeServer) getNic(nicID string) *fakeNic {
+	for _, nic := range server.nics {
+		if nicID == *nic.nic.Id {
+			return nic
+		}
+	}
+
+	return nil
+}
+
+// listServers returns all servers of the datacenter given.
+//
+// PARAMETERS
+// datacenter *fakeDatacenter Datacenter
+func (r *fakeRequest) listServers(datacenter *fakeDatacenter) (int, interface{}) {
+	items := []ionossdk.Server{}
+
+	for _, server := range datacenter.servers {
+		items = append(items, r.api.renderServer(datacenter, server))
+	}
+
+	sort.Slice(items, func(i, j int) bool { return *items[i].Id < *items[j].Id })
+
+	return http.StatusOK, &ionossdk.Servers{Type: newFakeType("collection"), Items: &items}
+}
+
+// createServer creates the server requested together with its volumes and NICs.
+//
+// PARAMETERS
+// datacenter *fakeDatacenter Datacenter
+func (r *fakeRequest) createServer(datacenter *fakeDatacenter) (int, interface{}) {
+	var serverRequested ionossdk.Server
+
+	err := r.decodeBody(&serverRequested)
+	if nil != err || !serverRequested.HasProperties() || !serverRequested.Properties.HasName() {
+		return r.errorResponse(http.StatusUnprocessableEntity, "Server name is required")
+	}
+
+	metadata := []*ionossdk.DatacenterElementMetadata{}
+	server := newFakeServer(*datacenter.datacenter.Id, serverRequested, "BUSY")
+
+	if server.server.Properties.HasBootVolume() {
+		if _, ok := datacenter.volumes[*server.server.Properties.BootVolume.Id]; !ok {
+			return r.errorResponse(http.StatusUnprocessableEntity, "Boot volume %q does not exist", *server.server.Properties.BootVolume.Id)
+		}
+	}
+
+	if serverRequested.HasEntities() && serverRequested.Entities.HasVolumes() && serverRequested.Entities.Volumes.HasItems() {
+		for _, volume := range *serverRequested.Entities.Volumes.Items {
+			if volume.HasId() {
+				if _, ok := datacenter.volumes[*volume.Id]; !ok {
+					return r.errorResponse(http.StatusUnprocessableEntity, "Volume %q does not exist", *volume.Id)
+				}
+
+				server.volumeIDs = append(server.volumeIDs, *volume.Id)
+
+				continue
+			}
+
+			entry, statusCode, message := r.api.newFakeVolume(datacenter, volume)
+			if nil == entry {
+				return r.errorResponse(statusCode, "%s", message)
+			}
+
+			datacenter.volumes[*entry.volume.Id] = entry
+			server.volumeIDs = append(server.volumeIDs, *entry.volume.Id)
+			metadata = append(metadata, entry.volume.Metadata)
+
+			if !server.server.Properties.HasBootVolume() {
+				server.server.Properties.BootVolume = &ionossdk.ResourceReference{Id: entry.volume.Id}
+			}
+		}
+	}
+
+	if serverRequested.HasEntities() && serverRequested.Entities.HasNics() && serverRequested.Entities.Nics.HasItems() {
+		for _, nicRequested := range *serverRequested.Entities.Nics.Items {
+			nic, statusCode, message := r.api.newFakeNic(server, nicRequested)
+			if nil == nic {
+				return r.errorResponse(statusCode, "%s", message)
+			}
+
+			server.nics = append(server.nics, nic)
+			metadata = append(metadata, nic.nic.Metadata)
+		}
+	}
+
+	datacenter.servers[*server.server.Id] = server
+	metadata = append(metadata, server.server.Metadata)
+
+	r.startRequest(func() {
+		server.server.Properties.VmState = ionossdk.PtrString("RUNNING")
+	}, metadata...)
+
+	return http.StatusAccepted, r.api.renderServer(datacenter, server)
+}
+
+// deleteServer deletes the server given. Attached volumes are detached but not deleted.
+//
+// PARAMETERS
+// datacenter *fakeDatacenter Datacenter
+// server     *fakeServer     Server to delete
+func (r *fakeRequest) deleteServer(datacenter *fakeDatacenter, server *fakeServer) (int, interface{}) {
+	r.startRequest(func() {
+		delete(datacenter.servers, *server.server.Id)
+	}, server.server.Metadata)
+
+	return http.StatusAccepted, nil
+}
+
+// setServerVMState executes the VM state action given.
+//
+// PARAMETERS
+// server *fakeServer Server
+// action string      "start", "stop" or "reboot"
+func (r *fakeRequest) setServerVMState(server *fakeServer, action string) (int, interface{}) {
+	vmState := "RUNNING"
+
+	if "stop" == action {
+		vmState = "SHUTOFF"
+	}
+
+	r.startRequest(func() {
+		server.server.Properties.VmState = ionossdk.PtrString(vmState)
+	}, server.server.Metadata)
+
+	return http.StatusAccepted, nil
+}
+
+// attachVolume attaches the volume requested to the server given.
+//
+// PARAMETERS
+// datacenter *fakeDatacenter Datacenter
+// server     *fakeServer     Server
+func (r *fakeRequest) attachVolume(datacenter *fakeDatacenter, server *fakeServer) (int, interface{}) {
+	var volumeRequested ionossdk.Volume
+
+	err := r.decodeBody(&volumeRequested)
+	if nil != err || !volumeRequested.HasId() {
+		return r.errorResponse(http.StatusUnprocessableEntity, "Volume ID is required")
+	}
+
+	volume, ok := datacenter.volumes[*volumeRequested.Id]
+	if !ok {
+		return r.errorResponse(http.StatusNotFound, "Volume %q does not exist", *volumeRequested.Id)
+	}
+
+	for _, volumeID := range server.volumeIDs {
+		if *volumeRequested.Id == volumeID {
+			return r.errorResponse(http.StatusUnprocessableEntity, "Volume %q is already attached", volumeID)
+		}
+	}
+
+	server.volumeIDs = append(server.volumeIDs, *volumeRequested.Id)
+	r.startRequest(nil, server.server.Metadata, volume.volume.Metadata)
+
+	return http.StatusAccepted, volume.volume
+}
+
+// newFakeVolume returns a new volume entry based on the volume given or the HTTP status code and message of the
+// validation failure.
+//
+// PARAMETERS
+// datacenter *fakeDatacenter Datacenter
+// volume     ionossdk.Volume Volume requested
+func (api *FakeCloudAPI) newFakeVolume(datacenter *fakeDatacenter, volume ionossdk.Volume) (*fakeVolume, int, string) {
+	if !volume.HasProperties() || !volume.Properties.HasSize() {
+		return nil, http.StatusUnprocessableEntity, "Volume size is required"
+	}
+
+	properties := *volume.Properties
+
+	// IONOS accepts image and snapshot IDs as volume image
+	if properties.HasImage() {
+		_, isImage := api.images[*properties.Image]
+		_, isSnapshot := api.snapshots[*properties.Image]
+
+		if !isImage && !isSnapshot {
+			return nil, http.StatusUnprocessableEntity, fmt.Sprintf("Image %q does not exist", *properties.Image)
+		}
+	}
+
+	if properties.HasImageAlias() {
+		location := *datacenter.datacenter.Properties.Location
+		imageID := api.getImageIDForAlias(*properties.ImageAlias, location)
+
+		if "" == imageID {
+			return nil, http.StatusUnprocessableEntity, fmt.Sprintf("Image alias %q is not available in %q", *properties.ImageAlias, location)
+		}
+
+		properties.Image = ionossdk.PtrString(imageID)
+	}
+
+	if !properties.HasType() {
+		properties.Type = ionossdk.PtrString("HDD")
+	}
+
+	volumeID := uuid.NewString()
+
+	return &fakeVolume{
+		volume: &ionossdk.Volume{
+			Id:         ionossdk.PtrString(volumeID),
+			Type:       newFakeType(ionossdk.VOLUME),
+			Href:       ionossdk.PtrString(fmt.Sprintf("%s/datacenters/%s/volumes/%s", apiBasePath, *datacenter.datacenter.Id, volumeID)),
+			Metadata:   newFakeMetadata("BUSY"),
+			Properties: &properties,
+		},
+		labels: map[string]string{},
+	}, 0, ""
+}
+
+// getImageIDForAlias returns the ID of the image with the alias given at the location given.
+//
+// PARAMETERS
+// alias    string Image alias
+// location string Location of the image
+func (api *FakeCloudAPI) getImageIDForAlias(alias, location string) string {
+	for imageID, image := range api.images {
+		if !image.Properties.HasImageAliases() || !image.Properties.HasLocation() || location != *image.Properties.Location {
+			continue
+		}
+
+		for _, imageAlias := range *image.Properties.ImageAliases {
+			if alias == imageAlias {
+				return imageID
+			}
+		}
+	}
+
+	return ""
+}
+
+// listVolumes returns all volumes of the datacenter given.
+//
+// PARAMETERS
+// datacenter *fakeDatacenter Datacenter
+func (r *fakeRequest) listVolumes(datacenter *fakeDatacenter) (int, interface{}) {
+	items := []ionossdk.Volume{}
+
+	for _, volume := range datacenter.volumes {
+		items = append(items, *volume.volume)
+	}
+
+	sort.Slice(items, func(i, j int) bool { return *items[i].Id < *items[j].Id })
+
+	return http.StatusOK, &ionossdk.Volumes{Type: newFakeType("collection"), Items: &items}
+}
+
+// createVolume creates the volume requested.
+//
+// PARAMETERS
+// datacenter *fakeDatacenter Datacenter
+func (r *fakeRequest) createVolume(datacenter *fakeDatacenter) (int, interface{}) {
+	var volumeRequested ionossdk.Volume
+
+	err := r.decodeBody(&volumeRequested)
+	if nil != err {
+		return r.errorResponse(http.StatusBadRequest, "%s", err.Error())
+	}
+
+	volume, statusCode, message := r.api.newFakeVolume(datacenter, volumeRequested)
+	if nil == volume {
+		return r.errorResponse(statusCode, "%s", message)
+	}
+
+	datacenter.volumes[*volume.volume.Id] = volume
+	r.startRequest(nil, volume.volume.Metadata)
+
+	return http.StatusAccepted, volume.volume
+}
+
+// deleteVolume deletes the volume given and detaches it from all servers.
+//
+// PARAMETERS
+// datacenter *fakeDatacenter Datacenter
+// volume     *fakeVolume     Volume to delete
+func (r *fakeRequest) deleteVolume(datacenter *fakeDatacenter, volume *fakeVolume) (int, interface{}) {
+	volumeID := *volume.volume.Id
+
+	r.startRequest(func() {
+		delete(datacenter.volumes, volumeID)
+
+		for _, server := range datacenter.servers {
+			volumeIDs := []string{}
+
+			for _, attachedVolumeID := range server.volumeIDs {
+				if volumeID != attachedVolumeID {
+					volumeIDs = append(volumeIDs, attachedVolumeID)
+				}
+			}
+
+			server.volumeIDs = volumeIDs
+		}
+	}, volume.volume.Metadata)
+
+	return http.StatusAccepted, nil
+}
+
+// newFakeNic returns a new NIC entry based on the NIC given or the HTTP status code and message of the validation
+// failure. IPs not requested are assigned from a private network.
+//
+// PARAMETERS
+// server *fakeServer  Server of the NIC
+// nic    ionossdk.Nic NIC requested
+func (api *FakeCloudAPI) newFakeNic(server *fakeServer, nic ionossdk.Nic) (*fakeNic, int, string) {
+	if !nic.HasProperties() || !nic.Properties.HasLan() {
+		return nil, http.StatusUnprocessableEntity, "NIC LAN is required"
+	}
+
+	properties := *nic.Properties
+
+	if properties.HasIps() && len(*properties.Ips) > 0 {
+		for _, ip := range *properties.Ips {
+			if api.isIPInUse(ip) {
+				return nil, http.StatusUnprocessableEntity, fmt.Sprintf("IP %q is already in use", ip)
+			}
+		}
+	} else {
+		api.ipCount++
+		properties.Ips = &[]string{fmt.Sprintf("10.7.%d.%d", api.ipCount/250, 1+api.ipCount%250)}
+	}
+
+	if !properties.HasDhcp() {
+		properties.Dhcp = ionossdk.PtrBool(true)
+	}
+
+	if !properties.HasFirewallActive() {
+		properties.FirewallActive = ionossdk.PtrBool(false)
+	}
+
+	nicID := uuid.NewString()
+	properties.Mac = ionossdk.PtrString(fmt.Sprintf("02:01:%s:%s:%s:%s", nicID[0:2], nicID[2:4], nicID[4:6], nicID[6:8]))
+
+	return &fakeNic{
+		nic: &ionossdk.Nic{
+			Id:         ionossdk.PtrString(nicID),
+			Type:       newFakeType(ionossdk.NIC),
+			Href:       ionossdk.PtrString(fmt.Sprintf("%s/nics/%s", *server.server.Href, nicID)),
+			Metadata:   newFakeMetadata("BUSY"),
+			Properties: &properties,
+		},
+		firewallRules: []*ionossdk.FirewallRule{},
+	}, 0, ""
+}
+
+// isIPInUse returns true if the IP given is used by any NIC.
+//
+// PARAMETERS
+// ip string IP to check
+func (api *FakeCloudAPI) isIPInUse(ip string) bool {
+	for _, datacenter := range api.datacenters {
+		for _, server := range datacenter.servers {
+			for _, nic := range server.nics {
+				for _, nicIP := range *nic.nic.Properties.Ips {
+					if ip == nicIP {
+						return true
+					}
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// createNic creates the NIC requested.
+//
+// PARAMETERS
+// datacenter *fakeDatacenter Datacenter
+// server     *fakeServer     Server of the NIC
+func (r *fakeRequest) createNic(datacenter *fakeDatacenter, server *fakeServer) (int, interface{}) {
+	var nicRequested ionossdk.Nic
+
+	err := r.decodeBody(&nicRequested)
+	if nil != err {
+		return r.errorResponse(http.StatusBadRequest, "%s", err.Error())
+	}
+
+	nic, statusCode, message := r.api.newFakeNic(server, nicRequested)
+	if nil == nic {
+		return r.errorResponse(statusCode, "%s", message)
+	}
+
+	server.nics = append(server.nics, nic)
+	r.startRequest(nil, nic.nic.Metadata)
+
+	return http.StatusAccepted, r.api.renderNic(nic)
+}
+
+// deleteNic deletes the NIC given.
+//
+// PARAMETERS
+// server *fakeServer Server of the NIC
+// nic    *fakeNic    NIC to delete
+func (r *fakeRequest) deleteNic(server *fakeServer, nic *fakeNic) (int, interface{}) {
+	r.startRequest(func() {
+		nics := []*fakeNic{}
+
+		for _, entry := range server.nics {
+			if entry != nic {
+				nics = append(nics, entry)
+			}
+		}
+
+		server.nics = nics
+	}, nic.nic.Metadata)
+
+	return http.StatusAccepted, nil
+}
+
+// createFirewallRule creates the firewall rule requested.
+//
+// PARAMETERS
+// nic *fakeNic NIC of the firewall rule
+func (r *fakeRequest) createFirewallRule(nic *fakeNic) (int, interface{}) {
+	var ruleRequested ionossdk.FirewallRule
+
+	err := r.decodeBody(&ruleRequested)
+	if nil != err || !ruleRequested.HasProperties() || !ruleRequested.Properties.HasProtocol() {
+		return r.errorResponse(http.StatusUnprocessableEntity, "Firewall rule protocol is required")
+	}
+
+	ruleID := uuid.NewString()
+
+	rule := &ionossdk.FirewallRule{
+		Id:         ionossdk.PtrString(ruleID),
+		Type:       newFakeType(ionossdk.FIREWALL_RULE),
+		Href:       ionossdk.PtrString(fmt.Sprintf("%s/firewallrules/%s", *nic.nic.Href, ruleID)),
+		Metadata:   newFakeMetadata("BUSY"),
+		Properties: ruleRequested.Properties,
+	}
+
+	nic.firewallRules = append(nic.firewallRules, rule)
+	r.startRequest(nil, rule.Metadata, nic.nic.Metadata)
+
+	return http.StatusAccepted, rule
+}
+
+// renderServer returns the server given including its attached volumes and NICs.
+//
+// PARAMETERS
+// datacenter *fakeDatacenter Datacenter
+// server     *fakeServer     Server to render
+func (api *FakeCloudAPI) renderServer(datacenter *fakeDatacenter, server *fakeServer) ionossdk.Server {
+	result := *server.server
+	volumes := api.renderAttachedVolumes(datacenter, server)
+	nics := api.renderNics(server)
+
+	result.Entities = &ionossdk.ServerEntities{Volumes: &volumes, Nics: &nics}
+
+	return result
+}
+
+// renderAttachedVolumes returns all volumes attached to the server given.
+//
+// PARAMETERS
+// datacenter *fakeDatacenter Datacenter
+// server     *fakeServer     Server
+func (api *FakeCloudAPI) renderAttachedVolumes(datacenter *fakeDatacenter, server *fakeServer) ionossdk.AttachedVolumes {
+	items := []ionossdk.Volume{}
+
+	for _, volumeID := range server.volumeIDs {
+		if volume, ok := datacenter.volumes[volumeID]; ok {
+			items = append(items, *volume.volume)
+		}
+	}
+
+	return ionossdk.AttachedVolumes{Type: newFakeType("collection"), Items: &items}
+}
+
+// renderNics returns all NICs of the server given.
+//
+// PARAMETERS
+// server *fakeServer Server
+func (api *FakeCloudAPI) renderNics(server *fakeServer) ionossdk.Nics {
+	items := []ionossdk.Nic{}
+
+	for _, nic := range server.nics {
+		items = append(items, api.renderNic(nic))
+	}
+
+	return ionossdk.Nics{Type: newFakeType("collection"), Items: &items}
+}
+
+// renderNic returns the NIC given including its firewall rules.
+//
+// PARAMETERS
+// nic *fakeNic NIC to render
+func (api *FakeCloudAPI) renderNic(nic *fakeNic) ionossdk.Nic {
+	result := *nic.nic
+	firewallRules := api.renderFirewallRules(nic)
+
+	result.Entities = &ionossdk.NicEntities{Firewallrules: &firewallRules}
+
+	return result
+}
+
+// renderFirewallRules returns all firewall rules of the NIC given.
+//
+// PARAMETERS
+// nic *fakeNic NIC
+func (api *FakeCloudAPI) renderFirewallRules(nic *fakeNic) ionossdk.FirewallRules {
+	items := []ionossdk.FirewallRule{}
+
+	for _, rule := range nic.firewallRules {
+		items = append(items, *rule)
+	}
+
+	return ionossdk.FirewallRules{Type: newFakeType("collection"), Items: &items}
+}
+
+// renderIPBlock returns the IP block with the ID given including all NICs consuming its IPs.
+//
+// PARAMETERS
+// ipBlockID string IP block ID
+func (api *FakeCloudAPI) renderIPBlock(ipBlockID string) ionossdk.IpBlock {
+	ipBlock := *api.ipBlocks[ipBlockID]
+	properties := *ipBlock.Properties
+	consumers := []ionossdk.IpConsumer{}
+	ips := map[string]bool{}
+
+	for _, ip := range *properties.Ips {
+		ips[ip] = true
+	}
+
+	for _, datacenter := range api.datacenters {
+		for _, server := range datacenter.servers {
+			for _, nic := range server.nics {
+				for _, ip := range *nic.nic.Properties.Ips {
+					if !ips[ip] {
+						continue
+					}
+
+					consumers = append(consumers, ionossdk.IpConsumer{
+						Ip:           ionossdk.PtrString(ip),
+						Mac:          nic.nic.Properties.Mac,
+						NicId:        nic.nic.Id,
+						ServerId:     server.server.Id,
+						ServerName:   server.server.Properties.Name,
+						DatacenterId: datacenter.datacenter.Id,
+					})
+				}
+			}
+		}
+	}
+
+	sort.Slice(consumers, func(i, j int) bool { return *consumers[i].Ip < *consumers[j].Ip })
+
+	properties.IpConsumers = &consumers
+	ipBlock.Properties = &properties
+
+	return ipBlock
+}
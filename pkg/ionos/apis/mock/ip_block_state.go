@@ -0,0 +1,242 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mock provides all methods required to simulate a driver
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
+)
+
+// IPBlockState represents an IONOS IP block and its consumers for testing purposes.
+type IPBlockState struct {
+	mutex sync.Mutex
+
+	IPs             []string
+	Consumers       []ionossdk.IpConsumer
+	RetainConsumers bool
+	// RacingIPs are consumed by a foreign server right before they are requested
+	RacingIPs []string
+
+	NicPostCount  int
+	ConflictCount int
+}
+
+// NewIPBlockState returns a new IP block state with the IPs given.
+//
+// PARAMETERS
+// ips []string IPs of the IP block
+func NewIPBlockState(ips ...string) *IPBlockState {
+	return &IPBlockState{
+		IPs:       ips,
+		Consumers: []ionossdk.IpConsumer{},
+	}
+}
+
+// TestForeignServerID is the server ID used for IPs consumed by a foreign server
+const TestForeignServerID = "fedcba98-7654-4321-8fed-cba987654321"
+
+// AddConsumer adds a consumer for the IP given. It returns false if the IP is already in use.
+//
+// PARAMETERS
+// ip       string IP to consume
+// serverID string Server ID of the consumer
+func (state *IPBlockState) AddConsumer(ip, serverID string) bool {
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+
+	for _, consumer := range state.Consumers {
+		if ip == *consumer.Ip {
+			state.ConflictCount++
+			return false
+		}
+	}
+
+	state.Consumers = append(state.Consumers, ionossdk.IpConsumer{Ip: ionossdk.PtrString(ip), ServerId: ionossdk.PtrString(serverID)})
+
+	return true
+}
+
+// GetConsumerIPs returns the IPs used by the server ID given.
+//
+// PARAMETERS
+// serverID string Server ID of the consumer
+func (state *IPBlockState) GetConsumerIPs(serverID string) []string {
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+
+	ips := []string{}
+
+	for _, consumer := range state.Consumers {
+		if serverID == *consumer.ServerId {
+			ips = append(ips, *consumer.Ip)
+		}
+	}
+
+	return ips
+}
+
+// RemoveConsumers removes all consumers of the server ID given unless consumers are retained.
+//
+// PARAMETERS
+// serverID string Server ID of the consumer
+func (state *IPBlockState) RemoveConsumers(serverID string) {
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+
+	if state.RetainConsumers {
+		return
+	}
+
+	consumers := []ionossdk.IpConsumer{}
+
+	for _, consumer := range state.Consumers {
+		if serverID != *consumer.ServerId {
+			consumers = append(consumers, consumer)
+		}
+	}
+
+	state.Consumers = consumers
+}
+
+// consumeRacingIP adds a foreign consumer for the IP given if it is defined as racing.
+//
+// PARAMETERS
+// ip string IP requested
+func (state *IPBlockState) consumeRacingIP(ip string) {
+	state.mutex.Lock()
+
+	isRacing := false
+
+	for _, racingIP := range state.RacingIPs {
+		if ip == racingIP {
+			isRacing = true
+			break
+		}
+	}
+
+	state.mutex.Unlock()
+
+	if isRacing {
+		state.AddConsumer(ip, TestForeignServerID)
+	}
+}
+
+// handleNicPost creates a NIC consuming the IPs requested and responds with a conflict if an IP is already in use.
+//
+// PARAMETERS
+// res      http.ResponseWriter Response instance
+// req      *http.Request       Request instance
+// serverID string              Server ID of the NIC
+func (state *IPBlockState) handleNicPost(res http.ResponseWriter, req *http.Request, serverID string) (ionossdk.Nic, bool) {
+	var nic ionossdk.Nic
+
+	jsonErr := json.NewDecoder(req.Body).Decode(&nic)
+	if jsonErr != nil {
+		panic(jsonErr)
+	}
+
+	if nic.Properties.HasIps() {
+		for _, ip := range *nic.Properties.Ips {
+			state.consumeRacingIP(ip)
+
+			if !state.AddConsumer(ip, serverID) {
+				writeJSON(res, http.StatusUnprocessableEntity, ionossdk.Error{
+					HttpStatus: ionossdk.PtrInt32(http.StatusUnprocessableEntity),
+					Messages: &[]ionossdk.ErrorMessage{
+						{ErrorCode: ionossdk.PtrString("422"), Message: ionossdk.PtrString(fmt.Sprintf("IP %q is already in use", ip))},
+					},
+				})
+				return nic, false
+			}
+		}
+	}
+
+	state.mutex.Lock()
+	state.NicPostCount++
+	state.mutex.Unlock()
+
+	return nic, true
+}
+
+// SetupIPBlockEndpointOnMux configures a "/ipblocks/<id>" endpoint for the IP block state on the mux given.
+//
+// PARAMETERS
+// mux   *http.ServeMux Mux to add handler to
+// state *IPBlockState  IP block state to read
+func SetupIPBlockEndpointOnMux(mux *http.ServeMux, state *IPBlockState) {
+	mux.HandleFunc(fmt.Sprintf("%s/ipblocks/%s", apiBasePath, TestIPBlockID), func(res http.ResponseWriter, req *http.Request) {
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+
+		if strings.ToLower(req.Method) == "get" {
+			consumers := append([]ionossdk.IpConsumer{}, state.Consumers...)
+			ips := append([]string{}, state.IPs...)
+
+			writeJSON(res, http.StatusOK, ionossdk.IpBlock{
+				Id: ionossdk.PtrString(TestIPBlockID),
+				Properties: &ionossdk.IpBlockProperties{
+					Ips:         &ips,
+					IpConsumers: &consumers,
+				},
+			})
+		} else {
+			panic("Unsupported HTTP method call")
+		}
+	})
+}
+
+// SetupIPBlockNicsEndpointsOnMux configures "/nics" endpoints for all servers consuming IPs of the IP block state on
+// the mux given.
+//
+// PARAMETERS
+// mux   *http.ServeMux Mux to add handler to
+// state *IPBlockState  IP block state to read and modify
+func SetupIPBlockNicsEndpointsOnMux(mux *http.ServeMux, state *IPBlockState) {
+	serversURL := fmt.Sprintf("%s/datacenters/%s/servers/", apiBasePath, TestProviderSpecDatacenterID)
+
+	mux.HandleFunc(serversURL, func(res http.ResponseWriter, req *http.Request) {
+		pathData := strings.Split(strings.TrimPrefix(req.URL.Path, serversURL), "/")
+
+		if len(pathData) < 2 || "nics" != pathData[1] {
+			panic("Unsupported HTTP endpoint call")
+		}
+
+		serverID := pathData[0]
+
+		if 2 == len(pathData) && strings.ToLower(req.Method) == "post" {
+			nic, ok := state.handleNicPost(res, req, serverID)
+			if ok {
+				nic.Id = ionossdk.PtrString(uuid.NewString())
+				writeJSON(res, http.StatusAccepted, nic)
+			}
+		} else if 3 == len(pathData) && strings.ToLower(req.Method) == "get" {
+			writeJSON(res, http.StatusOK, ionossdk.Nic{
+				Id:       ionossdk.PtrString(pathData[2]),
+				Metadata: &ionossdk.DatacenterElementMetadata{State: ionossdk.PtrString("AVAILABLE")},
+			})
+		} else {
+			panic("Unsupported HTTP method call")
+		}
+	})
+}
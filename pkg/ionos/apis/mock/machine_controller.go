@@ -23,8 +23,10 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis"
 	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
 	"github.com/google/uuid"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -186,11 +188,14 @@ const (
 	}
 }
 	`
+	TestMachineClassName = "test-mc"
 	TestNamespace = "test"
+	TestSecretName = "test-secret"
 	TestServerNameTemplate = "machine-%s"
 	TestServerID = "6789abcd-ef01-4345-6789-abcdef012325"
 	TestServerNicID = "23456789-abcd-4f01-23e5-6789abcdef01"
 	TestServerVolumeID = "3456789a-bcde-4012-3f56-789abcdef012"
+	TestIPBlockID = "456789ab-cdef-4123-4567-89abcdef0123"
 )
 
 // handleLabelEndpointRequest provides support for a generic "/labels" endpoint.
@@ -287,6 +292,38 @@ func NewMachineClass() *v1alpha1.MachineClass {
 	return NewMachineClassWithProviderSpec([]byte(TestProviderSpec))
 }
 
+// NewIonosMachineClass generates new legacy provider specific machine class data for testing purposes.
+func NewIonosMachineClass() *apis.IonosMachineClass {
+	providerSpec := NewProviderSpec()
+
+	return &apis.IonosMachineClass{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "machine.sapcloud.io/v1alpha1",
+			Kind:       apis.IonosMachineClassKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       TestMachineClassName,
+			Namespace:  TestNamespace,
+			Labels:     map[string]string{"test": "label"},
+			Finalizers: []string{"machine.sapcloud.io/machine-controller-manager"},
+		},
+		Spec: apis.IonosMachineClassSpec{
+			DatacenterID: providerSpec.DatacenterID,
+			Cluster:      providerSpec.Cluster,
+			Zone:         providerSpec.Zone,
+			Cores:        providerSpec.Cores,
+			Memory:       providerSpec.Memory,
+			ImageID:      providerSpec.ImageID,
+			SSHKey:       providerSpec.SSHKey,
+			NetworkID:    TestProviderSpecNetworkID,
+			SecretRef: &corev1.SecretReference{
+				Name:      TestSecretName,
+				Namespace: TestNamespace,
+			},
+		},
+	}
+}
+
 // NewMachineClassWithProviderSpec generates new v1alpha1 machine class data based on the given provider specification for testing purposes.
 //
 // PARAMETERS
@@ -333,6 +370,14 @@ func SetupImagesEndpointOnMux(mux *http.ServeMux) {
 	})
 }
 
+// SetupLabelsEndpointOnMux configures a "/labels" endpoint on the mux given.
+//
+// PARAMETERS
+// mux *http.ServeMux Mux to add handler to
+func SetupLabelsEndpointOnMux(mux *http.ServeMux) {
+	mux.HandleFunc(apiBasePath + "/labels", handleLabelEndpointRequest)
+}
+
 // SetupServersEndpointOnMux configures a "/datacenters/<id>/servers" endpoint on the mux given.
 //
 // PARAMETERS
@@ -470,7 +515,17 @@ func SetupVolumesEndpointOnMux(mux *http.ServeMux) {
 	mux.HandleFunc(fmt.Sprintf("%s/datacenters/%s/volumes", apiBasePath, TestProviderSpecDatacenterID), func(res http.ResponseWriter, req *http.Request) {
 		res.Header().Add("Content-Type", "application/json; charset=utf-8")
 
-		if (strings.ToLower(req.Method) == "post") {
+		if (strings.ToLower(req.Method) == "get") {
+			res.WriteHeader(http.StatusOK)
+			res.Write([]byte(fmt.Sprintf(`
+{
+	"id": %q,
+	"type": "collection",
+	"href": "",
+	"items": [ ]
+}
+			`, uuid.NewString())))
+		} else if (strings.ToLower(req.Method) == "post") {
 			res.WriteHeader(http.StatusAccepted)
 
 			jsonData := make([]byte, req.ContentLength)
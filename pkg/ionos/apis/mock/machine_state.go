@@ -0,0 +1,687 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mock provides all methods required to simulate a driver
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis"
+	"github.com/google/uuid"
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
+	"k8s.io/component-base/version"
+)
+
+// MachineStateDataVolume represents a data volume of a machine state for testing purposes.
+type MachineStateDataVolume struct {
+	ID       string
+	Name     string
+	State    string
+	Labels   map[string]string
+	Attached bool
+
+	Properties *ionossdk.VolumeProperties
+}
+
+// MachineState represents the IONOS resources of a (partially) created machine for testing purposes.
+type MachineState struct {
+	mutex sync.Mutex
+
+	MachineName            string
+	VolumeExists           bool
+	VolumeState            string
+	VolumeLabels           map[string]string
+	VolumeProperties       *ionossdk.VolumeProperties
+	ServerExists           bool
+	ServerState            string
+	ServerVMState          string
+	ServerAvailabilityZone string
+	ServerLabels           map[string]string
+	ServerProperties       *ionossdk.ServerProperties
+	ServerNicLANs          []int32
+	ServerNicProperties    []*ionossdk.NicProperties
+	ServerNicFirewallRules map[int32][]*ionossdk.FirewallruleProperties
+	IPBlock                *IPBlockState
+	DataVolumes            []*MachineStateDataVolume
+
+	VolumePostCount       int
+	DataVolumePostCount   int
+	VolumeAttachCount     int
+	VolumeDeleteCount     int
+	ServerPostCount       int
+	ServerStopCount       int
+	ServerStartCount      int
+	NicPostCount          int
+	FirewallRulePostCount int
+	LabelPostCount        int
+}
+
+// GetTestClusterLabelValue returns the encoded cluster label value of the test provider specification.
+func GetTestClusterLabelValue() string {
+	return apis.EncodeLabelValue(TestProviderSpecCluster)
+}
+
+// GetTestVolumeLabels returns the complete set of root volume labels for the test provider specification.
+//
+// PARAMETERS
+// machineName string Machine name
+func GetTestVolumeLabels(machineName string) map[string]string {
+	return map[string]string{
+		"cluster":          GetTestClusterLabelValue(),
+		"machine-name":     apis.EncodeLabelValue(machineName),
+		"provider-version": apis.EncodeLabelValue(version.Get().GitVersion),
+	}
+}
+
+// GetTestServerLabels returns the complete set of server labels for the test provider specification.
+//
+// PARAMETERS
+// machineName string Machine name
+func GetTestServerLabels(machineName string) map[string]string {
+	labels := GetTestVolumeLabels(machineName)
+	labels["role"] = "node"
+	labels["region"] = apis.EncodeLabelValue(apis.GetRegionFromZone(TestProviderSpecZone))
+	labels["zone"] = apis.EncodeLabelValue(TestProviderSpecZone)
+
+	return labels
+}
+
+// NewMachineState returns a new machine state without any existing resources.
+//
+// PARAMETERS
+// machineName string Machine name
+func NewMachineState(machineName string) *MachineState {
+	return &MachineState{
+		MachineName:            machineName,
+		VolumeState:            "AVAILABLE",
+		VolumeLabels:           map[string]string{},
+		ServerState:            "AVAILABLE",
+		ServerLabels:           map[string]string{},
+		ServerNicFirewallRules: map[int32][]*ionossdk.FirewallruleProperties{},
+	}
+}
+
+// GetTestNicID returns the NIC ID used for the LAN ID given.
+//
+// PARAMETERS
+// lan int32 LAN ID
+func GetTestNicID(lan int32) string {
+	return fmt.Sprintf("%s%04d", TestServerNicID[:len(TestServerNicID)-4], lan)
+}
+
+// AddDataVolume adds an existing data volume with the name and labels given.
+//
+// PARAMETERS
+// name     string            Data volume name
+// labels   map[string]string Data volume labels
+// attached bool              True if attached to the server
+func (state *MachineState) AddDataVolume(name string, labels map[string]string, attached bool) *MachineStateDataVolume {
+	dataVolume := &MachineStateDataVolume{
+		ID:       uuid.NewString(),
+		Name:     fmt.Sprintf("%s-%s", state.MachineName, name),
+		State:    "AVAILABLE",
+		Labels:   labels,
+		Attached: attached,
+	}
+
+	state.DataVolumes = append(state.DataVolumes, dataVolume)
+
+	return dataVolume
+}
+
+// GetDataVolume returns the data volume with the name given or nil if it does not exist.
+//
+// PARAMETERS
+// name string Data volume name
+func (state *MachineState) GetDataVolume(name string) *MachineStateDataVolume {
+	name = fmt.Sprintf("%s-%s", state.MachineName, name)
+
+	for _, dataVolume := range state.DataVolumes {
+		if name == dataVolume.Name {
+			return dataVolume
+		}
+	}
+
+	return nil
+}
+
+// getDataVolumeByID returns the data volume with the ID given or nil if it does not exist.
+//
+// PARAMETERS
+// id string Data volume ID
+func (state *MachineState) getDataVolumeByID(id string) *MachineStateDataVolume {
+	for _, dataVolume := range state.DataVolumes {
+		if id == dataVolume.ID {
+			return dataVolume
+		}
+	}
+
+	return nil
+}
+
+// newLabelResources returns the label resources for the label map given.
+//
+// PARAMETERS
+// labels map[string]string Labels
+func newLabelResources(labels map[string]string) ionossdk.LabelResources {
+	items := []ionossdk.LabelResource{}
+
+	for key, value := range labels {
+		items = append(items, ionossdk.LabelResource{
+			Id:         ionossdk.PtrString(key),
+			Properties: &ionossdk.LabelResourceProperties{Key: ionossdk.PtrString(key), Value: ionossdk.PtrString(value)},
+		})
+	}
+
+	return ionossdk.LabelResources{Id: ionossdk.PtrString(uuid.NewString()), Items: &items}
+}
+
+// newServer returns the server resource of the machine state.
+func (state *MachineState) newServer() ionossdk.Server {
+	nics := []ionossdk.Nic{}
+
+	for _, lan := range state.ServerNicLANs {
+		nics = append(nics, state.newNic(lan))
+	}
+
+	volumes := []ionossdk.Volume{state.newVolume()}
+
+	for _, dataVolume := range state.DataVolumes {
+		if dataVolume.Attached {
+			volumes = append(volumes, dataVolume.newVolume())
+		}
+	}
+
+	availabilityZone := apis.AvailabilityZoneAuto
+
+	if "" != state.ServerAvailabilityZone {
+		availabilityZone = state.ServerAvailabilityZone
+	}
+
+	return ionossdk.Server{
+		Id:       ionossdk.PtrString(TestServerID),
+		Metadata: &ionossdk.DatacenterElementMetadata{State: ionossdk.PtrString(state.ServerState)},
+		Properties: &ionossdk.ServerProperties{
+			Name:             ionossdk.PtrString(state.MachineName),
+			AvailabilityZone: ionossdk.PtrString(availabilityZone),
+			Cores:            ionossdk.PtrInt32(1),
+			Ram:              ionossdk.PtrInt32(1024),
+			VmState:          ionossdk.PtrString(state.ServerVMState),
+			BootVolume:       &ionossdk.ResourceReference{Id: ionossdk.PtrString(TestServerVolumeID)},
+		},
+		Entities: &ionossdk.ServerEntities{
+			Volumes: &ionossdk.AttachedVolumes{Items: &volumes},
+			Nics:    &ionossdk.Nics{Items: &nics},
+		},
+	}
+}
+
+// newNic returns a NIC resource for the LAN ID given.
+//
+// PARAMETERS
+// lan int32 LAN ID
+func (state *MachineState) newNic(lan int32) ionossdk.Nic {
+	return ionossdk.Nic{
+		Id:         ionossdk.PtrString(GetTestNicID(lan)),
+		Metadata:   &ionossdk.DatacenterElementMetadata{State: ionossdk.PtrString("AVAILABLE")},
+		Properties: &ionossdk.NicProperties{Lan: ionossdk.PtrInt32(lan)},
+	}
+}
+
+// newFirewallRules returns the firewall rule resources of the LAN ID given.
+//
+// PARAMETERS
+// lan int32 LAN ID
+func (state *MachineState) newFirewallRules(lan int32) ionossdk.FirewallRules {
+	items := []ionossdk.FirewallRule{}
+
+	for _, ruleProperties := range state.ServerNicFirewallRules[lan] {
+		items = append(items, ionossdk.FirewallRule{Id: ionossdk.PtrString(uuid.NewString()), Properties: ruleProperties})
+	}
+
+	return ionossdk.FirewallRules{Id: ionossdk.PtrString(uuid.NewString()), Items: &items}
+}
+
+// newVolume returns the root volume resource of the machine state.
+func (state *MachineState) newVolume() ionossdk.Volume {
+	return ionossdk.Volume{
+		Id:       ionossdk.PtrString(TestServerVolumeID),
+		Metadata: &ionossdk.DatacenterElementMetadata{State: ionossdk.PtrString(state.VolumeState)},
+		Properties: &ionossdk.VolumeProperties{
+			Name: ionossdk.PtrString(fmt.Sprintf("%s-root-volume", state.MachineName)),
+			Size: ionossdk.PtrFloat32(100),
+		},
+	}
+}
+
+// newVolume returns the volume resource of the data volume.
+func (dataVolume *MachineStateDataVolume) newVolume() ionossdk.Volume {
+	return ionossdk.Volume{
+		Id:         ionossdk.PtrString(dataVolume.ID),
+		Metadata:   &ionossdk.DatacenterElementMetadata{State: ionossdk.PtrString(dataVolume.State)},
+		Properties: &ionossdk.VolumeProperties{Name: ionossdk.PtrString(dataVolume.Name), Size: ionossdk.PtrFloat32(10)},
+	}
+}
+
+// writeJSON writes the given data as JSON response.
+//
+// PARAMETERS
+// res        http.ResponseWriter Response instance
+// statusCode int                 HTTP status code
+// data       interface{}         Data to serialize
+func writeJSON(res http.ResponseWriter, statusCode int, data interface{}) {
+	jsonData, err := json.Marshal(data)
+	if nil != err {
+		panic(err)
+	}
+
+	res.Header().Add("Content-Type", "application/json; charset=utf-8")
+	res.WriteHeader(statusCode)
+	res.Write(jsonData)
+}
+
+// handleLabels provides stateful support for a "/labels" endpoint.
+//
+// PARAMETERS
+// res    http.ResponseWriter Response instance
+// req    *http.Request       Request instance
+// labels map[string]string   Labels to read and modify
+func (state *MachineState) handleLabels(res http.ResponseWriter, req *http.Request, labels map[string]string) {
+	if strings.ToLower(req.Method) == "get" {
+		writeJSON(res, http.StatusOK, newLabelResources(labels))
+	} else if strings.ToLower(req.Method) == "post" {
+		var label ionossdk.LabelResource
+
+		jsonErr := json.NewDecoder(req.Body).Decode(&label)
+		if jsonErr != nil {
+			panic(jsonErr)
+		}
+
+		labels[*label.Properties.Key] = *label.Properties.Value
+		state.LabelPostCount++
+
+		writeJSON(res, http.StatusCreated, label)
+	} else {
+		panic("Unsupported HTTP method call")
+	}
+}
+
+// SetupMachineStateEndpointsOnMux configures stateful endpoints for all resources of the machine state on the mux given.
+//
+// PARAMETERS
+// mux   *http.ServeMux Mux to add handler to
+// state *MachineState  Machine state to read and modify
+func SetupMachineStateEndpointsOnMux(mux *http.ServeMux, state *MachineState) {
+	datacenterURL := fmt.Sprintf("%s/datacenters/%s", apiBasePath, TestProviderSpecDatacenterID)
+	serverURL := fmt.Sprintf("%s/servers/%s", datacenterURL, TestServerID)
+	volumeURL := fmt.Sprintf("%s/volumes/%s", datacenterURL, TestServerVolumeID)
+
+	SetupImagesEndpointOnMux(mux)
+
+	if nil != state.IPBlock {
+		SetupIPBlockEndpointOnMux(mux, state.IPBlock)
+	}
+
+	mux.HandleFunc(fmt.Sprintf("%s/volumes", datacenterURL), func(res http.ResponseWriter, req *http.Request) {
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+
+		if strings.ToLower(req.Method) == "get" {
+			items := []ionossdk.Volume{}
+
+			if state.VolumeExists {
+				items = append(items, state.newVolume())
+			}
+
+			for _, dataVolume := range state.DataVolumes {
+				items = append(items, dataVolume.newVolume())
+			}
+
+			writeJSON(res, http.StatusOK, ionossdk.Volumes{Id: ionossdk.PtrString(uuid.NewString()), Items: &items})
+		} else if strings.ToLower(req.Method) == "post" {
+			var volume ionossdk.Volume
+
+			jsonErr := json.NewDecoder(req.Body).Decode(&volume)
+			if jsonErr != nil {
+				panic(jsonErr)
+			}
+
+			if !strings.HasSuffix(*volume.Properties.Name, "-root-volume") {
+				dataVolume := &MachineStateDataVolume{
+					ID:     uuid.NewString(),
+					Name:   *volume.Properties.Name,
+					State:  "BUSY",
+					Labels: map[string]string{},
+
+					Properties: volume.Properties,
+				}
+
+				state.DataVolumes = append(state.DataVolumes, dataVolume)
+				state.DataVolumePostCount++
+
+				writeJSON(res, http.StatusAccepted, dataVolume.newVolume())
+				return
+			}
+
+			state.VolumeExists = true
+			state.VolumeProperties = volume.Properties
+			state.VolumeState = "BUSY"
+			state.VolumeLabels = map[string]string{}
+			state.VolumePostCount++
+
+			writeJSON(res, http.StatusAccepted, state.newVolume())
+		} else {
+			panic("Unsupported HTTP method call")
+		}
+	})
+
+	mux.HandleFunc(volumeURL, func(res http.ResponseWriter, req *http.Request) {
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+
+		if strings.ToLower(req.Method) == "get" {
+			state.VolumeState = "AVAILABLE"
+			writeJSON(res, http.StatusOK, state.newVolume())
+		} else if strings.ToLower(req.Method) == "delete" {
+			state.VolumeExists = false
+			state.VolumeDeleteCount++
+			res.WriteHeader(http.StatusAccepted)
+		} else {
+			panic("Unsupported HTTP method call")
+		}
+	})
+
+	mux.HandleFunc(fmt.Sprintf("%s/labels", volumeURL), func(res http.ResponseWriter, req *http.Request) {
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+
+		state.handleLabels(res, req, state.VolumeLabels)
+	})
+
+	mux.HandleFunc(fmt.Sprintf("%s/volumes/", datacenterURL), func(res http.ResponseWriter, req *http.Request) {
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+
+		pathData := strings.Split(strings.TrimPrefix(req.URL.Path, fmt.Sprintf("%s/volumes/", datacenterURL)), "/")
+
+		dataVolume := state.getDataVolumeByID(pathData[0])
+		if nil == dataVolume {
+			writeJSON(res, http.StatusNotFound, ionossdk.Error{HttpStatus: ionossdk.PtrInt32(http.StatusNotFound)})
+			return
+		}
+
+		if 2 == len(pathData) && "labels" == pathData[1] {
+			state.handleLabels(res, req, dataVolume.Labels)
+		} else if strings.ToLower(req.Method) == "get" {
+			dataVolume.State = "AVAILABLE"
+			writeJSON(res, http.StatusOK, dataVolume.newVolume())
+		} else if strings.ToLower(req.Method) == "delete" {
+			dataVolumes := []*MachineStateDataVolume{}
+
+			for _, existingDataVolume := range state.DataVolumes {
+				if dataVolume != existingDataVolume {
+					dataVolumes = append(dataVolumes, existingDataVolume)
+				}
+			}
+
+			state.DataVolumes = dataVolumes
+			state.VolumeDeleteCount++
+
+			res.WriteHeader(http.StatusAccepted)
+		} else {
+			panic("Unsupported HTTP method call")
+		}
+	})
+
+	mux.HandleFunc(fmt.Sprintf("%s/servers", datacenterURL), func(res http.ResponseWriter, req *http.Request) {
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+
+		if strings.ToLower(req.Method) == "get" {
+			items := []ionossdk.Server{}
+
+			if state.ServerExists {
+				items = append(items, state.newServer())
+			}
+
+			writeJSON(res, http.StatusOK, ionossdk.Servers{Id: ionossdk.PtrString(uuid.NewString()), Items: &items})
+		} else if strings.ToLower(req.Method) == "post" {
+			var server ionossdk.Server
+
+			jsonErr := json.NewDecoder(req.Body).Decode(&server)
+			if jsonErr != nil {
+				panic(jsonErr)
+			}
+
+			for _, dataVolume := range state.DataVolumes {
+				dataVolume.Attached = false
+			}
+
+			for _, volume := range *server.Entities.Volumes.Items {
+				// Volumes without ID are created together with the server
+				if !volume.HasId() {
+					state.VolumeExists = true
+					state.VolumeProperties = volume.Properties
+					state.VolumeState = "BUSY"
+					state.VolumeLabels = map[string]string{}
+					state.VolumePostCount++
+
+					continue
+				}
+
+				dataVolume := state.getDataVolumeByID(*volume.Id)
+				if nil != dataVolume {
+					dataVolume.Attached = true
+				}
+			}
+
+			state.ServerExists = true
+			state.ServerProperties = server.Properties
+			state.ServerAvailabilityZone = ""
+
+			if server.Properties.HasAvailabilityZone() {
+				state.ServerAvailabilityZone = *server.Properties.AvailabilityZone
+			}
+			state.ServerState = "BUSY"
+			state.ServerVMState = "RUNNING"
+			state.ServerLabels = map[string]string{}
+			state.ServerNicLANs = nil
+			state.ServerNicProperties = nil
+			state.ServerNicFirewallRules = map[int32][]*ionossdk.FirewallruleProperties{}
+			state.ServerPostCount++
+
+			writeJSON(res, http.StatusAccepted, state.newServer())
+		} else {
+			panic("Unsupported HTTP method call")
+		}
+	})
+
+	mux.HandleFunc(serverURL, func(res http.ResponseWriter, req *http.Request) {
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+
+		if strings.ToLower(req.Method) == "get" {
+			state.ServerState = "AVAILABLE"
+			writeJSON(res, http.StatusOK, state.newServer())
+		} else if strings.ToLower(req.Method) == "delete" {
+			state.ServerExists = false
+
+			if nil != state.IPBlock {
+				state.IPBlock.RemoveConsumers(TestServerID)
+			}
+
+			res.WriteHeader(http.StatusAccepted)
+		} else {
+			panic("Unsupported HTTP method call")
+		}
+	})
+
+	mux.HandleFunc(fmt.Sprintf("%s/labels", serverURL), func(res http.ResponseWriter, req *http.Request) {
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+
+		state.handleLabels(res, req, state.ServerLabels)
+	})
+
+	mux.HandleFunc(fmt.Sprintf("%s/labels", apiBasePath), func(res http.ResponseWriter, req *http.Request) {
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+
+		items := []ionossdk.Label{}
+
+		if state.ServerExists && "volume" != req.URL.Query().Get("filter.resourceType") {
+			for key, value := range state.ServerLabels {
+				if filterKey := req.URL.Query().Get("filter.key"); "" != filterKey && filterKey != key {
+					continue
+				}
+
+				items = append(items, ionossdk.Label{
+					Id: ionossdk.PtrString(fmt.Sprintf("urn:label:server:%s:%s", TestServerID, key)),
+					Properties: &ionossdk.LabelProperties{
+						Key:          ionossdk.PtrString(key),
+						Value:        ionossdk.PtrString(value),
+						ResourceId:   ionossdk.PtrString(TestServerID),
+						ResourceType: ionossdk.PtrString("server"),
+						ResourceHref: ionossdk.PtrString(serverURL),
+					},
+				})
+			}
+		}
+
+		writeJSON(res, http.StatusOK, ionossdk.Labels{Id: ionossdk.PtrString(uuid.NewString()), Items: &items})
+	})
+
+	mux.HandleFunc(fmt.Sprintf("%s/volumes", serverURL), func(res http.ResponseWriter, req *http.Request) {
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+
+		if strings.ToLower(req.Method) == "post" {
+			var volume ionossdk.Volume
+
+			jsonErr := json.NewDecoder(req.Body).Decode(&volume)
+			if jsonErr != nil {
+				panic(jsonErr)
+			}
+
+			dataVolume := state.getDataVolumeByID(*volume.Id)
+			dataVolume.Attached = true
+			state.VolumeAttachCount++
+
+			writeJSON(res, http.StatusAccepted, dataVolume.newVolume())
+		} else {
+			panic("Unsupported HTTP method call")
+		}
+	})
+
+	mux.HandleFunc(fmt.Sprintf("%s/nics", serverURL), func(res http.ResponseWriter, req *http.Request) {
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+
+		if strings.ToLower(req.Method) == "post" {
+			ipBlock := state.IPBlock
+
+			if nil == ipBlock {
+				ipBlock = NewIPBlockState()
+			}
+
+			nic, ok := ipBlock.handleNicPost(res, req, TestServerID)
+			if !ok {
+				return
+			}
+
+			state.ServerNicLANs = append(state.ServerNicLANs, *nic.Properties.Lan)
+			state.ServerNicProperties = append(state.ServerNicProperties, nic.Properties)
+			state.NicPostCount++
+
+			writeJSON(res, http.StatusAccepted, state.newNic(*nic.Properties.Lan))
+		} else {
+			panic("Unsupported HTTP method call")
+		}
+	})
+
+	mux.HandleFunc(fmt.Sprintf("%s/nics/", serverURL), func(res http.ResponseWriter, req *http.Request) {
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+
+		pathData := strings.Split(strings.TrimPrefix(req.URL.Path, fmt.Sprintf("%s/nics/", serverURL)), "/")
+		lan := int32(-1)
+
+		for _, serverLAN := range state.ServerNicLANs {
+			if GetTestNicID(serverLAN) == pathData[0] {
+				lan = serverLAN
+			}
+		}
+
+		if -1 == lan {
+			writeJSON(res, http.StatusNotFound, ionossdk.Error{HttpStatus: ionossdk.PtrInt32(http.StatusNotFound)})
+		} else if 2 == len(pathData) && "firewallrules" == pathData[1] {
+			if strings.ToLower(req.Method) == "get" {
+				writeJSON(res, http.StatusOK, state.newFirewallRules(lan))
+			} else if strings.ToLower(req.Method) == "post" {
+				var rule ionossdk.FirewallRule
+
+				jsonErr := json.NewDecoder(req.Body).Decode(&rule)
+				if jsonErr != nil {
+					panic(jsonErr)
+				}
+
+				state.ServerNicFirewallRules[lan] = append(state.ServerNicFirewallRules[lan], rule.Properties)
+				state.FirewallRulePostCount++
+
+				writeJSON(res, http.StatusAccepted, ionossdk.FirewallRule{Id: ionossdk.PtrString(uuid.NewString()), Properties: rule.Properties})
+			} else {
+				panic("Unsupported HTTP method call")
+			}
+		} else if strings.ToLower(req.Method) == "get" {
+			writeJSON(res, http.StatusOK, state.newNic(lan))
+		} else {
+			panic("Unsupported HTTP method call")
+		}
+	})
+
+	mux.HandleFunc(fmt.Sprintf("%s/start", serverURL), func(res http.ResponseWriter, req *http.Request) {
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+
+		if strings.ToLower(req.Method) == "post" {
+			state.ServerVMState = "RUNNING"
+			state.ServerStartCount++
+
+			res.WriteHeader(http.StatusAccepted)
+		} else {
+			panic("Unsupported HTTP method call")
+		}
+	})
+
+	mux.HandleFunc(fmt.Sprintf("%s/stop", serverURL), func(res http.ResponseWriter, req *http.Request) {
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+
+		if strings.ToLower(req.Method) == "post" {
+			state.ServerVMState = "SHUTOFF"
+			state.ServerStopCount++
+
+			res.WriteHeader(http.StatusAccepted)
+		} else {
+			panic("Unsupported HTTP method call")
+		}
+	})
+}
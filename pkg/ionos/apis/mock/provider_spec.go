@@ -22,14 +22,14 @@ import (
 )
 
 const (
-	TestProviderSpec = "{\"datacenterID\":\"01234567-89ab-4def-0123-c56789abcdef\",\"cluster\":\"xyz\",\"zone\":\"de-fra\",\"cores\":1,\"memory\":1024,\"imageID\":\"15f67991-0f51-4efc-a8ad-ef1fb31a480c\",\"sshKey\":\"ssh-rsa invalid\",\"networkIDs\":{\"wan\":\"1\"}}"
-	TestProviderSpecCluster = "xyz"
+	TestProviderSpec             = "{\"datacenterID\":\"01234567-89ab-4def-0123-c56789abcdef\",\"cluster\":\"xyz\",\"zone\":\"de-fra\",\"cores\":1,\"memory\":1024,\"imageID\":\"15f67991-0f51-4efc-a8ad-ef1fb31a480c\",\"sshKey\":\"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIC1xFkK3JrBEAWJ8qfusMvXIUw+xkDzE2wIlhxeSGkiB test@example.com\",\"networkIDs\":{\"wan\":\"1\"}}"
+	TestProviderSpecCluster      = "xyz"
 	TestProviderSpecDatacenterID = "01234567-89ab-4def-0123-c56789abcdef"
-	TestProviderSpecNetworkID = "1"
-	TestProviderSpecSSHKey = "ssh-rsa invalid"
-	TestProviderSpecImageID = "15f67991-0f51-4efc-a8ad-ef1fb31a480c"
-	TestProviderSpecZone = "de-fra"
-	TestInvalidProviderSpec = "{\"test\":\"invalid\"}"
+	TestProviderSpecNetworkID    = "1"
+	TestProviderSpecSSHKey       = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIC1xFkK3JrBEAWJ8qfusMvXIUw+xkDzE2wIlhxeSGkiB test@example.com"
+	TestProviderSpecImageID      = "15f67991-0f51-4efc-a8ad-ef1fb31a480c"
+	TestProviderSpecZone         = "de-fra"
+	TestInvalidProviderSpec      = "{\"test\":\"invalid\"}"
 )
 
 // ManipulateProviderSpec changes given provider specification.
@@ -39,7 +39,7 @@ const (
 // data         map[string]interface{} Members to change
 func ManipulateProviderSpec(providerSpec *apis.ProviderSpec, data map[string]interface{}) *apis.ProviderSpec {
 	for key, value := range data {
-		manipulateStruct(&providerSpec, key, value)
+		manipulateStruct(providerSpec, key, value)
 	}
 
 	return providerSpec
@@ -49,12 +49,12 @@ func ManipulateProviderSpec(providerSpec *apis.ProviderSpec, data map[string]int
 func NewProviderSpec() *apis.ProviderSpec {
 	return &apis.ProviderSpec{
 		DatacenterID: TestProviderSpecDatacenterID,
-		Cluster: TestProviderSpecCluster,
-		Zone: TestProviderSpecZone,
-		Cores: 1,
-		Memory: 1024,
-		ImageID: TestProviderSpecImageID,
-		SSHKey: TestProviderSpecSSHKey,
+		Cluster:      TestProviderSpecCluster,
+		Zone:         TestProviderSpecZone,
+		Cores:        1,
+		Memory:       1024,
+		ImageID:      TestProviderSpecImageID,
+		SSHKey:       TestProviderSpecSSHKey,
 		NetworkIDs: &apis.NetworkIDs{
 			WAN: TestProviderSpecNetworkID,
 		},
@@ -0,0 +1,718 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mock provides all methods required to simulate a driver
+package mock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis"
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/spi"
+	"github.com/google/uuid"
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SessionProvider implements spi.SessionProviderInterface by returning the session given for valid credentials
+type SessionProvider struct {
+	Session spi.Session
+}
+
+// NewSessionProvider returns a session provider always returning the session given.
+//
+// PARAMETERS
+// session spi.Session Session to return
+func NewSessionProvider(session spi.Session) *SessionProvider {
+	return &SessionProvider{Session: session}
+}
+
+// NewSession returns the session of the provider if the secret contains valid credentials.
+//
+// PARAMETERS
+// secret *corev1.Secret Kubernetes secret that contains any sensitive data/credentials
+func (p *SessionProvider) NewSession(secret *corev1.Secret) (spi.Session, error) {
+	_, err := apis.GetCredentialsFromSecret(secret)
+	if nil != err {
+		return nil, err
+	}
+
+	return p.Session, nil
+}
+
+// FakeSession is an in-memory implementation of spi.Session
+type FakeSession struct {
+	mutex         sync.Mutex
+	Datacenters   map[string]ionossdk.Datacenter
+	Images        map[string]ionossdk.Image
+	Snapshots     map[string]ionossdk.Snapshot
+	IPBlocks      map[string]ionossdk.IpBlock
+	Requests      map[string]ionossdk.RequestStatus
+	servers       map[string]ionossdk.Server
+	volumes       map[string]ionossdk.Volume
+	firewallRules map[string][]ionossdk.FirewallRule
+	labels        map[string]map[string]string
+}
+
+// NewFakeSession returns an empty in-memory session.
+func NewFakeSession() *FakeSession {
+	return &FakeSession{
+		Datacenters:   map[string]ionossdk.Datacenter{},
+		Images:        map[string]ionossdk.Image{},
+		Snapshots:     map[string]ionossdk.Snapshot{},
+		IPBlocks:      map[string]ionossdk.IpBlock{},
+		Requests:      map[string]ionossdk.RequestStatus{},
+		servers:       map[string]ionossdk.Server{},
+		volumes:       map[string]ionossdk.Volume{},
+		firewallRules: map[string][]ionossdk.FirewallRule{},
+		labels:        map[string]map[string]string{},
+	}
+}
+
+// newNotFoundError returns an API error for a resource not found.
+//
+// PARAMETERS
+// resourceType string Resource type
+// id           string Resource ID
+func newNotFoundError(resourceType, id string) error {
+	return &spi.APIError{StatusCode: http.StatusNotFound, Err: fmt.Errorf("%s %q not found", resourceType, id)}
+}
+
+// getResourceKey returns the key of a datacenter resource.
+//
+// PARAMETERS
+// datacenterID string Datacenter ID
+// id           string Resource ID
+func getResourceKey(datacenterID, id string) string {
+	return fmt.Sprintf("%s/%s", datacenterID, id)
+}
+
+// newAvailableMetadata returns metadata of an available resource.
+func newAvailableMetadata() *ionossdk.DatacenterElementMetadata {
+	return &ionossdk.DatacenterElementMetadata{State: ionossdk.PtrString("AVAILABLE")}
+}
+
+// GetServerByID returns the server with the ID given.
+//
+// PARAMETERS
+// datacenterID string Datacenter ID
+// serverID     string Server ID
+func (s *FakeSession) GetServerByID(datacenterID, serverID string) (ionossdk.Server, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	server, ok := s.servers[getResourceKey(datacenterID, serverID)]
+	return server, ok
+}
+
+// GetVolumeByID returns the volume with the ID given.
+//
+// PARAMETERS
+// datacenterID string Datacenter ID
+// volumeID     string Volume ID
+func (s *FakeSession) GetVolumeByID(datacenterID, volumeID string) (ionossdk.Volume, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	volume, ok := s.volumes[getResourceKey(datacenterID, volumeID)]
+	return volume, ok
+}
+
+// GetDatacenter returns the datacenter with the ID given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+func (s *FakeSession) GetDatacenter(ctx context.Context, datacenterID string) (ionossdk.Datacenter, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	datacenter, ok := s.Datacenters[datacenterID]
+	if !ok {
+		return ionossdk.Datacenter{}, newNotFoundError("datacenter", datacenterID)
+	}
+
+	return datacenter, nil
+}
+
+// GetImage returns the image with the ID given.
+//
+// PARAMETERS
+// ctx     context.Context Execution context
+// imageID string          Image ID
+func (s *FakeSession) GetImage(ctx context.Context, imageID string) (ionossdk.Image, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	image, ok := s.Images[imageID]
+	if !ok {
+		return ionossdk.Image{}, newNotFoundError("image", imageID)
+	}
+
+	return image, nil
+}
+
+// ListImages returns all images.
+//
+// PARAMETERS
+// ctx context.Context Execution context
+func (s *FakeSession) ListImages(ctx context.Context) ([]ionossdk.Image, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	images := []ionossdk.Image{}
+
+	for _, image := range s.Images {
+		images = append(images, image)
+	}
+
+	return images, nil
+}
+
+// GetSnapshot returns the snapshot with the ID given.
+//
+// PARAMETERS
+// ctx        context.Context Execution context
+// snapshotID string          Snapshot ID
+func (s *FakeSession) GetSnapshot(ctx context.Context, snapshotID string) (ionossdk.Snapshot, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	snapshot, ok := s.Snapshots[snapshotID]
+	if !ok {
+		return ionossdk.Snapshot{}, newNotFoundError("snapshot", snapshotID)
+	}
+
+	return snapshot, nil
+}
+
+// ListServers returns all servers of the datacenter given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// depth        int32           Depth of nested resources returned
+func (s *FakeSession) ListServers(ctx context.Context, datacenterID string, depth int32) ([]ionossdk.Server, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	servers := []ionossdk.Server{}
+
+	for key, server := range s.servers {
+		if getResourceKey(datacenterID, *server.Id) == key {
+			servers = append(servers, server)
+		}
+	}
+
+	return servers, nil
+}
+
+// GetServer returns the server with the ID given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+// depth        int32           Depth of nested resources returned
+func (s *FakeSession) GetServer(ctx context.Context, datacenterID, serverID string, depth int32) (ionossdk.Server, error) {
+	server, ok := s.GetServerByID(datacenterID, serverID)
+	if !ok {
+		return ionossdk.Server{}, newNotFoundError("server", serverID)
+	}
+
+	return server, nil
+}
+
+// CreateServer creates the server given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// server       ionossdk.Server Server to create
+func (s *FakeSession) CreateServer(ctx context.Context, datacenterID string, server ionossdk.Server) (ionossdk.Server, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !server.HasProperties() {
+		return ionossdk.Server{}, &spi.APIError{StatusCode: http.StatusUnprocessableEntity, Err: errors.New("server properties are required")}
+	}
+
+	if !server.HasId() {
+		server.Id = ionossdk.PtrString(uuid.NewString())
+	}
+
+	server.Metadata = newAvailableMetadata()
+	server.Properties.VmState = ionossdk.PtrString("RUNNING")
+
+	if !server.HasEntities() {
+		server.Entities = &ionossdk.ServerEntities{}
+	}
+	if !server.Entities.HasVolumes() {
+		server.Entities.Volumes = &ionossdk.AttachedVolumes{Items: &[]ionossdk.Volume{}}
+	}
+	if !server.Entities.HasNics() {
+		server.Entities.Nics = &ionossdk.Nics{Items: &[]ionossdk.Nic{}}
+	}
+
+	s.servers[getResourceKey(datacenterID, *server.Id)] = server
+
+	return server, nil
+}
+
+// DeleteServer deletes the server with the ID given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+func (s *FakeSession) DeleteServer(ctx context.Context, datacenterID, serverID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := getResourceKey(datacenterID, serverID)
+
+	if _, ok := s.servers[key]; !ok {
+		return newNotFoundError("server", serverID)
+	}
+
+	delete(s.servers, key)
+	delete(s.labels, "server "+key)
+
+	return nil
+}
+
+// setServerVMState sets the VM state of the server with the ID given.
+//
+// PARAMETERS
+// datacenterID string Datacenter ID
+// serverID     string Server ID
+// vmState      string VM state to set
+func (s *FakeSession) setServerVMState(datacenterID, serverID, vmState string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	server, ok := s.servers[getResourceKey(datacenterID, serverID)]
+	if !ok {
+		return newNotFoundError("server", serverID)
+	}
+
+	server.Properties.VmState = ionossdk.PtrString(vmState)
+
+	return nil
+}
+
+// SetServerState sets the metadata state and VM state of the server with the ID given.
+//
+// PARAMETERS
+// datacenterID string Datacenter ID
+// serverID     string Server ID
+// state        string Metadata state to set
+// vmState      string VM state to set
+func (s *FakeSession) SetServerState(datacenterID, serverID, state, vmState string) error {
+	err := s.setServerVMState(datacenterID, serverID, vmState)
+	if nil != err {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.servers[getResourceKey(datacenterID, serverID)].Metadata.State = ionossdk.PtrString(state)
+
+	return nil
+}
+
+// StartServer starts the server with the ID given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+func (s *FakeSession) StartServer(ctx context.Context, datacenterID, serverID string) error {
+	return s.setServerVMState(datacenterID, serverID, "RUNNING")
+}
+
+// StopServer stops the server with the ID given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+func (s *FakeSession) StopServer(ctx context.Context, datacenterID, serverID string) error {
+	return s.setServerVMState(datacenterID, serverID, "SHUTOFF")
+}
+
+// AttachVolume attaches the volume to the server with the IDs given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+// volumeID     string          Volume ID
+func (s *FakeSession) AttachVolume(ctx context.Context, datacenterID, serverID, volumeID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	server, ok := s.servers[getResourceKey(datacenterID, serverID)]
+	if !ok {
+		return newNotFoundError("server", serverID)
+	}
+
+	volume, ok := s.volumes[getResourceKey(datacenterID, volumeID)]
+	if !ok {
+		return newNotFoundError("volume", volumeID)
+	}
+
+	*server.Entities.Volumes.Items = append(*server.Entities.Volumes.Items, volume)
+
+	return nil
+}
+
+// WaitForServer returns the server with the ID given as all modifications are applied immediately.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+func (s *FakeSession) WaitForServer(ctx context.Context, datacenterID, serverID string) (ionossdk.Server, error) {
+	return s.GetServer(ctx, datacenterID, serverID, 0)
+}
+
+// ListVolumes returns all volumes of the datacenter given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// depth        int32           Depth of nested resources returned
+func (s *FakeSession) ListVolumes(ctx context.Context, datacenterID string, depth int32) ([]ionossdk.Volume, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	volumes := []ionossdk.Volume{}
+
+	for key, volume := range s.volumes {
+		if getResourceKey(datacenterID, *volume.Id) == key {
+			volumes = append(volumes, volume)
+		}
+	}
+
+	return volumes, nil
+}
+
+// CreateVolume creates the volume given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// volume       ionossdk.Volume Volume to create
+func (s *FakeSession) CreateVolume(ctx context.Context, datacenterID string, volume ionossdk.Volume) (ionossdk.Volume, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !volume.HasId() {
+		volume.Id = ionossdk.PtrString(uuid.NewString())
+	}
+
+	volume.Metadata = newAvailableMetadata()
+	s.volumes[getResourceKey(datacenterID, *volume.Id)] = volume
+
+	return volume, nil
+}
+
+// DeleteVolume deletes the volume with the ID given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// volumeID     string          Volume ID
+func (s *FakeSession) DeleteVolume(ctx context.Context, datacenterID, volumeID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := getResourceKey(datacenterID, volumeID)
+
+	if _, ok := s.volumes[key]; !ok {
+		return newNotFoundError("volume", volumeID)
+	}
+
+	delete(s.volumes, key)
+	delete(s.labels, "volume "+key)
+
+	return nil
+}
+
+// WaitForVolume returns the volume with the ID given as all modifications are applied immediately.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// volumeID     string          Volume ID
+func (s *FakeSession) WaitForVolume(ctx context.Context, datacenterID, volumeID string) (ionossdk.Volume, error) {
+	volume, ok := s.GetVolumeByID(datacenterID, volumeID)
+	if !ok {
+		return ionossdk.Volume{}, newNotFoundError("volume", volumeID)
+	}
+
+	return volume, nil
+}
+
+// CreateNic creates the NIC given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+// nic          ionossdk.Nic    NIC to create
+func (s *FakeSession) CreateNic(ctx context.Context, datacenterID, serverID string, nic ionossdk.Nic) (ionossdk.Nic, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	server, ok := s.servers[getResourceKey(datacenterID, serverID)]
+	if !ok {
+		return ionossdk.Nic{}, newNotFoundError("server", serverID)
+	}
+
+	if !nic.HasId() {
+		nic.Id = ionossdk.PtrString(uuid.NewString())
+	}
+
+	nic.Metadata = newAvailableMetadata()
+	*server.Entities.Nics.Items = append(*server.Entities.Nics.Items, nic)
+
+	return nic, nil
+}
+
+// WaitForNic returns the NIC with the ID given as all modifications are applied immediately.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+// nicID        string          NIC ID
+func (s *FakeSession) WaitForNic(ctx context.Context, datacenterID, serverID, nicID string) (ionossdk.Nic, error) {
+	server, err := s.GetServer(ctx, datacenterID, serverID, 0)
+	if nil != err {
+		return ionossdk.Nic{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, nic := range *server.Entities.Nics.Items {
+		if nicID == *nic.Id {
+			return nic, nil
+		}
+	}
+
+	return ionossdk.Nic{}, newNotFoundError("nic", nicID)
+}
+
+// ListFirewallRules returns all firewall rules of the NIC given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+// nicID        string          NIC ID
+func (s *FakeSession) ListFirewallRules(ctx context.Context, datacenterID, serverID, nicID string) ([]ionossdk.FirewallRule, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]ionossdk.FirewallRule{}, s.firewallRules[getResourceKey(datacenterID, nicID)]...), nil
+}
+
+// CreateFirewallRule creates the firewall rule given.
+//
+// PARAMETERS
+// ctx          context.Context       Execution context
+// datacenterID string                Datacenter ID
+// serverID     string                Server ID
+// nicID        string                NIC ID
+// rule         ionossdk.FirewallRule Firewall rule to create
+func (s *FakeSession) CreateFirewallRule(ctx context.Context, datacenterID, serverID, nicID string, rule ionossdk.FirewallRule) (ionossdk.FirewallRule, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !rule.HasId() {
+		rule.Id = ionossdk.PtrString(uuid.NewString())
+	}
+
+	key := getResourceKey(datacenterID, nicID)
+	s.firewallRules[key] = append(s.firewallRules[key], rule)
+
+	return rule, nil
+}
+
+// getLabels returns a copy of the labels of the resource given.
+//
+// PARAMETERS
+// resourceKey string Resource key
+func (s *FakeSession) getLabels(resourceKey string) map[string]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	labels := map[string]string{}
+
+	for key, value := range s.labels[resourceKey] {
+		labels[key] = value
+	}
+
+	return labels
+}
+
+// addLabel adds a label to the resource given.
+//
+// PARAMETERS
+// resourceKey string Resource key
+// key         string Label key
+// value       string Label value
+func (s *FakeSession) addLabel(resourceKey, key, value string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.labels[resourceKey]; !ok {
+		s.labels[resourceKey] = map[string]string{}
+	}
+
+	s.labels[resourceKey][key] = value
+}
+
+// GetServerLabels returns the labels of the server with the ID given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+func (s *FakeSession) GetServerLabels(ctx context.Context, datacenterID, serverID string) (map[string]string, error) {
+	return s.getLabels("server " + getResourceKey(datacenterID, serverID)), nil
+}
+
+// ListServerLabels returns the labels with the keys given of all servers in the datacenter indexed by server ID.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// keys         ...string       Label keys
+func (s *FakeSession) ListServerLabels(ctx context.Context, datacenterID string, keys ...string) (map[string]map[string]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	serverLabels := map[string]map[string]string{}
+
+	for resourceKey, server := range s.servers {
+		if getResourceKey(datacenterID, *server.Id) != resourceKey {
+			continue
+		}
+
+		labels := map[string]string{}
+
+		for _, key := range keys {
+			if value, ok := s.labels["server "+resourceKey][key]; ok {
+				labels[key] = value
+			}
+		}
+
+		if 0 < len(labels) {
+			serverLabels[*server.Id] = labels
+		}
+	}
+
+	return serverLabels, nil
+}
+
+// AddServerLabel adds a label to the server with the ID given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+// key          string          Label key
+// value        string          Label value
+func (s *FakeSession) AddServerLabel(ctx context.Context, datacenterID, serverID, key, value string) error {
+	if _, ok := s.GetServerByID(datacenterID, serverID); !ok {
+		return newNotFoundError("server", serverID)
+	}
+
+	s.addLabel("server "+getResourceKey(datacenterID, serverID), key, value)
+
+	return nil
+}
+
+// GetVolumeLabels returns the labels of the volume with the ID given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// volumeID     string          Volume ID
+func (s *FakeSession) GetVolumeLabels(ctx context.Context, datacenterID, volumeID string) (map[string]string, error) {
+	return s.getLabels("volume " + getResourceKey(datacenterID, volumeID)), nil
+}
+
+// AddVolumeLabel adds a label to the volume with the ID given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// volumeID     string          Volume ID
+// key          string          Label key
+// value        string          Label value
+func (s *FakeSession) AddVolumeLabel(ctx context.Context, datacenterID, volumeID, key, value string) error {
+	if _, ok := s.GetVolumeByID(datacenterID, volumeID); !ok {
+		return newNotFoundError("volume", volumeID)
+	}
+
+	s.addLabel("volume "+getResourceKey(datacenterID, volumeID), key, value)
+
+	return nil
+}
+
+// GetIPBlock returns the IP block with the ID given.
+//
+// PARAMETERS
+// ctx       context.Context Execution context
+// ipBlockID string          IP block ID
+func (s *FakeSession) GetIPBlock(ctx context.Context, ipBlockID string) (ionossdk.IpBlock, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ipBlock, ok := s.IPBlocks[ipBlockID]
+	if !ok {
+		return ionossdk.IpBlock{}, newNotFoundError("IP block", ipBlockID)
+	}
+
+	return ipBlock, nil
+}
+
+// GetRequestStatus returns the status of the IONOS API request with the ID given.
+//
+// PARAMETERS
+// ctx       context.Context Execution context
+// requestID string          Request ID
+func (s *FakeSession) GetRequestStatus(ctx context.Context, requestID string) (ionossdk.RequestStatus, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	requestStatus, ok := s.Requests[requestID]
+	if !ok {
+		return ionossdk.RequestStatus{}, newNotFoundError("request", requestID)
+	}
+
+	return requestStatus, nil
+}
@@ -17,21 +17,262 @@ limitations under the License.
 // Package apis is the main package for provider specific APIs
 package apis
 
+import (
+	"fmt"
+)
+
 // ProviderSpec is the spec to be used while parsing the calls.
 type ProviderSpec struct {
 	DatacenterID string `json:"datacenterID,omitempty"`
 	Cluster      string `json:"cluster"`
 	Zone         string `json:"zone"`
-	Cores        uint   `json:"cores"`
-	Memory       uint   `json:"memory"`
-	ImageID      string `json:"imageID"`
-	SSHKey       string `json:"sshKey"`
+	// AvailabilityZones maps Gardener zone names to IONOS availability zones (AUTO, ZONE_1 or ZONE_2).
+	AvailabilityZones map[string]string `json:"availabilityZones,omitempty"`
+	Cores             uint              `json:"cores"`
+	Memory            uint              `json:"memory"`
+	// ImageID is the UUID of the image to boot from. It is kept for backward compatibility with Image.
+	ImageID string `json:"imageID,omitempty"`
+	// Image references the image to boot from by UUID, alias, name pattern or snapshot UUID.
+	Image *ImageReference `json:"image,omitempty"`
+	// SSHKey is a SSH public key to authorize. It is kept for backward compatibility with SSHKeys.
+	SSHKey string `json:"sshKey,omitempty"`
+	// SSHKeys contains SSH public keys to authorize in the authorized_keys format.
+	SSHKeys []string `json:"sshKeys,omitempty"`
+	// SSHKeySecretKeys contains keys of the machine secret whose values contain authorized_keys lines to authorize.
+	SSHKeySecretKeys []string `json:"sshKeySecretKeys,omitempty"`
+	// CPUFamily is the CPU family of ENTERPRISE servers (e.g. AMD_OPTERON or INTEL_SKYLAKE).
+	CPUFamily string `json:"cpuFamily,omitempty"`
+	// Type is the server type (ENTERPRISE or CUBE). Default: ENTERPRISE
+	Type string `json:"type,omitempty"`
+	// TemplateUUID is the template defining cores, memory and DAS root volume size of CUBE servers.
+	TemplateUUID string `json:"templateUuid,omitempty"`
 
 	FloatingPoolID string      `json:"floatingPoolID,omitempty"`
 	NetworkIDs     *NetworkIDs `json:"networkIDs,omitempty"`
+	// NetworkInterfaces contains the network interfaces to attach. It replaces NetworkIDs and FloatingPoolID.
+	NetworkInterfaces []NetworkInterface `json:"networkInterfaces,omitempty"`
 	// Default: If you're creating the volume from a snapshot and don't specify
 	// a volume size, the default is the snapshot size.
-	VolumeSize     float32     `json:"volumeSize,omitempty"`
+	VolumeSize float32 `json:"volumeSize,omitempty"`
+	// VolumeType is the root volume type (HDD, SSD Standard, SSD Premium or DAS). Default: SSD Premium
+	VolumeType string `json:"volumeType,omitempty"`
+	// VolumeBus is the bus type of the root volume (VIRTIO or IDE). Default: VIRTIO
+	VolumeBus string `json:"bus,omitempty"`
+	// VolumeAvailabilityZone is the storage availability zone of the root volume (AUTO, ZONE_1, ZONE_2 or ZONE_3).
+	VolumeAvailabilityZone string `json:"availabilityZone,omitempty"`
+	// DataVolumes contains additional volumes to provision and attach.
+	DataVolumes []DataVolume `json:"dataVolumes,omitempty"`
+	// RestartOnShutoff is true if a server found SHUTOFF after the machine has been created should be started again.
+	RestartOnShutoff bool `json:"restartOnShutoff,omitempty"`
+}
+
+// GetImageReference returns the reference of the image to boot from. ImageID is used if Image is not specified.
+func (spec *ProviderSpec) GetImageReference() *ImageReference {
+	if nil == spec.Image {
+		return &ImageReference{ID: spec.ImageID}
+	}
+
+	return spec.Image
+}
+
+// GetAvailabilityZone returns the IONOS availability zone mapped to the zone or an empty string if none is mapped.
+func (spec *ProviderSpec) GetAvailabilityZone() string {
+	return spec.AvailabilityZones[spec.Zone]
+}
+
+// GetType returns the server type to use.
+func (spec *ProviderSpec) GetType() string {
+	if "" == spec.Type {
+		return ServerTypeEnterprise
+	}
+
+	return spec.Type
+}
+
+// IsCube returns true if the server type is CUBE.
+func (spec *ProviderSpec) IsCube() bool {
+	return ServerTypeCube == spec.GetType()
+}
+
+// GetVolumeBus returns the bus type to use for the root volume.
+func (spec *ProviderSpec) GetVolumeBus() string {
+	if "" == spec.VolumeBus {
+		return VolumeBusVirtIO
+	}
+
+	return spec.VolumeBus
+}
+
+// GetVolumeType returns the volume type to use for the root volume.
+func (spec *ProviderSpec) GetVolumeType() string {
+	if spec.IsCube() {
+		return VolumeTypeDAS
+	}
+
+	if "" == spec.VolumeType {
+		return VolumeTypeSSDPremium
+	}
+
+	return spec.VolumeType
+}
+
+// GetNetworkInterfaces returns the network interfaces to attach. Legacy NetworkIDs are converted if
+// NetworkInterfaces are not specified.
+func (spec *ProviderSpec) GetNetworkInterfaces() []NetworkInterface {
+	if len(spec.NetworkInterfaces) > 0 || nil == spec.NetworkIDs {
+		return spec.NetworkInterfaces
+	}
+
+	networkInterfaces := []NetworkInterface{
+		{
+			Name:           "wan",
+			LANID:          spec.NetworkIDs.WAN,
+			IPBlockID:      spec.FloatingPoolID,
+			FirewallActive: true,
+		},
+	}
+
+	if "" != spec.NetworkIDs.Workers {
+		dhcp := false
+
+		networkInterfaces = append(networkInterfaces, NetworkInterface{
+			Name:  "workers",
+			LANID: spec.NetworkIDs.Workers,
+			DHCP:  &dhcp,
+		})
+	}
+
+	return networkInterfaces
+}
+
+// ImageReference references the image to boot from. Exactly one field must be set.
+type ImageReference struct {
+	// ID is the UUID of an image.
+	ID string `json:"id,omitempty"`
+	// Alias is an IONOS image alias (e.g. ubuntu:latest) resolved in the location of the datacenter.
+	Alias string `json:"alias,omitempty"`
+	// Name is a pattern (e.g. ubuntu-20.04-*) matching the names of images in the location of the datacenter.
+	// The newest image matching is used.
+	Name string `json:"name,omitempty"`
+	// SnapshotID is the UUID of a snapshot.
+	SnapshotID string `json:"snapshotID,omitempty"`
+	// SnapshotCloudInit confirms that the snapshot referenced has been taken from a cloud-init enabled image. The
+	// IONOS API doesn't report cloud-init support for snapshots. It is required together with SnapshotID.
+	SnapshotCloudInit bool `json:"snapshotCloudInit,omitempty"`
+}
+
+// String returns a human readable representation of the image reference.
+func (reference *ImageReference) String() string {
+	switch {
+	case "" != reference.Alias:
+		return fmt.Sprintf("alias %q", reference.Alias)
+	case "" != reference.Name:
+		return fmt.Sprintf("name %q", reference.Name)
+	case "" != reference.SnapshotID:
+		return fmt.Sprintf("snapshot %q", reference.SnapshotID)
+	}
+
+	return fmt.Sprintf("image %q", reference.ID)
+}
+
+// DataVolume holds the specification of an additional volume.
+type DataVolume struct {
+	// Name is appended to the machine name to build the volume name.
+	Name string `json:"name"`
+	// Size is the volume size in bytes. It is rounded up to full gigabytes.
+	Size float32 `json:"size"`
+	// Type is the volume type (HDD, SSD Standard or SSD Premium). Default: SSD Standard
+	Type string `json:"type,omitempty"`
+	// Bus is the bus type of the volume (VIRTIO or IDE). Default: VIRTIO
+	Bus string `json:"bus,omitempty"`
+	// AvailabilityZone is the storage availability zone (AUTO, ZONE_1, ZONE_2 or ZONE_3).
+	AvailabilityZone string `json:"availabilityZone,omitempty"`
+	// DeleteOnTermination is false if the volume should be kept after the machine is deleted. Default: true
+	DeleteOnTermination *bool `json:"deleteOnTermination,omitempty"`
+}
+
+// GetBus returns the bus type to use for the volume.
+func (volume *DataVolume) GetBus() string {
+	if "" == volume.Bus {
+		return VolumeBusVirtIO
+	}
+
+	return volume.Bus
+}
+
+// GetType returns the volume type to use for the volume.
+func (volume *DataVolume) GetType() string {
+	if "" == volume.Type {
+		return VolumeTypeSSDStandard
+	}
+
+	return volume.Type
+}
+
+// IsDeletedOnTermination returns true if the volume should be deleted together with the machine.
+func (volume *DataVolume) IsDeletedOnTermination() bool {
+	return nil == volume.DeleteOnTermination || *volume.DeleteOnTermination
+}
+
+// NetworkInterface holds the specification of a network interface.
+type NetworkInterface struct {
+	// Name is the name of the network interface.
+	Name string `json:"name,omitempty"`
+	// LANID is the ID of the LAN to attach the network interface to.
+	LANID string `json:"lanID"`
+	// DHCP is false if DHCP should be disabled for the network interface. Default: true
+	DHCP *bool `json:"dhcp,omitempty"`
+	// IPs contains static IPs to assign.
+	IPs []string `json:"ips,omitempty"`
+	// IPBlockID is the ID of an IP block to select an unused IP to assign from.
+	IPBlockID string `json:"ipBlockID,omitempty"`
+	// FirewallActive is true if the firewall should be activated for the network interface.
+	FirewallActive bool `json:"firewallActive,omitempty"`
+	// FirewallType is the firewall type (INGRESS, EGRESS or BIDIRECTIONAL). Default: INGRESS
+	FirewallType string `json:"firewallType,omitempty"`
+	// FirewallRules contains the rules of the active firewall.
+	FirewallRules []FirewallRule `json:"firewallRules,omitempty"`
+}
+
+// FirewallRule holds the specification of a network interface firewall rule.
+type FirewallRule struct {
+	// Name is the name of the firewall rule. Default: rule-<index>
+	Name string `json:"name,omitempty"`
+	// Protocol is the protocol of the firewall rule (TCP, UDP, ICMP or ANY).
+	Protocol string `json:"protocol"`
+	// SourceIP is the IP or CIDR traffic has to originate from. Default: any
+	SourceIP string `json:"sourceIP,omitempty"`
+	// SourceMAC is the MAC address traffic has to originate from. Default: any
+	SourceMAC string `json:"sourceMAC,omitempty"`
+	// TargetIP is the IP or CIDR traffic has to be directed to. Default: any
+	TargetIP string `json:"targetIP,omitempty"`
+	// PortRangeStart is the first port allowed for TCP or UDP. Default: any
+	PortRangeStart *int32 `json:"portRangeStart,omitempty"`
+	// PortRangeEnd is the last port allowed for TCP or UDP. Default: PortRangeStart
+	PortRangeEnd *int32 `json:"portRangeEnd,omitempty"`
+	// ICMPType is the ICMP type allowed. Default: any
+	ICMPType *int32 `json:"icmpType,omitempty"`
+	// ICMPCode is the ICMP code allowed. Default: any
+	ICMPCode *int32 `json:"icmpCode,omitempty"`
+	// Direction is the traffic direction of the firewall rule (INGRESS or EGRESS). Default: INGRESS
+	Direction string `json:"direction,omitempty"`
+}
+
+// GetName returns the name of the firewall rule at the index given.
+//
+// PARAMETERS
+// index int Index of the firewall rule
+func (rule *FirewallRule) GetName(index int) string {
+	if "" == rule.Name {
+		return fmt.Sprintf("rule-%d", index)
+	}
+
+	return rule.Name
+}
+
+// IsDHCPEnabled returns true if DHCP should be enabled for the network interface.
+func (networkInterface *NetworkInterface) IsDHCPEnabled() bool {
+	return nil == networkInterface.DHCP || *networkInterface.DHCP
 }
 
 // Networks holds information about the Kubernetes and infrastructure networks.
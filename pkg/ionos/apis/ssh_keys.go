@@ -0,0 +1,170 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apis is the main package for provider specific APIs
+package apis
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// SSHKeyTypeRSA is the RSA public key type
+	SSHKeyTypeRSA = "ssh-rsa"
+	// SSHKeyTypeED25519 is the Ed25519 public key type
+	SSHKeyTypeED25519 = "ssh-ed25519"
+	// SSHKeyTypeECDSAP256 is the ECDSA public key type using the NIST P-256 curve
+	SSHKeyTypeECDSAP256 = "ecdsa-sha2-nistp256"
+	// SSHKeyTypeECDSAP384 is the ECDSA public key type using the NIST P-384 curve
+	SSHKeyTypeECDSAP384 = "ecdsa-sha2-nistp384"
+	// SSHKeyTypeECDSAP521 is the ECDSA public key type using the NIST P-521 curve
+	SSHKeyTypeECDSAP521 = "ecdsa-sha2-nistp521"
+)
+
+// SSHKeyTypes contains all supported SSH public key types
+var SSHKeyTypes = []string{SSHKeyTypeRSA, SSHKeyTypeED25519, SSHKeyTypeECDSAP256, SSHKeyTypeECDSAP384, SSHKeyTypeECDSAP521}
+
+// ParseAuthorizedKey parses the authorized_keys line given and returns the public key without options.
+//
+// PARAMETERS
+// line string authorized_keys line to parse
+func ParseAuthorizedKey(line string) (string, error) {
+	fields := strings.Fields(line)
+
+	// Options may precede the key type. They are skipped up to the first supported key type.
+	for index, field := range fields {
+		if !isSSHKeyTypeSupported(field) {
+			continue
+		}
+
+		if index+1 == len(fields) {
+			return "", fmt.Errorf("%s key data is missing", field)
+		}
+
+		err := validateSSHKeyData(field, fields[index+1])
+		if nil != err {
+			return "", err
+		}
+
+		return strings.Join(fields[index:], " "), nil
+	}
+
+	return "", fmt.Errorf("key type is not supported (supported: %s)", strings.Join(SSHKeyTypes, ", "))
+}
+
+// isSSHKeyTypeSupported returns true if the SSH public key type given is supported.
+//
+// PARAMETERS
+// keyType string SSH public key type
+func isSSHKeyTypeSupported(keyType string) bool {
+	for _, supportedKeyType := range SSHKeyTypes {
+		if keyType == supportedKeyType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateSSHKeyData validates that the base64 encoded key data given contains a public key of the type given.
+//
+// PARAMETERS
+// keyType string SSH public key type
+// keyData string Base64 encoded key data
+func validateSSHKeyData(keyType, keyData string) error {
+	data, err := base64.StdEncoding.DecodeString(keyData)
+	if nil != err {
+		return fmt.Errorf("%s key data is not base64 encoded", keyType)
+	}
+
+	// The key data starts with the length prefixed key type followed by the key type specific fields.
+	if len(data) < 4 {
+		return fmt.Errorf("%s key data is truncated", keyType)
+	}
+
+	length := binary.BigEndian.Uint32(data)
+	if uint32(len(data)-4) < length {
+		return fmt.Errorf("%s key data is truncated", keyType)
+	}
+
+	if !bytes.Equal([]byte(keyType), data[4:4+length]) {
+		return fmt.Errorf("%s key data contains a %q key", keyType, data[4:4+length])
+	}
+
+	if 4+int(length) == len(data) {
+		return fmt.Errorf("%s key data contains no public key", keyType)
+	}
+
+	return nil
+}
+
+// GetSSHKeys returns the SSH public keys of the provider specification including the ones referenced in the
+// secret given. Duplicates are removed.
+//
+// PARAMETERS
+// secret *corev1.Secret Kubernetes secret containing referenced SSH public keys
+func (spec *ProviderSpec) GetSSHKeys(secret *corev1.Secret) ([]string, error) {
+	lines := []string{}
+
+	if "" != spec.SSHKey {
+		lines = append(lines, spec.SSHKey)
+	}
+
+	lines = append(lines, spec.SSHKeys...)
+
+	for _, key := range spec.SSHKeySecretKeys {
+		if nil == secret {
+			return nil, errors.New("secret containing SSH keys referenced is required")
+		}
+
+		value, ok := secret.Data[key]
+		if !ok {
+			return nil, fmt.Errorf("secret does not contain the SSH keys referenced as %q", key)
+		}
+
+		for _, line := range strings.Split(string(value), "\n") {
+			line = strings.TrimSpace(line)
+
+			if "" != line && !strings.HasPrefix(line, "#") {
+				lines = append(lines, line)
+			}
+		}
+	}
+
+	sshKeys := []string{}
+	knownSSHKeys := map[string]bool{}
+
+	for _, line := range lines {
+		sshKey, err := ParseAuthorizedKey(line)
+		if nil != err {
+			return nil, err
+		}
+
+		if !knownSSHKeys[sshKey] {
+			sshKeys = append(sshKeys, sshKey)
+			knownSSHKeys[sshKey] = true
+		}
+	}
+
+	return sshKeys, nil
+}
@@ -30,6 +30,8 @@ var _ = Describe("Transcoder", func() {
 
 	providerSecret := &corev1.Secret{
 		Data: map[string][]byte{
+			"user":     []byte("dummy-user"),
+			"password": []byte("dummy-password"),
 			"userData": []byte("dummy-user-data"),
 		},
 	}
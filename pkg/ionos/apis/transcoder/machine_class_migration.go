@@ -0,0 +1,117 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package transcoder is used for API related object transformations
+package transcoder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis"
+	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DecodeIonosMachineClass decodes the given provider specific machine class object.
+//
+// PARAMETERS
+// providerSpecificMachineClass interface{} Typed or JSON serializable legacy machine class object
+func DecodeIonosMachineClass(providerSpecificMachineClass interface{}) (*apis.IonosMachineClass, error) {
+	switch ionosMachineClass := providerSpecificMachineClass.(type) {
+	case nil:
+		return nil, errors.New("Provider specific machine class provided is nil")
+	case *apis.IonosMachineClass:
+		if nil == ionosMachineClass {
+			return nil, errors.New("Provider specific machine class provided is nil")
+		}
+
+		return ionosMachineClass, nil
+	case apis.IonosMachineClass:
+		return &ionosMachineClass, nil
+	}
+
+	jsonData, err := json.Marshal(providerSpecificMachineClass)
+	if nil != err {
+		return nil, fmt.Errorf("Failed to serialize provider specific machine class: %v", err)
+	}
+
+	var ionosMachineClass *apis.IonosMachineClass
+
+	err = json.Unmarshal(jsonData, &ionosMachineClass)
+	if nil != err {
+		return nil, fmt.Errorf("Failed to parse JSON data provided as provider specific machine class: %v", err)
+	}
+
+	return ionosMachineClass, nil
+}
+
+// EncodeProviderSpecFromIonosMachineClass returns the ProviderSpec for the given legacy machine class.
+//
+// PARAMETERS
+// ionosMachineClass *apis.IonosMachineClass Legacy machine class
+func EncodeProviderSpecFromIonosMachineClass(ionosMachineClass *apis.IonosMachineClass) *apis.ProviderSpec {
+	spec := ionosMachineClass.Spec
+
+	providerSpec := &apis.ProviderSpec{
+		DatacenterID:   spec.DatacenterID,
+		Cluster:        spec.Cluster,
+		Zone:           spec.Zone,
+		Cores:          spec.Cores,
+		Memory:         spec.Memory,
+		ImageID:        spec.ImageID,
+		SSHKey:         spec.SSHKey,
+		FloatingPoolID: spec.FloatingPoolID,
+		VolumeSize:     spec.VolumeSize,
+	}
+
+	if nil != spec.NetworkIDs {
+		networkIDs := *spec.NetworkIDs
+		providerSpec.NetworkIDs = &networkIDs
+	} else if "" != spec.NetworkID {
+		providerSpec.NetworkIDs = &apis.NetworkIDs{WAN: spec.NetworkID}
+	}
+
+	return providerSpec
+}
+
+// EncodeMachineClassFromIonosMachineClass fills the given generic machine class based on the legacy machine class given.
+//
+// PARAMETERS
+// ionosMachineClass *apis.IonosMachineClass Legacy machine class
+// machineClass      *v1alpha1.MachineClass  Generic machine class to fill
+func EncodeMachineClassFromIonosMachineClass(ionosMachineClass *apis.IonosMachineClass, machineClass *v1alpha1.MachineClass) error {
+	if nil == machineClass {
+		return errors.New("MachineClass provided is nil")
+	}
+
+	providerSpecData, err := json.Marshal(EncodeProviderSpecFromIonosMachineClass(ionosMachineClass))
+	if nil != err {
+		return fmt.Errorf("Failed to serialize ProviderSpec: %v", err)
+	}
+
+	machineClass.Name = ionosMachineClass.Name
+	machineClass.Labels = ionosMachineClass.Labels
+	machineClass.Annotations = ionosMachineClass.Annotations
+	machineClass.Finalizers = ionosMachineClass.Finalizers
+	machineClass.ProviderSpec = runtime.RawExtension{Raw: providerSpecData}
+	machineClass.SecretRef = ionosMachineClass.Spec.SecretRef
+	machineClass.CredentialsSecretRef = ionosMachineClass.Spec.CredentialsSecretRef
+	machineClass.Provider = apis.ProviderName
+
+	return nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package transcoder is used for API related object transformations
+package transcoder
+
+import (
+	"encoding/json"
+
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis"
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis/mock"
+	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("MachineClassMigration", func() {
+	providerSecret := &corev1.Secret{
+		Data: map[string][]byte{
+			"user":     []byte("dummy-user"),
+			"password": []byte("dummy-password"),
+			"userData": []byte("dummy-user-data"),
+		},
+	}
+
+	Describe("#DecodeIonosMachineClass", func() {
+		It("should correctly return a typed legacy machine class", func() {
+			ionosMachineClass := mock.NewIonosMachineClass()
+
+			decodedMachineClass, err := DecodeIonosMachineClass(ionosMachineClass)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decodedMachineClass).To(Equal(ionosMachineClass))
+		})
+
+		It("should correctly parse a generic legacy machine class object", func() {
+			ionosMachineClass := mock.NewIonosMachineClass()
+
+			jsonData, err := json.Marshal(ionosMachineClass)
+			Expect(err).NotTo(HaveOccurred())
+
+			var genericMachineClass map[string]interface{}
+			Expect(json.Unmarshal(jsonData, &genericMachineClass)).To(Succeed())
+
+			decodedMachineClass, err := DecodeIonosMachineClass(genericMachineClass)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decodedMachineClass.Name).To(Equal(ionosMachineClass.Name))
+			Expect(decodedMachineClass.Spec).To(Equal(ionosMachineClass.Spec))
+		})
+
+		It("should fail if no legacy machine class is provided", func() {
+			_, err := DecodeIonosMachineClass(nil)
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("#EncodeMachineClassFromIonosMachineClass", func() {
+		It("should correctly generate a machine class that can be decoded again", func() {
+			ionosMachineClass := mock.NewIonosMachineClass()
+			machineClass := &v1alpha1.MachineClass{}
+
+			err := EncodeMachineClassFromIonosMachineClass(ionosMachineClass, machineClass)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(machineClass.Name).To(Equal(ionosMachineClass.Name))
+			Expect(machineClass.Labels).To(Equal(ionosMachineClass.Labels))
+			Expect(machineClass.Finalizers).To(Equal(ionosMachineClass.Finalizers))
+			Expect(machineClass.SecretRef).To(Equal(ionosMachineClass.Spec.SecretRef))
+			Expect(machineClass.CredentialsSecretRef).To(BeNil())
+			Expect(machineClass.Provider).To(Equal(apis.ProviderName))
+
+			providerSpec, err := DecodeProviderSpecFromMachineClass(machineClass, providerSecret)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(providerSpec).To(Equal(mock.NewProviderSpec()))
+		})
+
+		It("should prefer networkIDs over the deprecated networkID", func() {
+			ionosMachineClass := mock.NewIonosMachineClass()
+			ionosMachineClass.Spec.NetworkIDs = &apis.NetworkIDs{WAN: "2", Workers: "3"}
+
+			providerSpec := EncodeProviderSpecFromIonosMachineClass(ionosMachineClass)
+
+			Expect(providerSpec.NetworkIDs).To(Equal(&apis.NetworkIDs{WAN: "2", Workers: "3"}))
+		})
+
+		It("should fail if no machine class to fill is provided", func() {
+			err := EncodeMachineClassFromIonosMachineClass(mock.NewIonosMachineClass(), nil)
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,94 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package transcoder is used for API related object transformations
+package transcoder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Constant IonosCSIDriverName is the driver name registered by the IONOS cloud CSI driver
+const IonosCSIDriverName = "cloud.ionos.com"
+
+// IsIonosCSIPersistentVolumeSpec returns true if the PV spec given has been provisioned by the IONOS cloud CSI driver.
+//
+// PARAMETERS
+// pvSpec *corev1.PersistentVolumeSpec PV spec to check
+func IsIonosCSIPersistentVolumeSpec(pvSpec *corev1.PersistentVolumeSpec) bool {
+	return nil != pvSpec && nil != pvSpec.CSI && IonosCSIDriverName == pvSpec.CSI.Driver
+}
+
+// DecodeVolumeIDFromPVSpec decodes the IONOS volume ID of a PV spec provisioned by the IONOS cloud CSI driver.
+//
+// PARAMETERS
+// pvSpec *corev1.PersistentVolumeSpec PV spec to decode
+func DecodeVolumeIDFromPVSpec(pvSpec *corev1.PersistentVolumeSpec) (string, error) {
+	if !IsIonosCSIPersistentVolumeSpec(pvSpec) {
+		return "", errors.New("PV spec given has not been provisioned by the IONOS cloud CSI driver")
+	}
+
+	return DecodeVolumeIDFromVolumeHandle(pvSpec.CSI.VolumeHandle)
+}
+
+// DecodeVolumeIDFromVolumeHandle decodes the IONOS volume ID of the given CSI volume handle.
+// Supported formats are "<volumeID>", "<datacenterID>/<volumeID>" and
+// "datacenters/<datacenterID>/volumes/<volumeID>".
+//
+// PARAMETERS
+// volumeHandle string CSI volume handle to parse
+func DecodeVolumeIDFromVolumeHandle(volumeHandle string) (string, error) {
+	volumeHandleData := strings.Split(strings.Trim(volumeHandle, "/"), "/")
+
+	var volumeID string
+
+	switch len(volumeHandleData) {
+	case 1:
+		volumeID = volumeHandleData[0]
+	case 2:
+		volumeID = volumeHandleData[1]
+
+		_, err := uuid.Parse(volumeHandleData[0])
+		if nil != err {
+			return "", fmt.Errorf("DatacenterID found is invalid: %v", err)
+		}
+	case 4:
+		if "datacenters" != volumeHandleData[0] || "volumes" != volumeHandleData[2] {
+			return "", errors.New("Volume handle given contains an unsupported path")
+		}
+
+		volumeID = volumeHandleData[3]
+
+		_, err := uuid.Parse(volumeHandleData[1])
+		if nil != err {
+			return "", fmt.Errorf("DatacenterID found is invalid: %v", err)
+		}
+	default:
+		return "", errors.New("Volume handle given is malformed")
+	}
+
+	_, err := uuid.Parse(volumeID)
+	if nil != err {
+		return "", fmt.Errorf("VolumeID found is invalid: %v", err)
+	}
+
+	return volumeID, nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package transcoder is used for API related object transformations
+package transcoder
+
+import (
+	"fmt"
+
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis/mock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("VolumeID", func() {
+	Describe("#DecodeVolumeIDFromVolumeHandle", func() {
+		It("should correctly parse a plain volume ID", func() {
+			volumeID, err := DecodeVolumeIDFromVolumeHandle(mock.TestServerVolumeID)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(volumeID).To(Equal(mock.TestServerVolumeID))
+		})
+
+		It("should correctly parse a datacenter and volume ID pair", func() {
+			volumeID, err := DecodeVolumeIDFromVolumeHandle(fmt.Sprintf("%s/%s", mock.TestProviderSpecDatacenterID, mock.TestServerVolumeID))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(volumeID).To(Equal(mock.TestServerVolumeID))
+		})
+
+		It("should correctly parse a datacenter and volume resource path", func() {
+			volumeID, err := DecodeVolumeIDFromVolumeHandle(fmt.Sprintf("datacenters/%s/volumes/%s", mock.TestProviderSpecDatacenterID, mock.TestServerVolumeID))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(volumeID).To(Equal(mock.TestServerVolumeID))
+		})
+
+		It("should fail if an invalid volume ID is provided", func() {
+			_, err := DecodeVolumeIDFromVolumeHandle("test")
+
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail if an invalid datacenter ID is provided", func() {
+			_, err := DecodeVolumeIDFromVolumeHandle(fmt.Sprintf("test/%s", mock.TestServerVolumeID))
+
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail if an unsupported resource path is provided", func() {
+			_, err := DecodeVolumeIDFromVolumeHandle(fmt.Sprintf("datacenters/%s/snapshots/%s", mock.TestProviderSpecDatacenterID, mock.TestServerVolumeID))
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("#DecodeVolumeIDFromPVSpec", func() {
+		It("should correctly parse an IONOS CSI PV spec", func() {
+			pvSpec := &corev1.PersistentVolumeSpec{
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					CSI: &corev1.CSIPersistentVolumeSource{
+						Driver:       IonosCSIDriverName,
+						VolumeHandle: mock.TestServerVolumeID,
+					},
+				},
+			}
+
+			volumeID, err := DecodeVolumeIDFromPVSpec(pvSpec)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(volumeID).To(Equal(mock.TestServerVolumeID))
+		})
+
+		It("should fail if a foreign CSI PV spec is provided", func() {
+			pvSpec := &corev1.PersistentVolumeSpec{
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					CSI: &corev1.CSIPersistentVolumeSource{
+						Driver:       "ebs.csi.aws.com",
+						VolumeHandle: mock.TestServerVolumeID,
+					},
+				},
+			}
+
+			_, err := DecodeVolumeIDFromPVSpec(pvSpec)
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -19,9 +19,15 @@ package validation
 
 import (
 	"fmt"
+	"net"
+	"path"
+	"sort"
+	"strconv"
 
 	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis"
+	"github.com/google/uuid"
 	corev1 "k8s.io/api/core/v1"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
 )
 
 // ValidateIonosProviderSpec validates provider specification and secret to check if all fields are present and valid
@@ -41,24 +47,409 @@ func ValidateIonosProviderSpec(spec *apis.ProviderSpec, secrets *corev1.Secret)
 	if "" == spec.Zone {
 		allErrs = append(allErrs, fmt.Errorf("zone is a required field"))
 	}
-	if spec.Cores == 0 {
-		allErrs = append(allErrs, fmt.Errorf("cores is a required field"))
-	}
-	if spec.Memory == 0 {
-		allErrs = append(allErrs, fmt.Errorf("memory is a required field"))
-	}
-	if "" == spec.ImageID {
-		allErrs = append(allErrs, fmt.Errorf("imageID is a required field"))
+
+	allErrs = append(allErrs, validateImage(spec)...)
+	allErrs = append(allErrs, validateSSHKeys(spec, secrets)...)
+
+	_, err := apis.GetCredentialsFromSecret(secrets)
+	if nil != err {
+		allErrs = append(allErrs, err)
 	}
-	if "" == spec.SSHKey {
-		allErrs = append(allErrs, fmt.Errorf("sshKey is a required field"))
+
+	_, err = apis.GetClientSettingsFromSecret(secrets)
+	if nil != err {
+		allErrs = append(allErrs, err)
 	}
 
-	if nil == spec.NetworkIDs || "" == spec.NetworkIDs.WAN {
+	if len(spec.NetworkInterfaces) > 0 {
+		if nil != spec.NetworkIDs {
+			allErrs = append(allErrs, fmt.Errorf("networkIDs must not be set together with networkInterfaces"))
+		}
+		if "" != spec.FloatingPoolID {
+			allErrs = append(allErrs, fmt.Errorf("floatingPoolID must not be set together with networkInterfaces"))
+		}
+
+		allErrs = append(allErrs, validateNetworkInterfaces(spec.NetworkInterfaces)...)
+	} else if nil == spec.NetworkIDs || "" == spec.NetworkIDs.WAN {
 		allErrs = append(allErrs, fmt.Errorf("networkIDs.wan is a required field"))
 	}
 
+	allErrs = append(allErrs, validateAvailabilityZones(spec)...)
+	allErrs = append(allErrs, validateServerType(spec)...)
+
+	if !isValueSupported(spec.GetVolumeType(), apis.RootVolumeTypes) {
+		allErrs = append(allErrs, fmt.Errorf("volumeType %q is not supported", spec.GetVolumeType()))
+	}
+	if !isValueSupported(spec.GetVolumeBus(), apis.VolumeBusTypes) {
+		allErrs = append(allErrs, fmt.Errorf("bus %q is not supported", spec.VolumeBus))
+	}
+	if "" != spec.VolumeAvailabilityZone && !isValueSupported(spec.VolumeAvailabilityZone, apis.VolumeAvailabilityZones) {
+		allErrs = append(allErrs, fmt.Errorf("availabilityZone %q is not supported", spec.VolumeAvailabilityZone))
+	}
+
+	allErrs = append(allErrs, validateDataVolumes(spec.DataVolumes)...)
+
 	//allErrs = append(allErrs, ValidateSecret(secret)...)
 
 	return allErrs
 }
+
+// validateImage validates that the provider specification given references exactly one image
+//
+// PARAMETERS
+// spec *apis.ProviderSpec Provider specification to validate
+func validateImage(spec *apis.ProviderSpec) []error {
+	var allErrs []error
+
+	if nil == spec.Image {
+		if "" == spec.ImageID {
+			allErrs = append(allErrs, fmt.Errorf("imageID or image is a required field"))
+		}
+
+		return allErrs
+	}
+
+	if "" != spec.ImageID {
+		allErrs = append(allErrs, fmt.Errorf("imageID must not be set together with image"))
+	}
+
+	references := 0
+
+	for _, value := range []string{spec.Image.ID, spec.Image.Alias, spec.Image.Name, spec.Image.SnapshotID} {
+		if "" != value {
+			references++
+		}
+	}
+
+	if 1 != references {
+		allErrs = append(allErrs, fmt.Errorf("exactly one of image.id, image.alias, image.name or image.snapshotID is required"))
+	}
+
+	if "" != spec.Image.SnapshotID && !spec.Image.SnapshotCloudInit {
+		allErrs = append(allErrs, fmt.Errorf("image.snapshotCloudInit must be true to confirm that image.snapshotID supports cloud-init"))
+	} else if "" == spec.Image.SnapshotID && spec.Image.SnapshotCloudInit {
+		allErrs = append(allErrs, fmt.Errorf("image.snapshotCloudInit must not be set without image.snapshotID"))
+	}
+
+	if "" != spec.Image.Name {
+		if _, err := path.Match(spec.Image.Name, ""); nil != err {
+			allErrs = append(allErrs, fmt.Errorf("image.name %q is not a valid pattern", spec.Image.Name))
+		}
+	}
+
+	return allErrs
+}
+
+// validateSSHKeys validates the SSH public keys and SSH key references of the provider specification given
+//
+// PARAMETERS
+// spec   *apis.ProviderSpec Provider specification to validate
+// secret *corev1.Secret     Kubernetes secret containing referenced SSH public keys
+func validateSSHKeys(spec *apis.ProviderSpec, secret *corev1.Secret) []error {
+	var allErrs []error
+
+	if "" == spec.SSHKey && 0 == len(spec.SSHKeys) && 0 == len(spec.SSHKeySecretKeys) {
+		return append(allErrs, fmt.Errorf("sshKey, sshKeys or sshKeySecretKeys is a required field"))
+	}
+
+	if "" != spec.SSHKey {
+		if _, err := apis.ParseAuthorizedKey(spec.SSHKey); nil != err {
+			allErrs = append(allErrs, fmt.Errorf("sshKey is invalid: %v", err))
+		}
+	}
+
+	for index, sshKey := range spec.SSHKeys {
+		if _, err := apis.ParseAuthorizedKey(sshKey); nil != err {
+			allErrs = append(allErrs, fmt.Errorf("sshKeys[%d] is invalid: %v", index, err))
+		}
+	}
+
+	for index, key := range spec.SSHKeySecretKeys {
+		referencedSpec := &apis.ProviderSpec{SSHKeySecretKeys: []string{key}}
+
+		if _, err := referencedSpec.GetSSHKeys(secret); nil != err {
+			allErrs = append(allErrs, fmt.Errorf("sshKeySecretKeys[%d] is invalid: %v", index, err))
+		}
+	}
+
+	return allErrs
+}
+
+// validateAvailabilityZones validates the Gardener zone to IONOS availability zone mapping of the provider specification given
+//
+// PARAMETERS
+// spec *apis.ProviderSpec Provider specification to validate
+func validateAvailabilityZones(spec *apis.ProviderSpec) []error {
+	var allErrs []error
+
+	if 0 == len(spec.AvailabilityZones) {
+		return allErrs
+	}
+
+	zones := []string{}
+
+	for zone := range spec.AvailabilityZones {
+		zones = append(zones, zone)
+	}
+
+	sort.Strings(zones)
+
+	for _, zone := range zones {
+		availabilityZone := spec.AvailabilityZones[zone]
+
+		if !isValueSupported(availabilityZone, apis.ServerAvailabilityZones) {
+			allErrs = append(allErrs, fmt.Errorf("availabilityZones[%q] %q is not supported", zone, availabilityZone))
+		}
+	}
+
+	if _, ok := spec.AvailabilityZones[spec.Zone]; !ok {
+		allErrs = append(allErrs, fmt.Errorf("availabilityZones does not contain zone %q", spec.Zone))
+	}
+
+	return allErrs
+}
+
+// validateServerType validates the server type specific fields of the provider specification given
+//
+// PARAMETERS
+// spec *apis.ProviderSpec Provider specification to validate
+func validateServerType(spec *apis.ProviderSpec) []error {
+	var allErrs []error
+
+	if "" != spec.CPUFamily && !isValueSupported(spec.CPUFamily, apis.CPUFamilies) {
+		allErrs = append(allErrs, fmt.Errorf("cpuFamily %q is not supported", spec.CPUFamily))
+	}
+
+	switch spec.GetType() {
+	case apis.ServerTypeEnterprise:
+		if spec.Cores == 0 {
+			allErrs = append(allErrs, fmt.Errorf("cores is a required field"))
+		}
+		if spec.Memory == 0 {
+			allErrs = append(allErrs, fmt.Errorf("memory is a required field"))
+		}
+		if "" != spec.TemplateUUID {
+			allErrs = append(allErrs, fmt.Errorf("templateUuid is only supported for CUBE servers"))
+		}
+		if apis.VolumeTypeDAS == spec.VolumeType {
+			allErrs = append(allErrs, fmt.Errorf("volumeType %q is only supported for CUBE servers", spec.VolumeType))
+		}
+	case apis.ServerTypeCube:
+		if "" == spec.TemplateUUID {
+			allErrs = append(allErrs, fmt.Errorf("templateUuid is a required field for CUBE servers"))
+		} else if _, err := uuid.Parse(spec.TemplateUUID); nil != err {
+			allErrs = append(allErrs, fmt.Errorf("templateUuid %q is invalid", spec.TemplateUUID))
+		}
+		if spec.Cores != 0 || spec.Memory != 0 {
+			allErrs = append(allErrs, fmt.Errorf("cores and memory must not be set for CUBE servers as they are defined by the template"))
+		}
+		if "" != spec.CPUFamily {
+			allErrs = append(allErrs, fmt.Errorf("cpuFamily must not be set for CUBE servers as it is defined by the template"))
+		}
+		if "" != spec.VolumeType && apis.VolumeTypeDAS != spec.VolumeType {
+			allErrs = append(allErrs, fmt.Errorf("volumeType %q is not supported for CUBE servers", spec.VolumeType))
+		}
+		if 0 != spec.VolumeSize {
+			allErrs = append(allErrs, fmt.Errorf("volumeSize must not be set for CUBE servers as it is defined by the template"))
+		}
+	default:
+		allErrs = append(allErrs, fmt.Errorf("type %q is not supported", spec.Type))
+	}
+
+	return allErrs
+}
+
+// validateNetworkInterfaces validates the network interface specifications given
+//
+// PARAMETERS
+// networkInterfaces []apis.NetworkInterface Network interface specifications to validate
+func validateNetworkInterfaces(networkInterfaces []apis.NetworkInterface) []error {
+	var allErrs []error
+
+	lanIDs := map[string]bool{}
+
+	for index, networkInterface := range networkInterfaces {
+		if "" == networkInterface.LANID {
+			allErrs = append(allErrs, fmt.Errorf("networkInterfaces[%d].lanID is a required field", index))
+		} else if lanID, err := strconv.Atoi(networkInterface.LANID); nil != err || lanID < 1 {
+			allErrs = append(allErrs, fmt.Errorf("networkInterfaces[%d].lanID %q is invalid", index, networkInterface.LANID))
+		} else if lanIDs[networkInterface.LANID] {
+			allErrs = append(allErrs, fmt.Errorf("networkInterfaces[%d].lanID %q is used more than once", index, networkInterface.LANID))
+		}
+
+		lanIDs[networkInterface.LANID] = true
+
+		for ipIndex, ip := range networkInterface.IPs {
+			if nil == net.ParseIP(ip) {
+				allErrs = append(allErrs, fmt.Errorf("networkInterfaces[%d].ips[%d] %q is invalid", index, ipIndex, ip))
+			}
+		}
+
+		if len(networkInterface.IPs) > 0 && "" != networkInterface.IPBlockID {
+			allErrs = append(allErrs, fmt.Errorf("networkInterfaces[%d].ips must not be set together with ipBlockID", index))
+		}
+
+		if "" != networkInterface.FirewallType {
+			if !isValueSupported(networkInterface.FirewallType, apis.FirewallTypes) {
+				allErrs = append(allErrs, fmt.Errorf("networkInterfaces[%d].firewallType %q is not supported", index, networkInterface.FirewallType))
+			} else if !networkInterface.FirewallActive {
+				allErrs = append(allErrs, fmt.Errorf("networkInterfaces[%d].firewallType requires firewallActive", index))
+			}
+		}
+
+		if len(networkInterface.FirewallRules) > 0 && !networkInterface.FirewallActive {
+			allErrs = append(allErrs, fmt.Errorf("networkInterfaces[%d].firewallRules requires firewallActive", index))
+		}
+
+		allErrs = append(allErrs, validateFirewallRules(fmt.Sprintf("networkInterfaces[%d].firewallRules", index), networkInterface.FirewallRules)...)
+	}
+
+	return allErrs
+}
+
+// validateFirewallRules validates the firewall rule specifications given
+//
+// PARAMETERS
+// path          string              Field path of the firewall rules
+// firewallRules []apis.FirewallRule Firewall rule specifications to validate
+func validateFirewallRules(path string, firewallRules []apis.FirewallRule) []error {
+	var allErrs []error
+
+	ruleNames := map[string]bool{}
+
+	for index, firewallRule := range firewallRules {
+		rulePath := fmt.Sprintf("%s[%d]", path, index)
+		ruleName := firewallRule.GetName(index)
+
+		if ruleNames[ruleName] {
+			allErrs = append(allErrs, fmt.Errorf("%s.name %q is used more than once", rulePath, ruleName))
+		}
+
+		ruleNames[ruleName] = true
+
+		if "" == firewallRule.Protocol {
+			allErrs = append(allErrs, fmt.Errorf("%s.protocol is a required field", rulePath))
+		} else if !isValueSupported(firewallRule.Protocol, apis.FirewallRuleProtocols) {
+			allErrs = append(allErrs, fmt.Errorf("%s.protocol %q is not supported", rulePath, firewallRule.Protocol))
+		}
+
+		if "" != firewallRule.SourceIP && !isIPOrCIDR(firewallRule.SourceIP) {
+			allErrs = append(allErrs, fmt.Errorf("%s.sourceIP %q is not a valid IP or CIDR", rulePath, firewallRule.SourceIP))
+		}
+		if "" != firewallRule.TargetIP && !isIPOrCIDR(firewallRule.TargetIP) {
+			allErrs = append(allErrs, fmt.Errorf("%s.targetIP %q is not a valid IP or CIDR", rulePath, firewallRule.TargetIP))
+		}
+		if _, err := net.ParseMAC(firewallRule.SourceMAC); "" != firewallRule.SourceMAC && nil != err {
+			allErrs = append(allErrs, fmt.Errorf("%s.sourceMAC %q is invalid", rulePath, firewallRule.SourceMAC))
+		}
+
+		isPortProtocol := apis.FirewallRuleProtocolTCP == firewallRule.Protocol || apis.FirewallRuleProtocolUDP == firewallRule.Protocol
+
+		if nil != firewallRule.PortRangeStart || nil != firewallRule.PortRangeEnd {
+			if !isPortProtocol {
+				allErrs = append(allErrs, fmt.Errorf("%s port ranges are only supported for TCP and UDP", rulePath))
+			} else if nil == firewallRule.PortRangeStart {
+				allErrs = append(allErrs, fmt.Errorf("%s.portRangeStart is required if portRangeEnd is set", rulePath))
+			} else if !isPortValid(*firewallRule.PortRangeStart) {
+				allErrs = append(allErrs, fmt.Errorf("%s.portRangeStart %d is out of range", rulePath, *firewallRule.PortRangeStart))
+			} else if nil != firewallRule.PortRangeEnd && !isPortValid(*firewallRule.PortRangeEnd) {
+				allErrs = append(allErrs, fmt.Errorf("%s.portRangeEnd %d is out of range", rulePath, *firewallRule.PortRangeEnd))
+			} else if nil != firewallRule.PortRangeEnd && *firewallRule.PortRangeStart > *firewallRule.PortRangeEnd {
+				allErrs = append(allErrs, fmt.Errorf("%s.portRangeStart %d is greater than portRangeEnd %d", rulePath, *firewallRule.PortRangeStart, *firewallRule.PortRangeEnd))
+			}
+		}
+
+		if nil != firewallRule.ICMPType || nil != firewallRule.ICMPCode {
+			if apis.FirewallRuleProtocolICMP != firewallRule.Protocol {
+				allErrs = append(allErrs, fmt.Errorf("%s ICMP type and code are only supported for ICMP", rulePath))
+			}
+			if nil != firewallRule.ICMPType && (*firewallRule.ICMPType < 0 || *firewallRule.ICMPType > 254) {
+				allErrs = append(allErrs, fmt.Errorf("%s.icmpType %d is out of range", rulePath, *firewallRule.ICMPType))
+			}
+			if nil != firewallRule.ICMPCode && (*firewallRule.ICMPCode < 0 || *firewallRule.ICMPCode > 254) {
+				allErrs = append(allErrs, fmt.Errorf("%s.icmpCode %d is out of range", rulePath, *firewallRule.ICMPCode))
+			}
+		}
+
+		if "" != firewallRule.Direction && !isValueSupported(firewallRule.Direction, apis.FirewallRuleDirections) {
+			allErrs = append(allErrs, fmt.Errorf("%s.direction %q is not supported", rulePath, firewallRule.Direction))
+		}
+	}
+
+	return allErrs
+}
+
+// validateDataVolumes validates the data volume specifications given
+//
+// PARAMETERS
+// dataVolumes []apis.DataVolume Data volume specifications to validate
+func validateDataVolumes(dataVolumes []apis.DataVolume) []error {
+	var allErrs []error
+
+	volumeNames := map[string]bool{}
+
+	for index, dataVolume := range dataVolumes {
+		if "" == dataVolume.Name {
+			allErrs = append(allErrs, fmt.Errorf("dataVolumes[%d].name is a required field", index))
+		} else if "root-volume" == dataVolume.Name {
+			allErrs = append(allErrs, fmt.Errorf("dataVolumes[%d].name %q is reserved", index, dataVolume.Name))
+		} else if len(k8svalidation.IsDNS1123Label(dataVolume.Name)) > 0 {
+			allErrs = append(allErrs, fmt.Errorf("dataVolumes[%d].name %q is invalid", index, dataVolume.Name))
+		} else if volumeNames[dataVolume.Name] {
+			allErrs = append(allErrs, fmt.Errorf("dataVolumes[%d].name %q is used more than once", index, dataVolume.Name))
+		}
+
+		volumeNames[dataVolume.Name] = true
+
+		if dataVolume.Size <= 0 {
+			allErrs = append(allErrs, fmt.Errorf("dataVolumes[%d].size is a required field", index))
+		}
+		if !isValueSupported(dataVolume.GetType(), apis.DataVolumeTypes) {
+			allErrs = append(allErrs, fmt.Errorf("dataVolumes[%d].type %q is not supported", index, dataVolume.Type))
+		}
+		if !isValueSupported(dataVolume.GetBus(), apis.VolumeBusTypes) {
+			allErrs = append(allErrs, fmt.Errorf("dataVolumes[%d].bus %q is not supported", index, dataVolume.Bus))
+		}
+		if "" != dataVolume.AvailabilityZone && !isValueSupported(dataVolume.AvailabilityZone, apis.VolumeAvailabilityZones) {
+			allErrs = append(allErrs, fmt.Errorf("dataVolumes[%d].availabilityZone %q is not supported", index, dataVolume.AvailabilityZone))
+		}
+	}
+
+	return allErrs
+}
+
+// isIPOrCIDR returns true if the value is a valid IP or CIDR
+//
+// PARAMETERS
+// value string Value to check
+func isIPOrCIDR(value string) bool {
+	if nil != net.ParseIP(value) {
+		return true
+	}
+
+	_, _, err := net.ParseCIDR(value)
+	return nil == err
+}
+
+// isPortValid returns true if the port is in the range supported by IONOS firewall rules
+//
+// PARAMETERS
+// port int32 Port to check
+func isPortValid(port int32) bool {
+	return port >= 1 && port <= 65534
+}
+
+// isValueSupported returns true if the value is contained in the list of supported values
+//
+// PARAMETERS
+// value           string   Value to check
+// supportedValues []string Supported values
+func isValueSupported(value string, supportedValues []string) bool {
+	for _, supportedValue := range supportedValues {
+		if supportedValue == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -18,10 +18,12 @@ limitations under the License.
 package validation
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis"
 	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis/mock"
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
@@ -30,6 +32,8 @@ import (
 var _ = Describe("Validation", func() {
 	providerSecret := &corev1.Secret{
 		Data: map[string][]byte{
+			"user":     []byte("dummy-user"),
+			"password": []byte("dummy-password"),
 			"userData": []byte("dummy-user-data"),
 		},
 	}
@@ -69,7 +73,7 @@ var _ = Describe("Validation", func() {
 			Entry("Simple validation of IONOS machine class", &data{
 				setup: setup{},
 				action: action{
-					spec: mock.NewProviderSpec(),
+					spec:   mock.NewProviderSpec(),
 					secret: providerSecret,
 				},
 				expect: expect{
@@ -81,11 +85,11 @@ var _ = Describe("Validation", func() {
 				action: action{
 					spec: &apis.ProviderSpec{
 						Cluster: mock.TestProviderSpecCluster,
-						Zone: mock.TestProviderSpecZone,
-						Cores: 1,
-						Memory: 1024,
+						Zone:    mock.TestProviderSpecZone,
+						Cores:   1,
+						Memory:  1024,
 						ImageID: mock.TestProviderSpecImageID,
-						SSHKey: mock.TestProviderSpecSSHKey,
+						SSHKey:  mock.TestProviderSpecSSHKey,
 						NetworkIDs: &apis.NetworkIDs{
 							WAN: mock.TestProviderSpecNetworkID,
 						},
@@ -104,11 +108,11 @@ var _ = Describe("Validation", func() {
 				action: action{
 					spec: &apis.ProviderSpec{
 						DatacenterID: mock.TestProviderSpecDatacenterID,
-						Zone: mock.TestProviderSpecZone,
-						Cores: 1,
-						Memory: 1024,
-						ImageID: mock.TestProviderSpecImageID,
-						SSHKey: mock.TestProviderSpecSSHKey,
+						Zone:         mock.TestProviderSpecZone,
+						Cores:        1,
+						Memory:       1024,
+						ImageID:      mock.TestProviderSpecImageID,
+						SSHKey:       mock.TestProviderSpecSSHKey,
 						NetworkIDs: &apis.NetworkIDs{
 							WAN: mock.TestProviderSpecNetworkID,
 						},
@@ -127,11 +131,11 @@ var _ = Describe("Validation", func() {
 				action: action{
 					spec: &apis.ProviderSpec{
 						DatacenterID: mock.TestProviderSpecDatacenterID,
-						Cluster: mock.TestProviderSpecCluster,
-						Cores: 1,
-						Memory: 1024,
-						ImageID: mock.TestProviderSpecImageID,
-						SSHKey: mock.TestProviderSpecSSHKey,
+						Cluster:      mock.TestProviderSpecCluster,
+						Cores:        1,
+						Memory:       1024,
+						ImageID:      mock.TestProviderSpecImageID,
+						SSHKey:       mock.TestProviderSpecSSHKey,
 						NetworkIDs: &apis.NetworkIDs{
 							WAN: mock.TestProviderSpecNetworkID,
 						},
@@ -150,11 +154,11 @@ var _ = Describe("Validation", func() {
 				action: action{
 					spec: &apis.ProviderSpec{
 						DatacenterID: mock.TestProviderSpecDatacenterID,
-						Cluster: mock.TestProviderSpecCluster,
-						Zone: mock.TestProviderSpecZone,
-						Cores: 1,
-						Memory: 1024,
-						SSHKey: mock.TestProviderSpecSSHKey,
+						Cluster:      mock.TestProviderSpecCluster,
+						Zone:         mock.TestProviderSpecZone,
+						Cores:        1,
+						Memory:       1024,
+						SSHKey:       mock.TestProviderSpecSSHKey,
 						NetworkIDs: &apis.NetworkIDs{
 							WAN: mock.TestProviderSpecNetworkID,
 						},
@@ -164,7 +168,82 @@ var _ = Describe("Validation", func() {
 				expect: expect{
 					errToHaveOccurred: true,
 					errList: []error{
-						fmt.Errorf("imageID is a required field"),
+						fmt.Errorf("imageID or image is a required field"),
+					},
+				},
+			}),
+			Entry("image alias is valid", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"ImageID": "",
+						"Image":   &apis.ImageReference{Alias: "ubuntu:latest"},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
+			Entry("image snapshot is valid", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"ImageID": "",
+						"Image":   &apis.ImageReference{SnapshotID: "golden-image", SnapshotCloudInit: true},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
+			Entry("image snapshot is not confirmed to support cloud-init", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"ImageID": "",
+						"Image":   &apis.ImageReference{SnapshotID: "golden-image"},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("image.snapshotCloudInit must be true to confirm that image.snapshotID supports cloud-init"),
+					},
+				},
+			}),
+			Entry("image snapshot cloud-init confirmation is set without snapshot", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"ImageID": "",
+						"Image":   &apis.ImageReference{Alias: "ubuntu:latest", SnapshotCloudInit: true},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("image.snapshotCloudInit must not be set without image.snapshotID"),
+					},
+				},
+			}),
+			Entry("image references are invalid", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"Image": &apis.ImageReference{Alias: "ubuntu:latest", Name: "ubuntu-[20"},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("imageID must not be set together with image"),
+						fmt.Errorf("exactly one of image.id, image.alias, image.name or image.snapshotID is required"),
+						fmt.Errorf("image.name \"ubuntu-[20\" is not a valid pattern"),
 					},
 				},
 			}),
@@ -173,11 +252,11 @@ var _ = Describe("Validation", func() {
 				action: action{
 					spec: &apis.ProviderSpec{
 						DatacenterID: mock.TestProviderSpecDatacenterID,
-						Cluster: mock.TestProviderSpecCluster,
-						Zone: mock.TestProviderSpecZone,
-						Cores: 1,
-						Memory: 1024,
-						ImageID: mock.TestProviderSpecImageID,
+						Cluster:      mock.TestProviderSpecCluster,
+						Zone:         mock.TestProviderSpecZone,
+						Cores:        1,
+						Memory:       1024,
+						ImageID:      mock.TestProviderSpecImageID,
 						NetworkIDs: &apis.NetworkIDs{
 							WAN: mock.TestProviderSpecNetworkID,
 						},
@@ -187,7 +266,53 @@ var _ = Describe("Validation", func() {
 				expect: expect{
 					errToHaveOccurred: true,
 					errList: []error{
-						fmt.Errorf("sshKey is a required field"),
+						fmt.Errorf("sshKey, sshKeys or sshKeySecretKeys is a required field"),
+					},
+				},
+			}),
+			Entry("sshKeys and sshKeySecretKeys are valid", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"SSHKey": "",
+						"SSHKeys": []string{
+							"ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAAAgQABAgMEBQYHCAkKCwwNDg8QERITFBUWFxgZGhscHR4fICEiIyQlJicoKSorLC0uLzAxMjM0NTY3ODk6Ozw9Pj9AQUJDREVGR0hJSktMTU5PUFFSU1RVVldYWVpbXF1eX2BhYmNkZWZnaGlqa2xtbm9wcXJzdHV2d3h5ent8fX5/gA== rsa@example.com",
+							`no-port-forwarding,command="echo forced" ecdsa-sha2-nistp256 AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBAABAgMEBQYHCAkKCwwNDg8QERITFBUWFxgZGhscHR4fICEiIyQlJicoKSorLC0uLzAxMjM0NTY3ODk6Ozw9Pj8=`,
+						},
+						"SSHKeySecretKeys": []string{"authorizedKeys"},
+					}),
+					secret: &corev1.Secret{
+						Data: map[string][]byte{
+							"user":           []byte("dummy-user"),
+							"password":       []byte("dummy-password"),
+							"authorizedKeys": []byte("# Team keys\n" + mock.TestProviderSpecSSHKey + "\n\n"),
+						},
+					},
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
+			Entry("sshKey, sshKeys and sshKeySecretKeys are invalid", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"SSHKey": "ssh-rsa invalid",
+						"SSHKeys": []string{
+							"ssh-dss AAAAB3NzaC1kc3M=",
+							"ssh-ed25519 AAAAB3NzaC1yc2EAAAADAQABAAAAgQABAgMEBQYHCAkKCwwNDg8QERITFBUWFxgZGhscHR4fICEiIyQlJicoKSorLC0uLzAxMjM0NTY3ODk6Ozw9Pj9AQUJDREVGR0hJSktMTU5PUFFSU1RVVldYWVpbXF1eX2BhYmNkZWZnaGlqa2xtbm9wcXJzdHV2d3h5ent8fX5/gA==",
+						},
+						"SSHKeySecretKeys": []string{"missing"},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("sshKey is invalid: ssh-rsa key data is not base64 encoded"),
+						fmt.Errorf("sshKeys[0] is invalid: key type is not supported (supported: ssh-rsa, ssh-ed25519, ecdsa-sha2-nistp256, ecdsa-sha2-nistp384, ecdsa-sha2-nistp521)"),
+						fmt.Errorf("sshKeys[1] is invalid: ssh-ed25519 key data contains a \"ssh-rsa\" key"),
+						fmt.Errorf("sshKeySecretKeys[0] is invalid: secret does not contain the SSH keys referenced as \"missing\""),
 					},
 				},
 			}),
@@ -196,12 +321,12 @@ var _ = Describe("Validation", func() {
 				action: action{
 					spec: &apis.ProviderSpec{
 						DatacenterID: mock.TestProviderSpecDatacenterID,
-						Cluster: mock.TestProviderSpecCluster,
-						Zone: mock.TestProviderSpecZone,
-						Cores: 1,
-						Memory: 1024,
-						ImageID: mock.TestProviderSpecImageID,
-						SSHKey: mock.TestProviderSpecSSHKey,
+						Cluster:      mock.TestProviderSpecCluster,
+						Zone:         mock.TestProviderSpecZone,
+						Cores:        1,
+						Memory:       1024,
+						ImageID:      mock.TestProviderSpecImageID,
+						SSHKey:       mock.TestProviderSpecSSHKey,
 					},
 					secret: providerSecret,
 				},
@@ -217,12 +342,12 @@ var _ = Describe("Validation", func() {
 				action: action{
 					spec: &apis.ProviderSpec{
 						DatacenterID: mock.TestProviderSpecDatacenterID,
-						Cluster: mock.TestProviderSpecCluster,
-						Zone: mock.TestProviderSpecZone,
-						Cores: 1,
-						Memory: 1024,
-						ImageID: mock.TestProviderSpecImageID,
-						SSHKey: mock.TestProviderSpecSSHKey,
+						Cluster:      mock.TestProviderSpecCluster,
+						Zone:         mock.TestProviderSpecZone,
+						Cores:        1,
+						Memory:       1024,
+						ImageID:      mock.TestProviderSpecImageID,
+						SSHKey:       mock.TestProviderSpecSSHKey,
 						NetworkIDs: &apis.NetworkIDs{
 							Workers: mock.TestProviderSpecNetworkID,
 						},
@@ -236,6 +361,517 @@ var _ = Describe("Validation", func() {
 					},
 				},
 			}),
+			Entry("CUBE server is valid", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"Cores":        uint(0),
+						"Memory":       uint(0),
+						"Type":         apis.ServerTypeCube,
+						"TemplateUUID": "15c6dd2f-02d2-4987-b439-9a58dd59ecc3",
+						"VolumeType":   apis.VolumeTypeDAS,
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
+			Entry("CUBE server with cores, memory and SSD root volume is invalid", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"CPUFamily":    apis.CPUFamilyIntelSkylake,
+						"Type":         apis.ServerTypeCube,
+						"TemplateUUID": "15c6dd2f-02d2-4987-b439-9a58dd59ecc3",
+						"VolumeType":   apis.VolumeTypeSSDStandard,
+						"VolumeSize":   float32(10737418240),
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("cores and memory must not be set for CUBE servers as they are defined by the template"),
+						fmt.Errorf("cpuFamily must not be set for CUBE servers as it is defined by the template"),
+						fmt.Errorf("volumeType \"SSD Standard\" is not supported for CUBE servers"),
+						fmt.Errorf("volumeSize must not be set for CUBE servers as it is defined by the template"),
+					},
+				},
+			}),
+			Entry("CUBE server without template is invalid", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"Cores":  uint(0),
+						"Memory": uint(0),
+						"Type":   apis.ServerTypeCube,
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("templateUuid is a required field for CUBE servers"),
+					},
+				},
+			}),
+			Entry("ENTERPRISE server with template and DAS root volume is invalid", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"CPUFamily":    apis.CPUFamilyAMDOpteron,
+						"Type":         apis.ServerTypeEnterprise,
+						"TemplateUUID": "15c6dd2f-02d2-4987-b439-9a58dd59ecc3",
+						"VolumeType":   apis.VolumeTypeDAS,
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("templateUuid is only supported for CUBE servers"),
+						fmt.Errorf("volumeType \"DAS\" is only supported for CUBE servers"),
+					},
+				},
+			}),
+			Entry("unsupported server type and CPU family are invalid", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"CPUFamily": "ARM",
+						"Type":      "VCPU",
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("cpuFamily \"ARM\" is not supported"),
+						fmt.Errorf("type \"VCPU\" is not supported"),
+					},
+				},
+			}),
+			Entry("availability zone mapping is valid", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"AvailabilityZones": map[string]string{
+							mock.TestProviderSpecZone: apis.AvailabilityZone1,
+							"de-txl":                  apis.AvailabilityZoneAuto,
+						},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
+			Entry("availability zone mapping is invalid", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"AvailabilityZones": map[string]string{
+							"de-txl": apis.AvailabilityZone3,
+						},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("availabilityZones[\"de-txl\"] \"ZONE_3\" is not supported"),
+						fmt.Errorf("availabilityZones does not contain zone \"de-fra\""),
+					},
+				},
+			}),
+			Entry("root volume settings are valid", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"VolumeType":             apis.VolumeTypeSSDPremium,
+						"VolumeBus":              apis.VolumeBusIDE,
+						"VolumeAvailabilityZone": apis.AvailabilityZone2,
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
+			Entry("root volume type defaults to a supported type", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"VolumeType": "",
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
+			Entry("root volume settings are invalid", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"VolumeType":             "SSD Ultra",
+						"VolumeBus":              "SCSI",
+						"VolumeAvailabilityZone": "ZONE_4",
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("volumeType \"SSD Ultra\" is not supported"),
+						fmt.Errorf("bus \"SCSI\" is not supported"),
+						fmt.Errorf("availabilityZone \"ZONE_4\" is not supported"),
+					},
+				},
+			}),
+			Entry("networkInterfaces are valid", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"NetworkIDs": (*apis.NetworkIDs)(nil),
+						"NetworkInterfaces": []apis.NetworkInterface{
+							{Name: "wan", LANID: "1", IPBlockID: mock.TestIPBlockID, FirewallActive: true, FirewallType: apis.FirewallTypeBidirectional},
+							{Name: "internal", LANID: "2", IPs: []string{"10.1.0.5", "fd00::5"}},
+						},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
+			Entry("networkInterfaces are invalid", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"FloatingPoolID": mock.TestIPBlockID,
+						"NetworkInterfaces": []apis.NetworkInterface{
+							{Name: "missing"},
+							{LANID: "lan"},
+							{LANID: "2", IPs: []string{"10.1.0.300"}, IPBlockID: mock.TestIPBlockID},
+							{LANID: "2", FirewallType: apis.FirewallTypeIngress},
+							{LANID: "3", FirewallActive: true, FirewallType: "ANY"},
+						},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("networkIDs must not be set together with networkInterfaces"),
+						fmt.Errorf("floatingPoolID must not be set together with networkInterfaces"),
+						fmt.Errorf("networkInterfaces[0].lanID is a required field"),
+						fmt.Errorf("networkInterfaces[1].lanID \"lan\" is invalid"),
+						fmt.Errorf("networkInterfaces[2].ips[0] \"10.1.0.300\" is invalid"),
+						fmt.Errorf("networkInterfaces[2].ips must not be set together with ipBlockID"),
+						fmt.Errorf("networkInterfaces[3].lanID \"2\" is used more than once"),
+						fmt.Errorf("networkInterfaces[3].firewallType requires firewallActive"),
+						fmt.Errorf("networkInterfaces[4].firewallType \"ANY\" is not supported"),
+					},
+				},
+			}),
+			Entry("firewallRules are valid", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"NetworkIDs": (*apis.NetworkIDs)(nil),
+						"NetworkInterfaces": []apis.NetworkInterface{
+							{
+								LANID:          "1",
+								FirewallActive: true,
+								FirewallRules: []apis.FirewallRule{
+									{Protocol: apis.FirewallRuleProtocolTCP, SourceIP: "10.0.0.0/8", PortRangeStart: ionossdk.PtrInt32(80), PortRangeEnd: ionossdk.PtrInt32(443)},
+									{Protocol: apis.FirewallRuleProtocolICMP, SourceMAC: "aa:bb:cc:dd:ee:ff", ICMPType: ionossdk.PtrInt32(8), ICMPCode: ionossdk.PtrInt32(0)},
+									{Protocol: apis.FirewallRuleProtocolAny, TargetIP: "192.0.2.1", Direction: apis.FirewallTypeEgress},
+								},
+							},
+						},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
+			Entry("firewallRules are invalid", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"NetworkIDs": (*apis.NetworkIDs)(nil),
+						"NetworkInterfaces": []apis.NetworkInterface{
+							{
+								LANID: "1",
+								FirewallRules: []apis.FirewallRule{
+									{SourceIP: "10.0.0.0/33", TargetIP: "host", SourceMAC: "aa:bb"},
+									{Name: "rule-0", Protocol: apis.FirewallRuleProtocolTCP, PortRangeStart: ionossdk.PtrInt32(443), PortRangeEnd: ionossdk.PtrInt32(80)},
+									{Protocol: apis.FirewallRuleProtocolUDP, PortRangeStart: ionossdk.PtrInt32(0)},
+									{Protocol: apis.FirewallRuleProtocolUDP, PortRangeEnd: ionossdk.PtrInt32(53)},
+									{Protocol: apis.FirewallRuleProtocolICMP, PortRangeStart: ionossdk.PtrInt32(22), ICMPType: ionossdk.PtrInt32(255)},
+									{Protocol: "SCTP", ICMPCode: ionossdk.PtrInt32(0), Direction: apis.FirewallTypeBidirectional},
+								},
+							},
+						},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("networkInterfaces[0].firewallRules requires firewallActive"),
+						fmt.Errorf("networkInterfaces[0].firewallRules[0].protocol is a required field"),
+						fmt.Errorf("networkInterfaces[0].firewallRules[0].sourceIP \"10.0.0.0/33\" is not a valid IP or CIDR"),
+						fmt.Errorf("networkInterfaces[0].firewallRules[0].targetIP \"host\" is not a valid IP or CIDR"),
+						fmt.Errorf("networkInterfaces[0].firewallRules[0].sourceMAC \"aa:bb\" is invalid"),
+						fmt.Errorf("networkInterfaces[0].firewallRules[1].name \"rule-0\" is used more than once"),
+						fmt.Errorf("networkInterfaces[0].firewallRules[1].portRangeStart 443 is greater than portRangeEnd 80"),
+						fmt.Errorf("networkInterfaces[0].firewallRules[2].portRangeStart 0 is out of range"),
+						fmt.Errorf("networkInterfaces[0].firewallRules[3].portRangeStart is required if portRangeEnd is set"),
+						fmt.Errorf("networkInterfaces[0].firewallRules[4] port ranges are only supported for TCP and UDP"),
+						fmt.Errorf("networkInterfaces[0].firewallRules[4].icmpType 255 is out of range"),
+						fmt.Errorf("networkInterfaces[0].firewallRules[5].protocol \"SCTP\" is not supported"),
+						fmt.Errorf("networkInterfaces[0].firewallRules[5] ICMP type and code are only supported for ICMP"),
+						fmt.Errorf("networkInterfaces[0].firewallRules[5].direction \"BIDIRECTIONAL\" is not supported"),
+					},
+				},
+			}),
+			Entry("dataVolumes are valid", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"DataVolumes": []apis.DataVolume{
+							{Name: "data", Size: 10737418240},
+							{Name: "logs", Size: 10737418240, Type: apis.VolumeTypeHDD, Bus: apis.VolumeBusIDE, AvailabilityZone: apis.AvailabilityZone3},
+						},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
+			Entry("dataVolumes are invalid", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+						"DataVolumes": []apis.DataVolume{
+							{Size: 10737418240},
+							{Name: "root-volume", Size: 10737418240},
+							{Name: "Data_1", Size: 10737418240},
+							{Name: "data", Type: "SSD"},
+							{Name: "data", Size: 10737418240, Bus: "SCSI", AvailabilityZone: "ZONE_4"},
+						},
+					}),
+					secret: providerSecret,
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("dataVolumes[0].name is a required field"),
+						fmt.Errorf("dataVolumes[1].name \"root-volume\" is reserved"),
+						fmt.Errorf("dataVolumes[2].name \"Data_1\" is invalid"),
+						fmt.Errorf("dataVolumes[3].size is a required field"),
+						fmt.Errorf("dataVolumes[3].type \"SSD\" is not supported"),
+						fmt.Errorf("dataVolumes[4].name \"data\" is used more than once"),
+						fmt.Errorf("dataVolumes[4].bus \"SCSI\" is not supported"),
+						fmt.Errorf("dataVolumes[4].availabilityZone \"ZONE_4\" is not supported"),
+					},
+				},
+			}),
+			Entry("token is accepted as credentials", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.NewProviderSpec(),
+					secret: &corev1.Secret{
+						Data: map[string][]byte{
+							"token": []byte("dummy-token"),
+						},
+					},
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
+			Entry("documented credential aliases are accepted", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.NewProviderSpec(),
+					secret: &corev1.Secret{
+						Data: map[string][]byte{
+							"ionosUser":     []byte("dummy-user"),
+							"ionosPassword": []byte("dummy-password"),
+						},
+					},
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
+			Entry("credentials missing", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.NewProviderSpec(),
+					secret: &corev1.Secret{
+						Data: map[string][]byte{
+							"userData": []byte("dummy-user-data"),
+						},
+					},
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("secret must contain either \"token\" or \"user\" (\"ionosUser\") and \"password\" (\"ionosPassword\")"),
+					},
+				},
+			}),
+			Entry("password missing", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.NewProviderSpec(),
+					secret: &corev1.Secret{
+						Data: map[string][]byte{
+							"ionosUser": []byte("dummy-user"),
+						},
+					},
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("secret must contain \"password\" (\"ionosPassword\") if an user is given"),
+					},
+				},
+			}),
+			Entry("credential aliases differ", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.NewProviderSpec(),
+					secret: &corev1.Secret{
+						Data: map[string][]byte{
+							"user":      []byte("dummy-user"),
+							"ionosUser": []byte("other-user"),
+							"password":  []byte("dummy-password"),
+						},
+					},
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("secret keys \"user\" and \"ionosUser\" must not contain different values"),
+					},
+				},
+			}),
+			Entry("secret missing", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.NewProviderSpec(),
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("secret containing IONOS credentials is required"),
+					},
+				},
+			}),
+			Entry("client settings are accepted", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.NewProviderSpec(),
+					secret: &corev1.Secret{
+						Data: map[string][]byte{
+							"token":          []byte("dummy-token"),
+							"apiURL":         []byte("api.ionos.com"),
+							"proxyURL":       []byte("http://proxy.example.com:3128"),
+							"requestTimeout": []byte("30s"),
+						},
+					},
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
+			Entry("client settings are invalid", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.NewProviderSpec(),
+					secret: &corev1.Secret{
+						Data: map[string][]byte{
+							"token":  []byte("dummy-token"),
+							"apiURL": []byte("ftp://api.ionos.com"),
+						},
+					},
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("IONOS API URL \"ftp://api.ionos.com\" is invalid"),
+					},
+				},
+			}),
+			Entry("request timeout is invalid", &data{
+				setup: setup{},
+				action: action{
+					spec: mock.NewProviderSpec(),
+					secret: &corev1.Secret{
+						Data: map[string][]byte{
+							"token":          []byte("dummy-token"),
+							"requestTimeout": []byte("soon"),
+							"caBundle":       []byte("invalid"),
+						},
+					},
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errList: []error{
+						fmt.Errorf("secret key \"requestTimeout\" must contain a positive duration"),
+					},
+				},
+			}),
 		)
+
+		It("should only strip a trailing line break from passwords", func() {
+			credentials, err := apis.GetCredentialsFromSecret(&corev1.Secret{
+				Data: map[string][]byte{
+					"user":     []byte(" dummy-user\n"),
+					"password": []byte(" dummy password \n"),
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(credentials.User).To(Equal("dummy-user"))
+			Expect(credentials.Password).To(Equal(" dummy password "))
+
+			credentials, err = apis.GetCredentialsFromSecret(&corev1.Secret{
+				Data: map[string][]byte{
+					"token": []byte("dummy-token\r\n"),
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(credentials.Token).To(Equal("dummy-token"))
+		})
+
+		It("should default to the SSD Premium root volume type", func() {
+			spec := mock.NewProviderSpec()
+			spec.VolumeType = ""
+
+			Expect(spec.GetVolumeType()).To(Equal(apis.VolumeTypeSSDPremium))
+			Expect(apis.RootVolumeTypes).To(ContainElement(spec.GetVolumeType()))
+		})
+
+		It("should read the root volume settings from the provider spec keys", func() {
+			spec := &apis.ProviderSpec{}
+
+			err := json.Unmarshal([]byte(`{"volumeType": "HDD", "bus": "IDE", "availabilityZone": "ZONE_1"}`), spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(spec.VolumeType).To(Equal(apis.VolumeTypeHDD))
+			Expect(spec.VolumeBus).To(Equal(apis.VolumeBusIDE))
+			Expect(spec.VolumeAvailabilityZone).To(Equal(apis.AvailabilityZone1))
+		})
 	})
 })
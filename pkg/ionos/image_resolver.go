@@ -0,0 +1,319 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ionos contains the IONOS provider specific implementations to manage machines
+package ionos
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis"
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/spi"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
+	"k8s.io/klog/v2"
+)
+
+// Constant imageResolutionCacheTTL is the duration image aliases and name patterns stay resolved
+const imageResolutionCacheTTL = 15 * time.Minute
+
+// Constant snapshotLicenceTypeLinux is the only snapshot licence type cloud-init support may be confirmed for
+const snapshotLicenceTypeLinux = "LINUX"
+
+// imageResolutionCacheEntry is an image resolved together with its expiration time
+type imageResolutionCacheEntry struct {
+	image     ionossdk.Image
+	expiresAt time.Time
+}
+
+// imageResolver resolves image references to images and caches resolved aliases and name patterns
+type imageResolver struct {
+	mutex   sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	entries map[string]imageResolutionCacheEntry
+}
+
+// Variable ionosImageResolver is the image resolver used by all machine requests
+var ionosImageResolver = newImageResolver(imageResolutionCacheTTL)
+
+// newImageResolver returns a new image resolver caching results for the duration given.
+//
+// PARAMETERS
+// ttl time.Duration Duration resolved images are cached for
+func newImageResolver(ttl time.Duration) *imageResolver {
+	return &imageResolver{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]imageResolutionCacheEntry{},
+	}
+}
+
+// newImageFromSnapshot returns an image with the properties shared with the snapshot given. Snapshot properties
+// don't contain the cloud-init version of the image they were taken from. Cloud-init support is therefore confirmed
+// by the image reference instead and the cloud-init version of the image returned is left unset.
+//
+// PARAMETERS
+// snapshot *ionossdk.Snapshot Snapshot
+func newImageFromSnapshot(snapshot *ionossdk.Snapshot) ionossdk.Image {
+	image := ionossdk.Image{
+		Id:         snapshot.Id,
+		Type:       snapshot.Type,
+		Href:       snapshot.Href,
+		Metadata:   snapshot.Metadata,
+		Properties: &ionossdk.ImageProperties{},
+	}
+
+	if snapshot.HasProperties() {
+		image.Properties.Name = snapshot.Properties.Name
+		image.Properties.Description = snapshot.Properties.Description
+		image.Properties.Location = snapshot.Properties.Location
+		image.Properties.Size = snapshot.Properties.Size
+		image.Properties.LicenceType = snapshot.Properties.LicenceType
+	}
+
+	return image
+}
+
+// isCloudInitImage returns true if the image given supports cloud-init.
+//
+// PARAMETERS
+// image *ionossdk.Image Image
+func isCloudInitImage(image *ionossdk.Image) bool {
+	return image.HasProperties() && image.Properties.HasCloudInit() && "NONE" != *image.Properties.CloudInit
+}
+
+// validateSnapshotImage validates that the image converted from a snapshot is compatible with the provider
+// specification given. Cloud-init support must be confirmed by the image reference as the IONOS API doesn't report
+// it for snapshots. Only LINUX snapshots are accepted as IONOS supports cloud-init for Linux images only.
+//
+// PARAMETERS
+// providerSpec *apis.ProviderSpec Provider specification
+// image        *ionossdk.Image    Image converted from a snapshot
+func validateSnapshotImage(providerSpec *apis.ProviderSpec, image *ionossdk.Image) error {
+	properties := image.Properties
+
+	if !providerSpec.GetImageReference().SnapshotCloudInit {
+		return fmt.Errorf("snapshot %s is not confirmed to support cloud-init by image.snapshotCloudInit", *image.Id)
+	}
+
+	if !properties.HasLicenceType() || "UNKNOWN" == *properties.LicenceType {
+		return fmt.Errorf("snapshot %s has no known licence type", *image.Id)
+	} else if snapshotLicenceTypeLinux != *properties.LicenceType {
+		return fmt.Errorf("snapshot %s has the licence type %s but cloud-init is only supported for %s snapshots", *image.Id, *properties.LicenceType, snapshotLicenceTypeLinux)
+	}
+
+	if !properties.HasSize() || 0 >= *properties.Size {
+		return fmt.Errorf("snapshot %s has no size", *image.Id)
+	}
+
+	// The size of DAS root volumes is defined by the CUBE template
+	if !providerSpec.IsCube() && 0 != providerSpec.VolumeSize {
+		volumeSize := getVolumeSizeInGB(providerSpec.VolumeSize)
+
+		if volumeSize < *properties.Size {
+			return fmt.Errorf("volumeSize of %gGB is smaller than the snapshot size of %gGB", volumeSize, *properties.Size)
+		}
+	}
+
+	return nil
+}
+
+// isImageMatching returns true if the image given is a cloud-init capable HDD image at the location given matching
+// the alias or name pattern referenced.
+//
+// PARAMETERS
+// image     *ionossdk.Image      Image
+// location  string               Location of the datacenter
+// reference *apis.ImageReference Image reference
+func isImageMatching(image *ionossdk.Image, location string, reference *apis.ImageReference) bool {
+	if !image.HasId() || !image.HasProperties() || !isCloudInitImage(image) {
+		return false
+	}
+
+	properties := image.Properties
+
+	if !properties.HasLocation() || location != *properties.Location {
+		return false
+	}
+
+	if properties.HasImageType() && "HDD" != *properties.ImageType {
+		return false
+	}
+
+	if "" != reference.Alias {
+		if !properties.HasImageAliases() {
+			return false
+		}
+
+		for _, alias := range *properties.ImageAliases {
+			if reference.Alias == alias {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if !properties.HasName() {
+		return false
+	}
+
+	matched, _ := path.Match(reference.Name, *properties.Name)
+	return matched
+}
+
+// isImageNewer returns true if the image given has been created after the other one.
+//
+// PARAMETERS
+// image *ionossdk.Image Image
+// other *ionossdk.Image Image to compare with
+func isImageNewer(image, other *ionossdk.Image) bool {
+	if !image.HasMetadata() || !image.Metadata.HasCreatedDate() {
+		return false
+	} else if !other.HasMetadata() || !other.Metadata.HasCreatedDate() {
+		return true
+	}
+
+	return image.Metadata.CreatedDate.Time.After(other.Metadata.CreatedDate.Time)
+}
+
+// resolve returns the image referenced by the provider specification given. Aliases and name patterns are resolved
+// to the newest cloud-init capable image matching in the location of the datacenter.
+//
+// PARAMETERS
+// ctx          context.Context    Execution context
+// session      spi.Session        IONOS session
+// providerSpec *apis.ProviderSpec Provider specification
+func (r *imageResolver) resolve(ctx context.Context, session spi.Session, providerSpec *apis.ProviderSpec) (*ionossdk.Image, error) {
+	reference := providerSpec.GetImageReference()
+
+	if "" != reference.SnapshotID {
+		snapshot, err := session.GetSnapshot(ctx, reference.SnapshotID)
+		if nil != err {
+			return nil, getImageResolutionError(reference, err)
+		}
+
+		location, err := getDatacenterLocation(ctx, session, providerSpec.DatacenterID)
+		if nil != err {
+			return nil, err
+		}
+
+		image := newImageFromSnapshot(&snapshot)
+
+		if !image.Properties.HasLocation() || location != *image.Properties.Location {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s is not located in %q", reference, location))
+		}
+
+		err = validateSnapshotImage(providerSpec, &image)
+		if nil != err {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		return &image, nil
+	}
+
+	if "" != reference.ID {
+		image, err := session.GetImage(ctx, reference.ID)
+		if nil != err {
+			return nil, getImageResolutionError(reference, err)
+		} else if !isCloudInitImage(&image) {
+			return nil, status.Error(codes.InvalidArgument, "imageID given doesn't belong to a cloud-init enabled image")
+		}
+
+		return &image, nil
+	}
+
+	cacheKey := fmt.Sprintf("%s/%s", providerSpec.DatacenterID, reference)
+
+	r.mutex.Lock()
+	entry, ok := r.entries[cacheKey]
+	r.mutex.Unlock()
+
+	if ok && r.now().Before(entry.expiresAt) {
+		klog.V(3).Infof("Using cached image %s for %s", *entry.image.Id, reference)
+		return &entry.image, nil
+	}
+
+	location, err := getDatacenterLocation(ctx, session, providerSpec.DatacenterID)
+	if nil != err {
+		return nil, err
+	}
+
+	images, err := session.ListImages(ctx)
+	if nil != err {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+
+	var resolvedImage *ionossdk.Image
+
+	for index := range images {
+		image := &images[index]
+
+		if isImageMatching(image, location, reference) && (nil == resolvedImage || isImageNewer(image, resolvedImage)) {
+			resolvedImage = image
+		}
+	}
+
+	if nil == resolvedImage {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("no cloud-init enabled image matching %s found in %q", reference, location))
+	}
+
+	klog.V(2).Infof("Resolved %s in %q to image %s (%s)", reference, location, *resolvedImage.Id, *resolvedImage.Properties.Name)
+
+	r.mutex.Lock()
+	r.entries[cacheKey] = imageResolutionCacheEntry{image: *resolvedImage, expiresAt: r.now().Add(r.ttl)}
+	r.mutex.Unlock()
+
+	return resolvedImage, nil
+}
+
+// getDatacenterLocation returns the location of the datacenter given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// session      spi.Session     IONOS session
+// datacenterID string          Datacenter ID
+func getDatacenterLocation(ctx context.Context, session spi.Session, datacenterID string) (string, error) {
+	datacenter, err := session.GetDatacenter(ctx, datacenterID)
+	if spi.IsNotFoundError(err) {
+		return "", status.Error(codes.InvalidArgument, "datacenterID given is invalid")
+	} else if nil != err {
+		return "", status.Error(codes.Unavailable, err.Error())
+	} else if !datacenter.HasProperties() || !datacenter.Properties.HasLocation() {
+		return "", status.Error(codes.Internal, fmt.Sprintf("datacenter %s has no location", datacenterID))
+	}
+
+	return *datacenter.Properties.Location, nil
+}
+
+// getImageResolutionError returns the machine error for the API error given.
+//
+// PARAMETERS
+// reference *apis.ImageReference Image reference
+// err       error                API error
+func getImageResolutionError(reference *apis.ImageReference, err error) error {
+	if spi.IsNotFoundError(err) {
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("%s does not exist: %s", reference, err.Error()))
+	}
+
+	return status.Error(codes.Unavailable, err.Error())
+}
@@ -0,0 +1,220 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ionos contains the IONOS provider specific implementations to manage machines
+package ionos
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis"
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis/mock"
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/spi"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ImageResolver", func() {
+	var (
+		api         *mock.FakeCloudAPI
+		mockTestEnv mock.MockTestEnv
+		now         time.Time
+		resolver    *imageResolver
+		session     spi.Session
+	)
+
+	addImage := func(imageID, name, location, cloudInit string, createdDate time.Time, aliases ...string) {
+		api.AddImage(imageID, ionossdk.ImageProperties{
+			Name:         ionossdk.PtrString(name),
+			Location:     ionossdk.PtrString(location),
+			Size:         ionossdk.PtrFloat32(10),
+			LicenceType:  ionossdk.PtrString("LINUX"),
+			ImageType:    ionossdk.PtrString("HDD"),
+			CloudInit:    ionossdk.PtrString(cloudInit),
+			ImageAliases: &aliases,
+		})
+
+		api.SetImageCreatedDate(imageID, createdDate)
+	}
+
+	newProviderSpec := func(reference *apis.ImageReference) *apis.ProviderSpec {
+		return mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+			"ImageID": "",
+			"Image":   reference,
+		})
+	}
+
+	expectCode := func(err error, code codes.Code) {
+		Expect(err).To(HaveOccurred())
+
+		machineErr, ok := status.FromError(err)
+		Expect(ok).To(BeTrue())
+		Expect(machineErr.Code()).To(Equal(code))
+	}
+
+	var _ = BeforeEach(func() {
+		mockTestEnv = mock.NewMockTestEnv()
+		session = spi.NewClientSession(mockTestEnv.Client)
+
+		api = mock.NewFakeCloudAPI()
+		api.SetupOnMux(mockTestEnv.Mux)
+
+		api.AddDatacenter(mock.TestProviderSpecDatacenterID, "de/fra")
+
+		created := time.Date(2021, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+		addImage("ubuntu-old", "ubuntu-20.04-server-cloudimg-amd64-20210601", "de/fra", "V1", created, "ubuntu:latest")
+		addImage("ubuntu-new", "ubuntu-20.04-server-cloudimg-amd64-20210901", "de/fra", "V1", created.AddDate(0, 3, 0), "ubuntu:latest")
+		addImage("ubuntu-other-location", "ubuntu-20.04-server-cloudimg-amd64-20211001", "us/las", "V1", created.AddDate(0, 4, 0), "ubuntu:latest")
+		addImage("ubuntu-no-cloud-init", "ubuntu-20.04-server-amd64-20211001", "de/fra", "NONE", created.AddDate(0, 4, 0), "ubuntu:latest")
+
+		now = time.Now()
+
+		resolver = newImageResolver(time.Minute)
+		resolver.now = func() time.Time { return now }
+	})
+
+	var _ = AfterEach(func() {
+		mockTestEnv.Teardown()
+	})
+
+	Describe("#resolve", func() {
+		It("should resolve an alias to the newest cloud-init enabled image in the datacenter location", func() {
+			image, err := resolver.resolve(context.Background(), session, newProviderSpec(&apis.ImageReference{Alias: "ubuntu:latest"}))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*image.Id).To(Equal("ubuntu-new"))
+		})
+
+		It("should resolve a name pattern to the newest cloud-init enabled image in the datacenter location", func() {
+			image, err := resolver.resolve(context.Background(), session, newProviderSpec(&apis.ImageReference{Name: "ubuntu-*-20210601"}))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*image.Id).To(Equal("ubuntu-old"))
+		})
+
+		It("should cache resolved images until the TTL expires", func() {
+			providerSpec := newProviderSpec(&apis.ImageReference{Alias: "ubuntu:latest"})
+
+			_, err := resolver.resolve(context.Background(), session, providerSpec)
+			Expect(err).NotTo(HaveOccurred())
+
+			addImage("ubuntu-newest", "ubuntu-20.04-server-cloudimg-amd64-20211101", "de/fra", "V1", now, "ubuntu:latest")
+
+			image, err := resolver.resolve(context.Background(), session, providerSpec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*image.Id).To(Equal("ubuntu-new"))
+			Expect(api.GetRequestCount(http.MethodGet, "/images")).To(Equal(1))
+
+			now = now.Add(2 * time.Minute)
+
+			image, err = resolver.resolve(context.Background(), session, providerSpec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*image.Id).To(Equal("ubuntu-newest"))
+			Expect(api.GetRequestCount(http.MethodGet, "/images")).To(Equal(2))
+		})
+
+		It("should fail if no image matches", func() {
+			_, err := resolver.resolve(context.Background(), session, newProviderSpec(&apis.ImageReference{Alias: "debian:latest"}))
+			expectCode(err, codes.InvalidArgument)
+		})
+
+		It("should reject image IDs of images not being cloud-init enabled", func() {
+			_, err := resolver.resolve(context.Background(), session, newProviderSpec(&apis.ImageReference{ID: "ubuntu-no-cloud-init"}))
+			expectCode(err, codes.InvalidArgument)
+		})
+
+		It("should resolve snapshots located in the datacenter location", func() {
+			api.AddSnapshot("snapshot-fra", ionossdk.SnapshotProperties{
+				Name:        ionossdk.PtrString("golden-image"),
+				Location:    ionossdk.PtrString("de/fra"),
+				Size:        ionossdk.PtrFloat32(20),
+				LicenceType: ionossdk.PtrString("LINUX"),
+			})
+
+			image, err := resolver.resolve(context.Background(), session, newProviderSpec(&apis.ImageReference{SnapshotID: "snapshot-fra", SnapshotCloudInit: true}))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*image.Id).To(Equal("snapshot-fra"))
+			Expect(*image.Properties.Size).To(Equal(float32(20)))
+		})
+
+		It("should reject snapshots with incompatible licence types or sizes", func() {
+			api.AddSnapshot("snapshot-windows", ionossdk.SnapshotProperties{
+				Location:    ionossdk.PtrString("de/fra"),
+				Size:        ionossdk.PtrFloat32(20),
+				LicenceType: ionossdk.PtrString("WINDOWS2016"),
+			})
+			api.AddSnapshot("snapshot-unknown-licence", ionossdk.SnapshotProperties{
+				Location:    ionossdk.PtrString("de/fra"),
+				Size:        ionossdk.PtrFloat32(20),
+				LicenceType: ionossdk.PtrString("UNKNOWN"),
+			})
+			api.AddSnapshot("snapshot-large", ionossdk.SnapshotProperties{
+				Location:    ionossdk.PtrString("de/fra"),
+				Size:        ionossdk.PtrFloat32(50),
+				LicenceType: ionossdk.PtrString("LINUX"),
+			})
+
+			_, err := resolver.resolve(context.Background(), session, newProviderSpec(&apis.ImageReference{SnapshotID: "snapshot-windows", SnapshotCloudInit: true}))
+			expectCode(err, codes.InvalidArgument)
+			Expect(err.Error()).To(ContainSubstring("cloud-init"))
+
+			_, err = resolver.resolve(context.Background(), session, newProviderSpec(&apis.ImageReference{SnapshotID: "snapshot-unknown-licence", SnapshotCloudInit: true}))
+			expectCode(err, codes.InvalidArgument)
+
+			providerSpec := newProviderSpec(&apis.ImageReference{SnapshotID: "snapshot-large", SnapshotCloudInit: true})
+			providerSpec.VolumeSize = 20 * 1073741824
+
+			_, err = resolver.resolve(context.Background(), session, providerSpec)
+			expectCode(err, codes.InvalidArgument)
+			Expect(err.Error()).To(ContainSubstring("volumeSize of 20GB is smaller than the snapshot size of 50GB"))
+
+			providerSpec.VolumeSize = 0
+
+			image, err := resolver.resolve(context.Background(), session, providerSpec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(image.Properties.HasCloudInit()).To(BeFalse())
+		})
+
+		It("should reject snapshots not confirmed to support cloud-init", func() {
+			api.AddSnapshot("snapshot-unconfirmed", ionossdk.SnapshotProperties{
+				Location:    ionossdk.PtrString("de/fra"),
+				Size:        ionossdk.PtrFloat32(20),
+				LicenceType: ionossdk.PtrString("LINUX"),
+			})
+
+			_, err := resolver.resolve(context.Background(), session, newProviderSpec(&apis.ImageReference{SnapshotID: "snapshot-unconfirmed"}))
+			expectCode(err, codes.InvalidArgument)
+			Expect(err.Error()).To(ContainSubstring("snapshotCloudInit"))
+		})
+
+		It("should reject snapshots located elsewhere or not existing", func() {
+			api.AddSnapshot("snapshot-las", ionossdk.SnapshotProperties{
+				Name:     ionossdk.PtrString("golden-image"),
+				Location: ionossdk.PtrString("us/las"),
+			})
+
+			_, err := resolver.resolve(context.Background(), session, newProviderSpec(&apis.ImageReference{SnapshotID: "snapshot-las", SnapshotCloudInit: true}))
+			expectCode(err, codes.InvalidArgument)
+
+			_, err = resolver.resolve(context.Background(), session, newProviderSpec(&apis.ImageReference{SnapshotID: "snapshot-unknown", SnapshotCloudInit: true}))
+			expectCode(err, codes.InvalidArgument)
+		})
+	})
+})
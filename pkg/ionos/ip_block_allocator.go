@@ -0,0 +1,203 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ionos contains the IONOS provider specific implementations to manage machines
+package ionos
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/spi"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+// Constant maxIPBlockAllocationAttempts is the maximum number of IPs tried if the IONOS API reports conflicts
+const maxIPBlockAllocationAttempts = 5
+
+var (
+	// floatingIPAllocator serializes the allocation of IPs of all IP blocks used by this provider
+	floatingIPAllocator = newIPBlockAllocator()
+	// ipBlockReleasePollInterval is the interval used to check if IP block consumers have been released
+	ipBlockReleasePollInterval = 5 * time.Second
+	// ipBlockReleaseTimeout is the maximum time to wait for IP block consumers to be released
+	ipBlockReleaseTimeout = 2 * time.Minute
+)
+
+// ipBlockAllocator serializes the allocation of IPs per IP block.
+type ipBlockAllocator struct {
+	mutex sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// newIPBlockAllocator returns a new IP block allocator.
+func newIPBlockAllocator() *ipBlockAllocator {
+	return &ipBlockAllocator{
+		locks: map[string]*sync.Mutex{},
+	}
+}
+
+// getLock returns the lock of the IP block ID given.
+//
+// PARAMETERS
+// ipBlockID string IP block ID
+func (a *ipBlockAllocator) getLock(ipBlockID string) *sync.Mutex {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	lock, ok := a.locks[ipBlockID]
+	if !ok {
+		lock = &sync.Mutex{}
+		a.locks[ipBlockID] = lock
+	}
+
+	return lock
+}
+
+// allocate selects an unused IP of the IP block given and calls attach with it. The IP block is locked until attach
+// returns. IPs the IONOS API reports a conflict for are skipped and the next unused IP is tried.
+//
+// PARAMETERS
+// ctx       context.Context       Execution context
+// session   spi.Session           IONOS session
+// ipBlockID string                IP block ID
+// attach    func(ip string) error Function consuming the IP selected
+func (a *ipBlockAllocator) allocate(ctx context.Context, session spi.Session, ipBlockID string, attach func(ip string) error) error {
+	lock := a.getLock(ipBlockID)
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	ips, err := getUnusedIPBlockIPs(ctx, session, ipBlockID)
+	if nil != err {
+		return err
+	}
+
+	if 0 == len(ips) {
+		return fmt.Errorf("IP block '%s' given is exhausted", ipBlockID)
+	}
+
+	var lastErr error
+
+	for attempt, ip := range ips {
+		if attempt >= maxIPBlockAllocationAttempts {
+			break
+		}
+
+		err := attach(ip)
+		if nil == err {
+			return nil
+		} else if !spi.IsIPInUseError(err) {
+			return err
+		}
+
+		klog.V(2).Infof("IP %s of IP block %s is already in use, trying another one", ip, ipBlockID)
+		lastErr = err
+	}
+
+	return fmt.Errorf("Failed to allocate an unused IP of IP block '%s': %v", ipBlockID, lastErr)
+}
+
+// getUnusedIPBlockIPs returns all IPs of the IP block given not yet used by any consumer.
+//
+// PARAMETERS
+// ctx       context.Context Execution context
+// session   spi.Session     IONOS session
+// ipBlockID string          IP block ID
+func getUnusedIPBlockIPs(ctx context.Context, session spi.Session, ipBlockID string) ([]string, error) {
+	ipBlock, err := session.GetIPBlock(ctx, ipBlockID)
+	if nil != err {
+		return nil, err
+	}
+
+	ips := []string{}
+
+	if !ipBlock.HasProperties() || !ipBlock.Properties.HasIps() {
+		return ips, nil
+	}
+
+	ipsInUse := map[string]bool{}
+
+	if ipBlock.Properties.HasIpConsumers() {
+		for _, ipConsumer := range *ipBlock.Properties.IpConsumers {
+			if ipConsumer.HasIp() {
+				ipsInUse[*ipConsumer.Ip] = true
+			}
+		}
+	}
+
+	for _, ip := range *ipBlock.Properties.Ips {
+		if !ipsInUse[ip] {
+			ips = append(ips, ip)
+		}
+	}
+
+	return ips, nil
+}
+
+// isIPBlockConsumedByServer returns true if an IP of the IP block given is still used by the server ID given.
+//
+// PARAMETERS
+// ctx       context.Context Execution context
+// session   spi.Session     IONOS session
+// ipBlockID string          IP block ID
+// serverID  string          Server ID
+func isIPBlockConsumedByServer(ctx context.Context, session spi.Session, ipBlockID, serverID string) (bool, error) {
+	ipBlock, err := session.GetIPBlock(ctx, ipBlockID)
+	if nil != err {
+		return false, err
+	}
+
+	if ipBlock.HasProperties() && ipBlock.Properties.HasIpConsumers() {
+		for _, ipConsumer := range *ipBlock.Properties.IpConsumers {
+			if ipConsumer.HasServerId() && serverID == *ipConsumer.ServerId {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// waitForIPBlocksReleasedByServer waits until no IP of the IP blocks given is used by the server ID given.
+//
+// PARAMETERS
+// ctx        context.Context Execution context
+// session    spi.Session     IONOS session
+// ipBlockIDs []string        IP block IDs
+// serverID   string          Server ID
+func waitForIPBlocksReleasedByServer(ctx context.Context, session spi.Session, ipBlockIDs []string, serverID string) error {
+	if 0 == len(ipBlockIDs) {
+		return nil
+	}
+
+	return wait.PollImmediateWithContext(ctx, ipBlockReleasePollInterval, ipBlockReleaseTimeout, func(ctx context.Context) (bool, error) {
+		for _, ipBlockID := range ipBlockIDs {
+			isConsumed, err := isIPBlockConsumedByServer(ctx, session, ipBlockID, serverID)
+			if nil != err {
+				return false, err
+			} else if isConsumed {
+				klog.V(3).Infof("IP block %s is still used by server %s", ipBlockID, serverID)
+				return false, nil
+			}
+		}
+
+		return true, nil
+	})
+}
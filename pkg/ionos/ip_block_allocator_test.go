@@ -0,0 +1,134 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ionos contains the IONOS provider specific implementations to manage machines
+package ionos
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis"
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis/mock"
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/spi"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IPBlockAllocator", func() {
+	var mockTestEnv mock.MockTestEnv
+
+	networkInterface := apis.NetworkInterface{Name: "wan", LANID: "1", IPBlockID: mock.TestIPBlockID}
+
+	var _ = BeforeEach(func() {
+		mockTestEnv = mock.NewMockTestEnv()
+	})
+
+	var _ = AfterEach(func() {
+		mockTestEnv.Teardown()
+	})
+
+	Describe("#attachNetworkInterface", func() {
+		It("should allocate distinct IPs for concurrent requests", func() {
+			ctx := context.Background()
+
+			ips := []string{}
+			for i := 1; i <= 8; i++ {
+				ips = append(ips, fmt.Sprintf("192.0.2.%d", i))
+			}
+
+			state := mock.NewIPBlockState(ips...)
+
+			mock.SetupIPBlockEndpointOnMux(mockTestEnv.Mux, state)
+			mock.SetupIPBlockNicsEndpointsOnMux(mockTestEnv.Mux, state)
+
+			var waitGroup sync.WaitGroup
+			errs := make(chan error, len(ips))
+
+			for range ips {
+				waitGroup.Add(1)
+
+				go func(serverID string) {
+					defer waitGroup.Done()
+
+					_, err := attachNetworkInterface(ctx, spi.NewClientSession(mockTestEnv.Client), mock.TestProviderSpecDatacenterID, serverID, networkInterface)
+					errs <- err
+				}(uuid.NewString())
+			}
+
+			waitGroup.Wait()
+			close(errs)
+
+			for err := range errs {
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			Expect(state.NicPostCount).To(Equal(len(ips)))
+			Expect(state.ConflictCount).To(Equal(0))
+			Expect(state.Consumers).To(HaveLen(len(ips)))
+		})
+
+		It("should retry with another IP if a conflict is reported", func() {
+			ctx := context.Background()
+			serverID := uuid.NewString()
+
+			state := mock.NewIPBlockState("192.0.2.1", "192.0.2.2")
+			state.RacingIPs = []string{"192.0.2.1"}
+
+			mock.SetupIPBlockEndpointOnMux(mockTestEnv.Mux, state)
+			mock.SetupIPBlockNicsEndpointsOnMux(mockTestEnv.Mux, state)
+
+			_, err := attachNetworkInterface(ctx, spi.NewClientSession(mockTestEnv.Client), mock.TestProviderSpecDatacenterID, serverID, networkInterface)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.ConflictCount).To(Equal(1))
+			Expect(state.GetConsumerIPs(serverID)).To(Equal([]string{"192.0.2.2"}))
+		})
+
+		It("should fail if all IPs are in use", func() {
+			ctx := context.Background()
+
+			state := mock.NewIPBlockState("192.0.2.1", "192.0.2.2")
+			state.RacingIPs = []string{"192.0.2.2"}
+			state.AddConsumer("192.0.2.1", mock.TestForeignServerID)
+
+			mock.SetupIPBlockEndpointOnMux(mockTestEnv.Mux, state)
+			mock.SetupIPBlockNicsEndpointsOnMux(mockTestEnv.Mux, state)
+
+			_, err := attachNetworkInterface(ctx, spi.NewClientSession(mockTestEnv.Client), mock.TestProviderSpecDatacenterID, uuid.NewString(), networkInterface)
+
+			Expect(err).To(HaveOccurred())
+			Expect(state.NicPostCount).To(Equal(0))
+		})
+
+		It("should fail if the IP block is exhausted", func() {
+			ctx := context.Background()
+
+			state := mock.NewIPBlockState("192.0.2.1")
+			state.AddConsumer("192.0.2.1", mock.TestForeignServerID)
+
+			mock.SetupIPBlockEndpointOnMux(mockTestEnv.Mux, state)
+			mock.SetupIPBlockNicsEndpointsOnMux(mockTestEnv.Mux, state)
+
+			_, err := attachNetworkInterface(ctx, spi.NewClientSession(mockTestEnv.Client), mock.TestProviderSpecDatacenterID, uuid.NewString(), networkInterface)
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("exhausted"))
+		})
+	})
+})
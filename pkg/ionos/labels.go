@@ -0,0 +1,252 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ionos contains the IONOS provider specific implementations to manage machines
+package ionos
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis"
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/spi"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// Constant labelKeyCluster is the label key storing the cluster a resource belongs to
+	labelKeyCluster = "cluster"
+	// Constant labelKeyDeleteOnTermination is the volume label key storing if a data volume is deleted with the machine
+	labelKeyDeleteOnTermination = "delete-on-termination"
+	// Constant labelKeyMachineClass is the label key storing the machine class a resource has been created for
+	labelKeyMachineClass = "machine-class"
+	// Constant labelKeyMachineDeployment is the label key storing the machine deployment a resource has been created for
+	labelKeyMachineDeployment = "machine-deployment"
+	// Constant labelKeyMachineName is the label key storing the machine a resource has been created for
+	labelKeyMachineName = "machine-name"
+	// Constant labelKeyProviderVersion is the label key storing the provider version a resource has been created with
+	labelKeyProviderVersion = "provider-version"
+	// Constant labelKeyRegion is the server label key storing the region
+	labelKeyRegion = "region"
+	// Constant labelKeyRole is the server label key storing the role of the server
+	labelKeyRole = "role"
+	// Constant labelKeyZone is the server label key storing the zone
+	labelKeyZone = "zone"
+	// Constant labelManagerConcurrency is the maximum number of labels added in parallel
+	labelManagerConcurrency = 4
+)
+
+// isLabeledByPreviousVersion returns true if the labels given have been added by a previous version encoding label
+// values as hex. These versions didn't add the provider version label which is added before all other labels now.
+//
+// PARAMETERS
+// labels map[string]string Labels of a resource
+func isLabeledByPreviousVersion(labels map[string]string) bool {
+	_, ok := labels[labelKeyProviderVersion]
+	return !ok
+}
+
+// isLabelMatching returns true if the label with the key given encodes the value given.
+//
+// PARAMETERS
+// labels map[string]string Labels of a resource
+// key    string            Label key
+// value  string            Value expected
+func isLabelMatching(labels map[string]string, key, value string) bool {
+	labelValue, ok := labels[key]
+	return ok && apis.IsLabelValueMatching(labelValue, value, isLabeledByPreviousVersion(labels))
+}
+
+// labelManager adds label sets to IONOS resources
+type labelManager struct {
+	session      spi.Session
+	datacenterID string
+	concurrency  int
+}
+
+// newLabelManager returns a new label manager for resources of the datacenter given.
+//
+// PARAMETERS
+// session      spi.Session IONOS session
+// datacenterID string      Datacenter ID
+func newLabelManager(session spi.Session, datacenterID string) *labelManager {
+	return &labelManager{
+		session:      session,
+		datacenterID: datacenterID,
+		concurrency:  labelManagerConcurrency,
+	}
+}
+
+// applyServerLabels adds all labels given missing in the existing labels to the server. Labels added are recorded in
+// the existing labels map.
+//
+// PARAMETERS
+// ctx      context.Context   Execution context
+// serverID string            Server ID
+// labels   map[string]string Labels to apply
+// existing map[string]string Labels already present
+func (m *labelManager) applyServerLabels(ctx context.Context, serverID string, labels, existing map[string]string) error {
+	add := func(ctx context.Context, key, value string) error {
+		return m.session.AddServerLabel(ctx, m.datacenterID, serverID, key, value)
+	}
+
+	wait := func(ctx context.Context) error {
+		_, err := m.session.WaitForServer(ctx, m.datacenterID, serverID)
+		return err
+	}
+
+	return m.apply(ctx, labels, existing, add, wait)
+}
+
+// applyVolumeLabels adds all labels given missing in the existing labels to the volume. Labels added are recorded in
+// the existing labels map.
+//
+// PARAMETERS
+// ctx      context.Context   Execution context
+// volumeID string            Volume ID
+// labels   map[string]string Labels to apply
+// existing map[string]string Labels already present
+func (m *labelManager) applyVolumeLabels(ctx context.Context, volumeID string, labels, existing map[string]string) error {
+	add := func(ctx context.Context, key, value string) error {
+		return m.session.AddVolumeLabel(ctx, m.datacenterID, volumeID, key, value)
+	}
+
+	wait := func(ctx context.Context) error {
+		_, err := m.session.WaitForVolume(ctx, m.datacenterID, volumeID)
+		return err
+	}
+
+	return m.apply(ctx, labels, existing, add, wait)
+}
+
+// apply adds all missing labels in parallel with the concurrency of the label manager. The provider version label is
+// added and waited for first as resources labeled without it are matched like the ones of previous versions. No
+// further labels are added after the first error which is returned.
+//
+// PARAMETERS
+// ctx      context.Context                                    Execution context
+// labels   map[string]string                                  Labels to apply
+// existing map[string]string                                  Labels already present
+// add      func(ctx context.Context, key, value string) error Function adding a label
+// wait     func(ctx context.Context) error                    Function waiting for labels added to the resource
+func (m *labelManager) apply(ctx context.Context, labels, existing map[string]string, add func(ctx context.Context, key, value string) error, wait func(ctx context.Context) error) error {
+	var (
+		firstErr  error
+		mutex     sync.Mutex
+		waitGroup sync.WaitGroup
+	)
+
+	if value, ok := labels[labelKeyProviderVersion]; ok {
+		if _, ok := existing[labelKeyProviderVersion]; !ok {
+			err := add(ctx, labelKeyProviderVersion, value)
+			if nil != err {
+				return err
+			}
+
+			existing[labelKeyProviderVersion] = value
+
+			err = wait(ctx)
+			if nil != err {
+				return err
+			}
+		}
+	}
+
+	missingKeys := getMissingLabelKeys(labels, existing)
+	if 0 == len(missingKeys) {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	semaphore := make(chan struct{}, m.concurrency)
+
+	for _, key := range missingKeys {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+		}
+
+		if nil != ctx.Err() {
+			break
+		}
+
+		waitGroup.Add(1)
+
+		go func(key, value string) {
+			defer waitGroup.Done()
+			defer func() { <-semaphore }()
+
+			err := add(ctx, key, value)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if nil != err {
+				if nil == firstErr {
+					firstErr = err
+					cancel()
+				}
+
+				return
+			}
+
+			existing[key] = value
+		}(key, labels[key])
+	}
+
+	waitGroup.Wait()
+
+	if nil == firstErr && nil != ctx.Err() {
+		firstErr = ctx.Err()
+	}
+
+	if nil == firstErr {
+		klog.V(3).Infof("Added %d labels to resources of datacenter %q", len(missingKeys), m.datacenterID)
+	}
+
+	return firstErr
+}
+
+// getMissingLabelKeys returns the sorted keys of all labels not present in the existing labels.
+//
+// PARAMETERS
+// labels   map[string]string Labels to apply
+// existing map[string]string Labels already present
+func getMissingLabelKeys(labels, existing map[string]string) []string {
+	missingKeys := []string{}
+
+	for key := range labels {
+		if _, ok := existing[key]; !ok {
+			missingKeys = append(missingKeys, key)
+		}
+	}
+
+	sort.Strings(missingKeys)
+
+	return missingKeys
+}
+
+// hasAllLabels returns true if all keys of the labels given are present in the existing labels.
+//
+// PARAMETERS
+// existing map[string]string Labels already present
+// labels   map[string]string Labels expected
+func hasAllLabels(existing, labels map[string]string) bool {
+	return 0 == len(getMissingLabelKeys(labels, existing))
+}
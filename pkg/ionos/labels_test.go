@@ -0,0 +1,198 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ionos contains the IONOS provider specific implementations to manage machines
+package ionos
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis"
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis/mock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Labels", func() {
+	Describe("#EncodeLabelValue", func() {
+		DescribeTable("##table",
+			func(value, encoded string) {
+				Expect(apis.EncodeLabelValue(value)).To(Equal(encoded))
+
+				decoded, err := apis.DecodeLabelValue(encoded)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(decoded).To(Equal(value))
+			},
+			Entry("keeps letters, digits and dashes", "shoot--dev-Cluster1", "shoot--dev-Cluster1"),
+			Entry("escapes all other characters", "shoot--dev/eu.1", "shoot--dev_2feu_2e1"),
+			Entry("escapes the escape character", "a_b", "a_5fb"),
+			Entry("escapes multi-byte characters bytewise", "ä", "_c3_a4"),
+			Entry("keeps empty values", "", ""),
+		)
+
+		It("should reject invalid label values", func() {
+			for _, value := range []string{"a_", "a_5", "a_zz", "a.b"} {
+				_, err := apis.DecodeLabelValue(value)
+				Expect(err).To(HaveOccurred(), value)
+			}
+		})
+
+		It("should match hex encoded label values of previous versions", func() {
+			Expect(apis.IsLabelValueMatching("shoot--dev_2feu", "shoot--dev/eu", false)).To(BeTrue())
+			Expect(apis.IsLabelValueMatching(hex.EncodeToString([]byte("shoot--dev/eu")), "shoot--dev/eu", true)).To(BeTrue())
+			Expect(apis.IsLabelValueMatching(hex.EncodeToString([]byte("shoot--dev/eu")), "shoot--dev/eu", false)).To(BeFalse())
+			Expect(apis.IsLabelValueMatching("shoot--dev", "shoot--prod", true)).To(BeFalse())
+		})
+
+		It("should only match hex encoded label values decoding to exactly the value expected", func() {
+			Expect(apis.IsLabelValueMatching("616263", "abc", true)).To(BeTrue())
+			Expect(apis.IsLabelValueMatching("616263", "616263", true)).To(BeFalse())
+			Expect(apis.IsLabelValueMatching("616263", "616263", false)).To(BeTrue())
+			Expect(apis.IsLabelValueMatching("616263", "abc", false)).To(BeFalse())
+			Expect(apis.IsLabelValueMatching("616263", "ABC", true)).To(BeFalse())
+			Expect(apis.IsLabelValueMatching("6162", "abc", true)).To(BeFalse())
+			Expect(apis.IsLabelValueMatching("61626", "abc", true)).To(BeFalse())
+			Expect(apis.IsLabelValueMatching("shoot", "shoot", true)).To(BeTrue())
+		})
+	})
+
+	Describe("#labelManager", func() {
+		noWait := func(ctx context.Context) error {
+			return nil
+		}
+
+		It("should add all missing labels with bounded concurrency", func() {
+			var (
+				mutex       sync.Mutex
+				added       []string
+				inFlight    int
+				maxInFlight int
+			)
+
+			labels := map[string]string{}
+			for _, key := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+				labels[key] = key
+			}
+
+			existing := map[string]string{"a": "a"}
+			manager := &labelManager{concurrency: 3}
+
+			err := manager.apply(context.Background(), labels, existing, func(ctx context.Context, key, value string) error {
+				mutex.Lock()
+				inFlight++
+				if inFlight > maxInFlight {
+					maxInFlight = inFlight
+				}
+				added = append(added, key)
+				mutex.Unlock()
+
+				time.Sleep(10 * time.Millisecond)
+
+				mutex.Lock()
+				inFlight--
+				mutex.Unlock()
+
+				return nil
+			}, noWait)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(existing).To(Equal(labels))
+			Expect(added).To(HaveLen(7))
+			Expect(added).NotTo(ContainElement("a"))
+			Expect(maxInFlight).To(BeNumerically("<=", 3))
+			Expect(maxInFlight).To(BeNumerically(">", 1))
+		})
+
+		It("should return the first error and only record labels added", func() {
+			existing := map[string]string{}
+			manager := &labelManager{concurrency: 1}
+
+			err := manager.apply(context.Background(), map[string]string{"a": "a", "b": "b", "c": "c"}, existing, func(ctx context.Context, key, value string) error {
+				if "b" == key {
+					return errors.New("label rejected")
+				}
+
+				return nil
+			}, noWait)
+
+			Expect(err).To(MatchError("label rejected"))
+			Expect(existing).To(Equal(map[string]string{"a": "a"}))
+		})
+		It("should add and wait for the provider version label before all other labels", func() {
+			var (
+				mutex sync.Mutex
+				calls []string
+			)
+
+			labels := map[string]string{labelKeyCluster: "a", labelKeyRole: "node", labelKeyProviderVersion: "v1", labelKeyZone: "b"}
+			existing := map[string]string{}
+			manager := &labelManager{concurrency: 3}
+
+			err := manager.apply(context.Background(), labels, existing, func(ctx context.Context, key, value string) error {
+				mutex.Lock()
+				calls = append(calls, key)
+				mutex.Unlock()
+
+				return nil
+			}, func(ctx context.Context) error {
+				calls = append(calls, "wait")
+				return nil
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(existing).To(Equal(labels))
+			Expect(calls).To(HaveLen(5))
+			Expect(calls[:2]).To(Equal([]string{labelKeyProviderVersion, "wait"}))
+		})
+
+		It("should not add other labels if the provider version label was rejected", func() {
+			existing := map[string]string{}
+			manager := &labelManager{concurrency: 3}
+
+			err := manager.apply(context.Background(), map[string]string{labelKeyCluster: "a", labelKeyProviderVersion: "v1"}, existing, func(ctx context.Context, key, value string) error {
+				return errors.New("label rejected")
+			}, noWait)
+
+			Expect(err).To(MatchError("label rejected"))
+			Expect(existing).To(BeEmpty())
+		})
+	})
+
+	Describe("#createMachineWorkflow", func() {
+		It("should label resources with the machine class and deployment", func() {
+			machine := mock.NewMachine("")
+			machine.Name = "machine-labeled"
+			machine.Labels = map[string]string{"name": "shoot--dev--worker-z1"}
+			machine.Spec.Class.Name = "shoot--dev--worker-z1-3f4a2"
+
+			workflow := newCreateMachineWorkflow(nil, machine, mock.NewProviderSpec(), nil, nil, nil, &CreateMachineMethodData{})
+			labels := workflow.getServerLabels()
+
+			Expect(labels).To(HaveKeyWithValue(labelKeyMachineName, "machine-labeled"))
+			Expect(labels).To(HaveKeyWithValue(labelKeyMachineClass, "shoot--dev--worker-z1-3f4a2"))
+			Expect(labels).To(HaveKeyWithValue(labelKeyMachineDeployment, "shoot--dev--worker-z1"))
+			Expect(labels).To(HaveKeyWithValue(labelKeyRole, "node"))
+			Expect(labels).To(HaveKey(labelKeyProviderVersion))
+
+			Expect(workflow.getVolumeLabels()).NotTo(HaveKey(labelKeyRole))
+			Expect(workflow.getDataVolumeLabels(apis.DataVolume{Name: "data"})).To(HaveKeyWithValue(labelKeyDeleteOnTermination, "true"))
+		})
+	})
+})
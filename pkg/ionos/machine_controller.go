@@ -18,16 +18,13 @@ limitations under the License.
 package ionos
 
 import (
-	"bytes"
 	"context"
-	"encoding/base64"
-	"encoding/hex"
+	"encoding/json"
 	"fmt"
-	"math"
 
-	ionosapiwrapper "github.com/23technologies/ionos-api-wrapper/pkg"
 	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis"
 	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis/transcoder"
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/spi"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/driver"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
@@ -35,20 +32,28 @@ import (
 	"k8s.io/klog/v2"
 )
 
-// Constant ionosVolumeType is the volume type
-const ionosVolumeType = "SSD"
-
 // CreateMachine handles a machine creation request
 //
 // PARAMETERS
 // ctx context.Context              Execution context
 // req *driver.CreateMachineRequest The create request for VM creation
 func (p *MachineProvider) CreateMachine(ctx context.Context, req *driver.CreateMachineRequest) (*driver.CreateMachineResponse, error) {
-	extendedCtx := context.WithValue(ctx, CtxWrapDataKey("MethodData"), &CreateMachineMethodData{})
+	resultData := &CreateMachineMethodData{}
+	extendedCtx := context.WithValue(ctx, CtxWrapDataKey("MethodData"), resultData)
 
-	resp, err := p.createMachine(extendedCtx, req)
+	budgetCtx, cancel := withOperationBudget(extendedCtx)
+	defer cancel()
 
-	if nil != err {
+	resp, err := p.createMachine(budgetCtx, req)
+
+	if nil != err && isDeadlineExceeded(budgetCtx) {
+		if errStatus, ok := err.(*status.Status); !ok || codes.DeadlineExceeded != errStatus.Code() {
+			err = status.Error(codes.DeadlineExceeded, fmt.Sprintf("Machine creation of %q exceeded its deadline: %v", req.Machine.Name, err))
+		}
+	}
+
+	// Resources of resumable errors are discovered and taken over by the next CreateMachine call
+	if nil != err && !isCreateMachineErrorResumable(err) {
 		p.createMachineOnErrorCleanup(extendedCtx, req, err)
 	}
 
@@ -86,157 +91,51 @@ func (p *MachineProvider) createMachine(ctx context.Context, req *driver.CreateM
 		return nil, status.Error(codes.Internal, "userData doesn't exist")
 	}
 
-	// @TODO: IONOS is currently unable to set-up hostnames. We need to hack around it for now.
-	if bytes.HasPrefix(userData, []byte("#cloud-config\n")) {
-		return nil, status.Error(codes.InvalidArgument, "userData #cloud-config which is not supported")
-	}
-
-	userDataBuffer := bytes.NewBuffer(userData)
-	userDataBuffer.WriteString(fmt.Sprintf("\n\necho '%s' > /etc/hostname", machine.Name))
-	userData = userDataBuffer.Bytes()
-
-	client := ionosapiwrapper.GetClientForUser(string(secret.Data["user"]), string(secret.Data["password"]))
-
-	image, _, err := client.ImagesApi.ImagesFindById(ctx, providerSpec.ImageID).Depth(1).Execute()
+	// IONOS is unable to set-up hostnames. They are injected into the user data together with the SSH keys.
+	sshKeys, err := providerSpec.GetSSHKeys(secret)
 	if nil != err {
 		return nil, status.Error(codes.InvalidArgument, err.Error())
-	} else if (!image.Properties.HasCloudInit() || "NONE" == *image.Properties.CloudInit) {
-		return nil, status.Error(codes.InvalidArgument, "imageID given doesn't belong to a cloud-init enabled image")
-	}
-
-	sshKeys := []string{fmt.Sprintf("%s\n", providerSpec.SSHKey)}
-	userDataBase64Enc := base64.StdEncoding.EncodeToString(userData)
-	volumeName := fmt.Sprintf("%s-root-volume", machine.Name)
-	volumeSize := providerSpec.VolumeSize
-	volumeType := ionosVolumeType
-
-	if 0 == volumeSize {
-		volumeSize = *image.Properties.Size
-	} else {
-		volumeSize = float32(math.Max(math.Ceil(float64(volumeSize) / 1073741824), float64(*image.Properties.Size)))
-	}
-
-	volumeProperties := ionossdk.VolumeProperties{
-		Type: &volumeType,
-		Name: &volumeName,
-		Size: &volumeSize,
-		Image: &providerSpec.ImageID,
-		SshKeys: &sshKeys,
-		UserData: &userDataBase64Enc,
-	}
-
-	volumeApiCreateRequest := client.VolumesApi.DatacentersVolumesPost(ctx, providerSpec.DatacenterID).Depth(0)
-	volume, httpResponse, err := volumeApiCreateRequest.Volume(ionossdk.Volume{Properties: &volumeProperties}).Execute()
-	if 404 == httpResponse.StatusCode {
-		return nil, status.Error(codes.Canceled, "datacenterID given is invalid")
-	} else if nil != err {
-		return nil, status.Error(codes.Internal, err.Error())
-	}
-
-	clusterValue := hex.EncodeToString([]byte(providerSpec.Cluster))
-	volumeID := *volume.Id
-	resultData.VolumeID = volumeID
-
-	volume, err = ionosapiwrapper.WaitForVolumeModificationsAndGetResult(ctx, client, providerSpec.DatacenterID, volumeID)
-	if nil != err {
-		return nil, status.Error(codes.Internal, err.Error())
-	}
-
-	err = ionosapiwrapper.AddLabelToVolume(ctx, client, providerSpec.DatacenterID, volumeID, "cluster", clusterValue)
-	if nil != err {
-		return nil, status.Error(codes.Internal, err.Error())
-	}
-
-	cores := int32(providerSpec.Cores)
-	memory := int32(providerSpec.Memory)
-
-	serverEntities := ionossdk.ServerEntities{
-		Volumes: &ionossdk.AttachedVolumes{Items: &[]ionossdk.Volume{ionossdk.Volume{Id: &volumeID}}},
-	}
-
-	serverProperties := ionossdk.ServerProperties{
-		Name:       &machine.Name,
-		Cores:      &cores,
-		Ram:        &memory,
-		BootVolume: &ionossdk.ResourceReference{Id: &volumeID},
-	}
-
-	serverApiCreateRequest := client.ServersApi.DatacentersServersPost(ctx, providerSpec.DatacenterID).Depth(0)
-	server, _, err := serverApiCreateRequest.Server(ionossdk.Server{Entities: &serverEntities, Properties: &serverProperties}).Execute()
-	if nil != err {
-		return nil, status.Error(codes.Unavailable, err.Error())
-	}
-
-	serverID := *server.Id
-	resultData.ServerID = serverID
-
-	err = ionosapiwrapper.WaitForServerModifications(ctx, client, providerSpec.DatacenterID, serverID)
-	if nil != err {
-		return nil, status.Error(codes.Internal, err.Error())
-	}
-
-	_, err = client.ServersApi.DatacentersServersStopPost(ctx, providerSpec.DatacenterID, serverID).Execute()
-	if nil != err {
-		return nil, status.Error(codes.Aborted, err.Error())
-	}
-
-	err = ionosapiwrapper.WaitForServerModifications(ctx, client, providerSpec.DatacenterID, serverID)
-	if nil != err {
-		return nil, status.Error(codes.Internal, err.Error())
 	}
 
-	err = ionosapiwrapper.AddLabelToServer(ctx, client, providerSpec.DatacenterID, serverID, "cluster", clusterValue)
+	userData, err = newUserDataRenderer(machine.Name, sshKeys).render(userData)
 	if nil != err {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
-	err = ionosapiwrapper.AddLabelToServer(ctx, client, providerSpec.DatacenterID, serverID, "role", "node")
+	userData, err = compressUserData(userData)
 	if nil != err {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
-	region := apis.GetRegionFromZone(providerSpec.Zone)
-
-	err = ionosapiwrapper.AddLabelToServer(ctx, client, providerSpec.DatacenterID, serverID, "region", hex.EncodeToString([]byte(region)))
+	session, err := p.SPI.NewSession(secret)
 	if nil != err {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
-	err = ionosapiwrapper.AddLabelToServer(ctx, client, providerSpec.DatacenterID, serverID, "zone", hex.EncodeToString([]byte(providerSpec.Zone)))
+	image, err := ionosImageResolver.resolve(ctx, session, providerSpec)
 	if nil != err {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, err
 	}
 
-	if "" == providerSpec.FloatingPoolID {
-		err = ionosapiwrapper.AttachWANToServer(ctx, client, providerSpec.DatacenterID, serverID, providerSpec.NetworkIDs.WAN)
-	} else {
-		err = ionosapiwrapper.AttachWANAndFloatingIPToServer(ctx, client, providerSpec.DatacenterID, serverID, providerSpec.NetworkIDs.WAN, providerSpec.FloatingPoolID)
-	}
+	workflow := newCreateMachineWorkflow(session, machine, providerSpec, image, userData, sshKeys, resultData)
 
+	step, err := workflow.discover(ctx)
 	if nil != err {
-		return nil, status.Error(codes.Internal, err.Error())
-	}
-
-	if "" != providerSpec.NetworkIDs.Workers {
-		err = ionosapiwrapper.AttachLANToServerWithoutDHCP(ctx, client, providerSpec.DatacenterID, serverID, providerSpec.NetworkIDs.Workers)
-		if nil != err {
-			return nil, status.Error(codes.Internal, err.Error())
-		}
+		return nil, status.Error(codes.Unavailable, err.Error())
 	}
 
-	err = ionosapiwrapper.WaitForServerModifications(ctx, client, providerSpec.DatacenterID, serverID)
-	if nil != err {
-		return nil, status.Error(codes.Internal, err.Error())
+	if CreateMachineStepCreateVolume != step {
+		klog.V(2).Infof("Resuming machine creation for %q with step %s", machine.Name, step)
 	}
 
-	_, err = client.ServersApi.DatacentersServersStartPost(ctx, providerSpec.DatacenterID, serverID).Execute()
+	err = workflow.run(ctx, step)
 	if nil != err {
-		return nil, status.Error(codes.Aborted, err.Error())
+		return nil, err
 	}
 
-	server, err = ionosapiwrapper.WaitForServerModificationsAndGetResult(ctx, client, providerSpec.DatacenterID, serverID)
+	server, err := session.WaitForServer(ctx, providerSpec.DatacenterID, resultData.ServerID)
 	if nil != err {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, newCreateMachineStepError(err)
 	}
 
 	response := &driver.CreateMachineResponse{
@@ -260,22 +159,30 @@ func (p *MachineProvider) createMachineOnErrorCleanup(ctx context.Context, req *
 		resultData   = ctx.Value(CtxWrapDataKey("MethodData")).(*CreateMachineMethodData)
 	)
 
-	client := ionosapiwrapper.GetClientForUser(string(secret.Data["user"]), string(secret.Data["password"]))
+	session, sessionErr := p.SPI.NewSession(secret)
+	if nil != sessionErr {
+		return
+	}
+
 	providerSpec, _ := transcoder.DecodeProviderSpecFromMachineClass(machineClass, secret)
 
 	if resultData.ServerID != "" {
-		_, err := client.ServersApi.DatacentersServersStopPost(ctx, providerSpec.DatacenterID, resultData.ServerID).Execute()
+		err := session.StopServer(ctx, providerSpec.DatacenterID, resultData.ServerID)
 		if nil == err {
-			ionosapiwrapper.WaitForServerModifications(ctx, client, providerSpec.DatacenterID, resultData.ServerID)
+			_, _ = session.WaitForServer(ctx, providerSpec.DatacenterID, resultData.ServerID)
 		}
 	}
 
 	if resultData.VolumeID != "" {
-		_, _ = client.VolumesApi.DatacentersVolumesDelete(ctx, providerSpec.DatacenterID, resultData.VolumeID).Depth(0).Execute()
+		_ = session.DeleteVolume(ctx, providerSpec.DatacenterID, resultData.VolumeID)
+	}
+
+	for _, volumeID := range resultData.DataVolumeIDs {
+		_ = session.DeleteVolume(ctx, providerSpec.DatacenterID, volumeID)
 	}
 
 	if resultData.ServerID != "" {
-		_, _ = client.ServersApi.DatacentersServersDelete(ctx, providerSpec.DatacenterID, resultData.ServerID).Depth(0).Execute()
+		_ = session.DeleteServer(ctx, providerSpec.DatacenterID, resultData.ServerID)
 	}
 }
 
@@ -285,10 +192,33 @@ func (p *MachineProvider) createMachineOnErrorCleanup(ctx context.Context, req *
 // ctx context.Context              Execution context
 // req *driver.CreateMachineRequest The delete request for VM deletion
 func (p *MachineProvider) DeleteMachine(ctx context.Context, req *driver.DeleteMachineRequest) (*driver.DeleteMachineResponse, error) {
+	resultData := &DeleteMachineMethodData{}
+	extendedCtx := context.WithValue(ctx, CtxWrapDataKey("MethodData"), resultData)
+
+	budgetCtx, cancel := withOperationBudget(extendedCtx)
+	defer cancel()
+
+	resp, err := p.deleteMachine(budgetCtx, req)
+
+	if nil != err && isDeadlineExceeded(budgetCtx) {
+		resp = &driver.DeleteMachineResponse{LastKnownState: encodeLastKnownState(resultData)}
+		err = status.Error(codes.DeadlineExceeded, fmt.Sprintf("Machine deletion of %q exceeded its deadline: %v", req.Machine.Name, err))
+	}
+
+	return resp, err
+}
+
+// deleteMachine handles the actual machine deletion request
+//
+// PARAMETERS
+// ctx context.Context              Execution context
+// req *driver.DeleteMachineRequest The delete request for VM deletion
+func (p *MachineProvider) deleteMachine(ctx context.Context, req *driver.DeleteMachineRequest) (*driver.DeleteMachineResponse, error) {
 	var (
 		machine      = req.Machine
 		machineClass = req.MachineClass
 		secret       = req.Secret
+		resultData   = ctx.Value(CtxWrapDataKey("MethodData")).(*DeleteMachineMethodData)
 	)
 
 	// Log messages to track delete request
@@ -300,46 +230,119 @@ func (p *MachineProvider) DeleteMachine(ctx context.Context, req *driver.DeleteM
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
+	resultData.ServerID = serverID
+
+	// Progress of a previous call exceeding its deadline is taken over
+	if "" != machine.Status.LastKnownState {
+		lastKnownState := &DeleteMachineMethodData{}
+
+		if nil == json.Unmarshal([]byte(machine.Status.LastKnownState), lastKnownState) && serverID == lastKnownState.ServerID {
+			*resultData = *lastKnownState
+		}
+	}
+
 	providerSpec, err := transcoder.DecodeProviderSpecFromMachineClass(machineClass, secret)
 	if nil != err {
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
-	client := ionosapiwrapper.GetClientForUser(string(secret.Data["user"]), string(secret.Data["password"]))
-
-	httpResponse, err := client.ServersApi.DatacentersServersStopPost(ctx, providerSpec.DatacenterID, serverID).Execute()
+	session, err := p.SPI.NewSession(secret)
 	if nil != err {
-		if 404 == httpResponse.StatusCode {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	ipBlockIDs := []string{}
+
+	for _, networkInterface := range providerSpec.GetNetworkInterfaces() {
+		if "" != networkInterface.IPBlockID {
+			ipBlockIDs = append(ipBlockIDs, networkInterface.IPBlockID)
+		}
+	}
+
+	// Floating IPs of a VM deleted may not have been released yet
+	waitForIPsReleased := func() (*driver.DeleteMachineResponse, error) {
+		err := waitForIPBlocksReleasedByServer(ctx, session, ipBlockIDs, serverID)
+		if nil != err {
+			return nil, status.Error(codes.Unavailable, fmt.Sprintf("Floating IPs of VM %s (%s) have not been released: %v", machine.Name, serverID, err))
+		}
+
+		return &driver.DeleteMachineResponse{}, nil
+	}
+
+	if resultData.ServerDeleted {
+		klog.V(3).Infof("VM %s (%s) has been deleted by a previous call", machine.Name, serverID)
+		return waitForIPsReleased()
+	}
+
+	if !resultData.ServerStopped {
+		err = session.StopServer(ctx, providerSpec.DatacenterID, serverID)
+		if spi.IsNotFoundError(err) {
 			klog.V(3).Infof("VM %s (%s) does not exist", machine.Name, serverID)
-			return &driver.DeleteMachineResponse{}, nil
-		} else {
+			return waitForIPsReleased()
+		} else if nil != err {
 			return nil, status.Error(codes.Unavailable, err.Error())
 		}
-	}
 
-	err = ionosapiwrapper.WaitForServerModifications(ctx, client, providerSpec.DatacenterID, serverID)
-	if nil != err {
-		return nil, status.Error(codes.Internal, err.Error())
+		_, err = session.WaitForServer(ctx, providerSpec.DatacenterID, serverID)
+		if nil != err {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		resultData.ServerStopped = true
 	}
 
-	server, _, err := client.ServersApi.DatacentersServersFindById(ctx, providerSpec.DatacenterID, serverID).Depth(3).Execute()
-	if nil != err {
+	server, err := session.GetServer(ctx, providerSpec.DatacenterID, serverID, 3)
+	if spi.IsNotFoundError(err) {
+		klog.V(3).Infof("VM %s (%s) does not exist", machine.Name, serverID)
+		return waitForIPsReleased()
+	} else if nil != err {
 		return nil, status.Error(codes.Unavailable, err.Error())
 	}
 
-	for _, volume := range *server.Entities.Volumes.Items {
-		_, err := client.VolumesApi.DatacentersVolumesDelete(ctx, providerSpec.DatacenterID, *volume.Id).Depth(0).Execute()
+	deletedVolumeIDs := map[string]bool{}
+
+	for _, volumeID := range resultData.DeletedVolumeIDs {
+		deletedVolumeIDs[volumeID] = true
+	}
+
+	volumes := []ionossdk.Volume{}
+
+	if server.HasEntities() && server.Entities.HasVolumes() && server.Entities.Volumes.HasItems() {
+		volumes = *server.Entities.Volumes.Items
+	}
+
+	for _, volume := range volumes {
+		if deletedVolumeIDs[*volume.Id] {
+			klog.V(3).Infof("Volume %s of VM %s (%s) has been deleted by a previous call", *volume.Id, machine.Name, serverID)
+			continue
+		}
+
+		labels, err := session.GetVolumeLabels(ctx, providerSpec.DatacenterID, *volume.Id)
 		if nil != err {
 			return nil, status.Error(codes.Unavailable, err.Error())
 		}
+
+		if "false" == labels[labelKeyDeleteOnTermination] {
+			klog.V(3).Infof("Volume %s of VM %s (%s) is retained", *volume.Id, machine.Name, serverID)
+			continue
+		}
+
+		err = session.DeleteVolume(ctx, providerSpec.DatacenterID, *volume.Id)
+		if nil != err {
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+
+		resultData.DeletedVolumeIDs = append(resultData.DeletedVolumeIDs, *volume.Id)
 	}
 
-	_, err = client.ServersApi.DatacentersServersDelete(ctx, providerSpec.DatacenterID, serverID).Depth(0).Execute()
+	err = session.DeleteServer(ctx, providerSpec.DatacenterID, serverID)
 	if nil != err {
 		return nil, status.Error(codes.Unavailable, err.Error())
 	}
 
-	return &driver.DeleteMachineResponse{}, nil
+	resultData.ServerDeleted = true
+
+	return waitForIPsReleased()
 }
 
 // GetMachineStatus handles a machine get status request
@@ -349,8 +352,8 @@ func (p *MachineProvider) DeleteMachine(ctx context.Context, req *driver.DeleteM
 // req *driver.CreateMachineRequest The get request for VM info
 func (p *MachineProvider) GetMachineStatus(ctx context.Context, req *driver.GetMachineStatusRequest) (*driver.GetMachineStatusResponse, error) {
 	var (
-		machine      = req.Machine
-		secret       = req.Secret
+		machine = req.Machine
+		secret  = req.Secret
 	)
 
 	// Log messages to track start and end of request
@@ -367,16 +370,58 @@ func (p *MachineProvider) GetMachineStatus(ctx context.Context, req *driver.GetM
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
-	client := ionosapiwrapper.GetClientForUser(string(secret.Data["user"]), string(secret.Data["password"]))
-
-	server, _, err := client.ServersApi.DatacentersServersFindById(ctx, serverData.DatacenterID, serverData.ID).Depth(1).Execute()
+	session, err := p.SPI.NewSession(secret)
 	if nil != err {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	server, err := session.GetServer(ctx, serverData.DatacenterID, serverData.ID, 1)
+	if spi.IsNotFoundError(err) {
 		return nil, status.Error(codes.NotFound, err.Error())
-	} else if "INACTIVE" == *server.Metadata.State {
-		return nil, status.Error(codes.NotFound, fmt.Sprintf("VM %s (%s) does not exist", *server.Properties.Name, serverData.ID))
+	} else if nil != err {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+
+	err = getServerStatusError(&server, serverData.ID)
+	if nil != err {
+		// The creation workflow stops servers temporarily. Only servers of created machines are restarted.
+		if "" != machine.Status.Node && isServerRestartable(&server) {
+			err = p.restartServer(ctx, session, req, serverData.DatacenterID, serverData.ID, err)
+		}
+
+		return nil, err
+	}
+
+	return &driver.GetMachineStatusResponse{ProviderID: machine.Spec.ProviderID, NodeName: *server.Properties.Name}, nil
+}
+
+// restartServer starts a SHUTOFF server if requested by the providerSpec. The status error given is returned otherwise.
+//
+// PARAMETERS
+// ctx          context.Context                  Execution context
+// session      spi.Session                      IONOS session
+// req          *driver.GetMachineStatusRequest  The get request for VM info
+// datacenterID string                           Datacenter ID
+// serverID     string                           Server ID
+// statusErr    error                            Status error of the SHUTOFF server
+func (p *MachineProvider) restartServer(ctx context.Context, session spi.Session, req *driver.GetMachineStatusRequest, datacenterID, serverID string, statusErr error) error {
+	providerSpec, err := transcoder.DecodeProviderSpecFromMachineClass(req.MachineClass, req.Secret)
+	if nil != err {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if !providerSpec.RestartOnShutoff {
+		return statusErr
 	}
 
-	return &driver.GetMachineStatusResponse{ ProviderID: machine.Spec.ProviderID, NodeName: *server.Properties.Name }, nil
+	klog.V(2).Infof("Restarting SHUTOFF VM %s for machine %q", serverID, req.Machine.Name)
+
+	err = session.StartServer(ctx, datacenterID, serverID)
+	if nil != err {
+		return status.Error(codes.Unavailable, fmt.Sprintf("VM %s could not be restarted: %s", serverID, err.Error()))
+	}
+
+	return status.Error(codeUninitialized, fmt.Sprintf("VM %s is being restarted", serverID))
 }
 
 // ListMachines lists all the machines possibilly created by a providerSpec
@@ -399,58 +444,56 @@ func (p *MachineProvider) ListMachines(ctx context.Context, req *driver.ListMach
 	klog.V(2).Infof("List machines request has been received for %q", machineClass.Name)
 	defer klog.V(2).Infof("List machines request has been processed for %q", machineClass.Name)
 
-	client := ionosapiwrapper.GetClientForUser(string(secret.Data["user"]), string(secret.Data["password"]))
+	session, err := p.SPI.NewSession(secret)
+	if nil != err {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	servers, err := session.ListServers(ctx, providerSpec.DatacenterID, 1)
+	if nil != err {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
 
-	servers, _, err := client.ServersApi.DatacentersServersGet(ctx, providerSpec.DatacenterID).Depth(1).Execute()
+	serverLabels, err := session.ListServerLabels(ctx, providerSpec.DatacenterID, labelKeyCluster, labelKeyProviderVersion, labelKeyRole, labelKeyZone)
 	if nil != err {
 		return nil, status.Error(codes.Unavailable, err.Error())
 	}
 
-	clusterValue := hex.EncodeToString([]byte(providerSpec.Cluster))
+	availabilityZone := providerSpec.GetAvailabilityZone()
 	listOfVMs := make(map[string]string)
-	zoneValue := hex.EncodeToString([]byte(providerSpec.Zone))
 
-	for _, server := range *servers.Items {
+	for _, server := range servers {
 		if "INACTIVE" == *server.Metadata.State {
 			continue
 		}
 
-		labels, _, err := client.LabelsApi.DatacentersServersLabelsGet(ctx, providerSpec.DatacenterID, *server.Id).Depth(1).Execute()
-		if nil != err {
-			return nil, status.Error(codes.Unavailable, err.Error())
+		if "" != availabilityZone && !isServerInAvailabilityZone(server, availabilityZone) {
+			continue
 		}
 
-		labelMatches := 0
-
-		for _, label := range *labels.Items {
-			switch *label.Properties.Key {
-			case "cluster":
-				if clusterValue == *label.Properties.Value {
-					labelMatches++
-				}
-
-				break
-			case "role":
-				if "node" == *label.Properties.Value {
-					labelMatches++
-				}
-
-				break
-			case "zone":
-				if zoneValue == *label.Properties.Value {
-					labelMatches++
-				}
-
-				break
-			}
-		}
+		labels := serverLabels[*server.Id]
 
-		if 3 == labelMatches {
+		if isLabelMatching(labels, labelKeyCluster, providerSpec.Cluster) && "node" == labels[labelKeyRole] && isLabelMatching(labels, labelKeyZone, providerSpec.Zone) {
 			listOfVMs[transcoder.EncodeProviderID(providerSpec.DatacenterID, *server.Id)] = *server.Properties.Name
 		}
 	}
 
-	return &driver.ListMachinesResponse{ MachineList: listOfVMs }, nil
+	return &driver.ListMachinesResponse{MachineList: listOfVMs}, nil
+}
+
+// isServerInAvailabilityZone returns true if the server has been placed in the IONOS availability zone given.
+//
+// PARAMETERS
+// server           ionossdk.Server Server to check
+// availabilityZone string          IONOS availability zone
+func isServerInAvailabilityZone(server ionossdk.Server, availabilityZone string) bool {
+	serverAvailabilityZone := apis.AvailabilityZoneAuto
+
+	if server.HasProperties() && server.Properties.HasAvailabilityZone() {
+		serverAvailabilityZone = *server.Properties.AvailabilityZone
+	}
+
+	return availabilityZone == serverAvailabilityZone
 }
 
 // GetVolumeIDs returns a list of Volume IDs for all PV Specs for whom an provider volume was found
@@ -463,7 +506,23 @@ func (p *MachineProvider) GetVolumeIDs(ctx context.Context, req *driver.GetVolum
 	klog.V(2).Infof("GetVolumeIDs request has been received for %q", req.PVSpecs)
 	defer klog.V(2).Infof("GetVolumeIDs request has been processed successfully for %q", req.PVSpecs)
 
-	return &driver.GetVolumeIDsResponse{}, status.Error(codes.Unimplemented, "")
+	volumeIDs := []string{}
+
+	for _, pvSpec := range req.PVSpecs {
+		if !transcoder.IsIonosCSIPersistentVolumeSpec(pvSpec) {
+			continue
+		}
+
+		volumeID, err := transcoder.DecodeVolumeIDFromPVSpec(pvSpec)
+		if nil != err {
+			klog.Warningf("Skipping IONOS CSI volume handle %q: %v", pvSpec.CSI.VolumeHandle, err)
+			continue
+		}
+
+		volumeIDs = append(volumeIDs, volumeID)
+	}
+
+	return &driver.GetVolumeIDsResponse{VolumeIDs: volumeIDs}, nil
 }
 
 // GenerateMachineClassForMigration helps in migration of one kind of machineClass CR to another kind.
@@ -476,5 +535,19 @@ func (p *MachineProvider) GenerateMachineClassForMigration(ctx context.Context,
 	klog.V(2).Infof("MigrateMachineClass request has been received for %q", req.ClassSpec)
 	defer klog.V(2).Infof("MigrateMachineClass request has been processed successfully for %q", req.ClassSpec)
 
-	return &driver.GenerateMachineClassForMigrationResponse{}, status.Error(codes.Unimplemented, "")
+	if nil == req.ClassSpec || apis.IonosMachineClassKind != req.ClassSpec.Kind {
+		return nil, status.Error(codes.Internal, "Migration cannot be done for this machineClass kind")
+	}
+
+	ionosMachineClass, err := transcoder.DecodeIonosMachineClass(req.ProviderSpecificMachineClass)
+	if nil != err {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	err = transcoder.EncodeMachineClassFromIonosMachineClass(ionosMachineClass, req.MachineClass)
+	if nil != err {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &driver.GenerateMachineClassForMigrationResponse{}, nil
 }
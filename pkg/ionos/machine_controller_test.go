@@ -19,25 +19,25 @@ package ionos
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"time"
 
-	ionosapiwrapper "github.com/23technologies/ionos-api-wrapper/pkg"
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis"
 	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis/mock"
 	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/spi"
+	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/driver"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 )
-var provider *MachineProvider
 
-var _ = BeforeSuite(func() {
-	provider = &MachineProvider {
-		SPI: &spi.PluginSPIImpl{},
-	}
-})
+var provider *MachineProvider
 
 var _ = Describe("MachineController", func() {
 	var mockTestEnv mock.MockTestEnv
@@ -45,16 +45,17 @@ var _ = Describe("MachineController", func() {
 	providerSecret := &corev1.Secret{
 		Data: map[string][]byte{
 			"user":     []byte("dummy-user"),
-			"password":    []byte("dummy-password"),
+			"password": []byte("dummy-password"),
 			"userData": []byte("dummy-user-data"),
 		},
 	}
 
 	var _ = BeforeEach(func() {
 		mockTestEnv = mock.NewMockTestEnv()
+		provider = &MachineProvider{SPI: mock.NewSessionProvider(spi.NewClientSession(mockTestEnv.Client))}
 
-		ionosapiwrapper.SetClientForUser("dummy-user", mockTestEnv.Client)
 		mock.SetupImagesEndpointOnMux(mockTestEnv.Mux)
+		mock.SetupLabelsEndpointOnMux(mockTestEnv.Mux)
 		mock.SetupServersEndpointOnMux(mockTestEnv.Mux)
 		mock.SetupTestServerEndpointOnMux(mockTestEnv.Mux)
 		mock.SetupTestVolumeEndpointOnMux(mockTestEnv.Mux)
@@ -63,7 +64,6 @@ var _ = Describe("MachineController", func() {
 
 	var _ = AfterEach(func() {
 		mockTestEnv.Teardown()
-		ionosapiwrapper.SetClientForUser("dummy-user", nil)
 	})
 
 	Describe("#CreateMachine", func() {
@@ -126,21 +126,21 @@ var _ = Describe("MachineController", func() {
 				},
 				expect: expect{
 					errToHaveOccurred: true,
-					errStatus: codes.InvalidArgument,
+					errStatus:         codes.InvalidArgument,
 				},
 			}),
 			Entry("contains an invalid provider ID", &data{
 				setup: setup{},
 				action: action{
 					&driver.CreateMachineRequest{
-						Machine:      mock.ManipulateMachine(mock.NewMachine(mock.TestServerID), map[string]interface{}{ "Spec.ProviderID": "test:///invalid" }),
+						Machine:      mock.ManipulateMachine(mock.NewMachine(mock.TestServerID), map[string]interface{}{"Spec.ProviderID": "test:///invalid"}),
 						MachineClass: mock.NewMachineClass(),
 						Secret:       providerSecret,
 					},
 				},
 				expect: expect{
 					errToHaveOccurred: true,
-					errStatus: codes.InvalidArgument,
+					errStatus:         codes.InvalidArgument,
 				},
 			}),
 			Entry("contains an invalid machine class", &data{
@@ -154,7 +154,7 @@ var _ = Describe("MachineController", func() {
 				},
 				expect: expect{
 					errToHaveOccurred: true,
-					errStatus: codes.InvalidArgument,
+					errStatus:         codes.InvalidArgument,
 				},
 			}),
 		)
@@ -220,21 +220,21 @@ var _ = Describe("MachineController", func() {
 				},
 				expect: expect{
 					errToHaveOccurred: true,
-					errStatus: codes.InvalidArgument,
+					errStatus:         codes.InvalidArgument,
 				},
 			}),
 			Entry("contains an invalid provider ID", &data{
 				setup: setup{},
 				action: action{
 					&driver.DeleteMachineRequest{
-						Machine:      mock.ManipulateMachine(mock.NewMachine(mock.TestServerID), map[string]interface{}{ "Spec.ProviderID": "test:///invalid" }),
+						Machine:      mock.ManipulateMachine(mock.NewMachine(mock.TestServerID), map[string]interface{}{"Spec.ProviderID": "test:///invalid"}),
 						MachineClass: mock.NewMachineClass(),
 						Secret:       providerSecret,
 					},
 				},
 				expect: expect{
 					errToHaveOccurred: true,
-					errStatus: codes.InvalidArgument,
+					errStatus:         codes.InvalidArgument,
 				},
 			}),
 		)
@@ -300,21 +300,21 @@ var _ = Describe("MachineController", func() {
 				},
 				expect: expect{
 					errToHaveOccurred: true,
-					errStatus: codes.NotFound,
+					errStatus:         codes.NotFound,
 				},
 			}),
 			Entry("contains an invalid provider ID", &data{
 				setup: setup{},
 				action: action{
 					&driver.GetMachineStatusRequest{
-						Machine:      mock.ManipulateMachine(mock.NewMachine(mock.TestServerID), map[string]interface{}{ "Spec.ProviderID": "test:///invalid" }),
+						Machine:      mock.ManipulateMachine(mock.NewMachine(mock.TestServerID), map[string]interface{}{"Spec.ProviderID": "test:///invalid"}),
 						MachineClass: mock.NewMachineClass(),
 						Secret:       providerSecret,
 					},
 				},
 				expect: expect{
 					errToHaveOccurred: true,
-					errStatus: codes.InvalidArgument,
+					errStatus:         codes.InvalidArgument,
 				},
 			}),
 		)
@@ -377,51 +377,618 @@ var _ = Describe("MachineController", func() {
 				},
 				expect: expect{
 					errToHaveOccurred: true,
-					errStatus: codes.InvalidArgument,
+					errStatus:         codes.InvalidArgument,
 				},
 			}),
 		)
 	})
 
 	Describe("#GetVolumeIDs", func() {
-		It("is not implemented", func() {
-			ctx := context.Background()
+		type action struct {
+			volumeIDsRequest *driver.GetVolumeIDsRequest
+		}
+
+		type expect struct {
+			volumeIDs []string
+		}
 
-			req := &driver.GetVolumeIDsRequest{
-				PVSpecs: []*corev1.PersistentVolumeSpec{
-					{
-						Capacity:                      map[corev1.ResourceName]resource.Quantity{},
-						PersistentVolumeSource:        corev1.PersistentVolumeSource{},
-						AccessModes:                   []corev1.PersistentVolumeAccessMode{},
-						ClaimRef:                      &corev1.ObjectReference{},
-						PersistentVolumeReclaimPolicy: "",
-						StorageClassName:              "",
-						MountOptions:                  []string{},
-						NodeAffinity:                  &corev1.VolumeNodeAffinity{},
+		type data struct {
+			action action
+			expect expect
+		}
+
+		newCSIPVSpec := func(driverName, volumeHandle string) *corev1.PersistentVolumeSpec {
+			return &corev1.PersistentVolumeSpec{
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					CSI: &corev1.CSIPersistentVolumeSource{
+						Driver:       driverName,
+						VolumeHandle: volumeHandle,
 					},
 				},
 			}
+		}
 
-			_, err := provider.GetVolumeIDs(ctx, req)
-			Expect(err).To(HaveOccurred())
+		DescribeTable("##table",
+			func(data *data) {
+				ctx := context.Background()
+				response, err := provider.GetVolumeIDs(ctx, data.action.volumeIDsRequest)
 
-			errStatus, ok := err.(*status.Status)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(response.VolumeIDs).To(Equal(data.expect.volumeIDs))
+			},
+
+			Entry("contains a PV spec without volume source", &data{
+				action: action{
+					&driver.GetVolumeIDsRequest{
+						PVSpecs: []*corev1.PersistentVolumeSpec{
+							{
+								Capacity:                      map[corev1.ResourceName]resource.Quantity{},
+								PersistentVolumeSource:        corev1.PersistentVolumeSource{},
+								AccessModes:                   []corev1.PersistentVolumeAccessMode{},
+								ClaimRef:                      &corev1.ObjectReference{},
+								PersistentVolumeReclaimPolicy: "",
+								StorageClassName:              "",
+								MountOptions:                  []string{},
+								NodeAffinity:                  &corev1.VolumeNodeAffinity{},
+							},
+						},
+					},
+				},
+				expect: expect{
+					volumeIDs: []string{},
+				},
+			}),
+			Entry("contains IONOS CSI PV specs", &data{
+				action: action{
+					&driver.GetVolumeIDsRequest{
+						PVSpecs: []*corev1.PersistentVolumeSpec{
+							newCSIPVSpec("cloud.ionos.com", mock.TestServerVolumeID),
+							newCSIPVSpec("cloud.ionos.com", fmt.Sprintf("%s/%s", mock.TestProviderSpecDatacenterID, mock.TestServerVolumeID)),
+							newCSIPVSpec("cloud.ionos.com", fmt.Sprintf("datacenters/%s/volumes/%s", mock.TestProviderSpecDatacenterID, mock.TestServerVolumeID)),
+						},
+					},
+				},
+				expect: expect{
+					volumeIDs: []string{mock.TestServerVolumeID, mock.TestServerVolumeID, mock.TestServerVolumeID},
+				},
+			}),
+			Entry("contains foreign and malformed PV specs", &data{
+				action: action{
+					&driver.GetVolumeIDsRequest{
+						PVSpecs: []*corev1.PersistentVolumeSpec{
+							newCSIPVSpec("ebs.csi.aws.com", "vol-0123456789abcdef0"),
+							newCSIPVSpec("cloud.ionos.com", "invalid"),
+							{
+								PersistentVolumeSource: corev1.PersistentVolumeSource{
+									HostPath: &corev1.HostPathVolumeSource{Path: "/tmp"},
+								},
+							},
+						},
+					},
+				},
+				expect: expect{
+					volumeIDs: []string{},
+				},
+			}),
+		)
+	})
+
+	Describe("#GenerateMachineClassForMigration", func() {
+		type action struct {
+			migrationRequest *driver.GenerateMachineClassForMigrationRequest
+		}
+
+		type expect struct {
+			errToHaveOccurred bool
+			errStatus         codes.Code
+		}
+
+		type data struct {
+			action action
+			expect expect
+		}
+
+		DescribeTable("##table",
+			func(data *data) {
+				ctx := context.Background()
+				_, err := provider.GenerateMachineClassForMigration(ctx, data.action.migrationRequest)
+
+				if data.expect.errToHaveOccurred {
+					Expect(err).To(HaveOccurred())
+
+					errStatus, ok := err.(*status.Status)
+					Expect(ok).To(BeTrue())
+					Expect(errStatus.Code()).To(Equal(data.expect.errStatus))
+				} else {
+					Expect(err).NotTo(HaveOccurred())
+					Expect(data.action.migrationRequest.MachineClass.ProviderSpec.Raw).NotTo(BeEmpty())
+				}
+			},
+
+			Entry("is correctly executed", &data{
+				action: action{
+					&driver.GenerateMachineClassForMigrationRequest{
+						ProviderSpecificMachineClass: mock.NewIonosMachineClass(),
+						MachineClass:                 &v1alpha1.MachineClass{},
+						ClassSpec:                    &v1alpha1.ClassSpec{Kind: apis.IonosMachineClassKind, Name: mock.TestMachineClassName},
+					},
+				},
+				expect: expect{
+					errToHaveOccurred: false,
+				},
+			}),
+			Entry("contains an unsupported machine class kind", &data{
+				action: action{
+					&driver.GenerateMachineClassForMigrationRequest{
+						ProviderSpecificMachineClass: mock.NewIonosMachineClass(),
+						MachineClass:                 &v1alpha1.MachineClass{},
+						ClassSpec:                    &v1alpha1.ClassSpec{Kind: "AWSMachineClass", Name: mock.TestMachineClassName},
+					},
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errStatus:         codes.Internal,
+				},
+			}),
+			Entry("contains no provider specific machine class", &data{
+				action: action{
+					&driver.GenerateMachineClassForMigrationRequest{
+						MachineClass: &v1alpha1.MachineClass{},
+						ClassSpec:    &v1alpha1.ClassSpec{Kind: apis.IonosMachineClassKind, Name: mock.TestMachineClassName},
+					},
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errStatus:         codes.InvalidArgument,
+				},
+			}),
+		)
+	})
+})
+
+var _ = Describe("MachineControllerWithState", func() {
+	var mockTestEnv mock.MockTestEnv
+
+	machineName := fmt.Sprintf(mock.TestServerNameTemplate, mock.TestServerID)
+
+	providerSecret := &corev1.Secret{
+		Data: map[string][]byte{
+			"user":     []byte("dummy-user"),
+			"password": []byte("dummy-password"),
+			"userData": []byte("dummy-user-data"),
+		},
+	}
+
+	var _ = BeforeEach(func() {
+		mockTestEnv = mock.NewMockTestEnv()
+		provider = &MachineProvider{SPI: mock.NewSessionProvider(spi.NewClientSession(mockTestEnv.Client))}
+
+		ipBlockReleasePollInterval = 10 * time.Millisecond
+		ipBlockReleaseTimeout = 100 * time.Millisecond
+	})
+
+	var _ = AfterEach(func() {
+		mockTestEnv.Teardown()
+
+		ipBlockReleasePollInterval = 5 * time.Second
+		ipBlockReleaseTimeout = 2 * time.Minute
+	})
+
+	Describe("#DeleteMachine", func() {
+		type setup struct {
+			dataVolumes []mock.MachineStateDataVolume
+			ipBlock     *mock.IPBlockState
+		}
+
+		type action struct {
+		}
+
+		type expect struct {
+			volumeDeleteCount    int
+			remainingDataVolumes []string
+			errToHaveOccurred    bool
+			errCode              codes.Code
+		}
+
+		type data struct {
+			setup  setup
+			action action
+			expect expect
+		}
+
+		DescribeTable("##table",
+			func(data *data) {
+				ctx := context.Background()
+
+				state := mock.NewMachineState(machineName)
+				state.VolumeExists = true
+				state.ServerExists = true
+				state.ServerVMState = "RUNNING"
+
+				for _, dataVolume := range data.setup.dataVolumes {
+					state.AddDataVolume(dataVolume.Name, dataVolume.Labels, true)
+				}
+
+				machineClass := mock.NewMachineClass()
+
+				if nil != data.setup.ipBlock {
+					state.IPBlock = data.setup.ipBlock
+					state.IPBlock.AddConsumer("192.0.2.1", mock.TestServerID)
+
+					providerSpec := mock.NewProviderSpec()
+					providerSpec.FloatingPoolID = mock.TestIPBlockID
+
+					providerSpecJSON, err := json.Marshal(providerSpec)
+					Expect(err).NotTo(HaveOccurred())
+
+					machineClass = mock.NewMachineClassWithProviderSpec(providerSpecJSON)
+				}
+
+				mock.SetupMachineStateEndpointsOnMux(mockTestEnv.Mux, state)
+
+				_, err := provider.DeleteMachine(ctx, &driver.DeleteMachineRequest{
+					Machine:      mock.NewMachine(mock.TestServerID),
+					MachineClass: machineClass,
+					Secret:       providerSecret,
+				})
+
+				if data.expect.errToHaveOccurred {
+					Expect(err).To(HaveOccurred())
+					Expect(err.(*status.Status).Code()).To(Equal(data.expect.errCode))
+
+					return
+				}
+
+				Expect(err).NotTo(HaveOccurred())
+
+				if nil != data.setup.ipBlock {
+					Expect(data.setup.ipBlock.GetConsumerIPs(mock.TestServerID)).To(BeEmpty())
+				}
+
+				Expect(state.ServerExists).To(BeFalse())
+				Expect(state.VolumeExists).To(BeFalse())
+				Expect(state.VolumeDeleteCount).To(Equal(data.expect.volumeDeleteCount))
+				Expect(state.DataVolumes).To(HaveLen(len(data.expect.remainingDataVolumes)))
+
+				for _, name := range data.expect.remainingDataVolumes {
+					Expect(state.GetDataVolume(name)).NotTo(BeNil())
+				}
+			},
+
+			Entry("deletes the root volume", &data{
+				expect: expect{
+					volumeDeleteCount: 1,
+				},
+			}),
+			Entry("deletes data volumes marked for deletion on termination", &data{
+				setup: setup{
+					dataVolumes: []mock.MachineStateDataVolume{
+						{Name: "data", Labels: map[string]string{labelKeyDeleteOnTermination: "true"}},
+						{Name: "unlabeled", Labels: map[string]string{}},
+					},
+				},
+				expect: expect{
+					volumeDeleteCount: 3,
+				},
+			}),
+			Entry("retains data volumes not marked for deletion on termination", &data{
+				setup: setup{
+					dataVolumes: []mock.MachineStateDataVolume{
+						{Name: "data", Labels: map[string]string{labelKeyDeleteOnTermination: "true"}},
+						{Name: "logs", Labels: map[string]string{labelKeyDeleteOnTermination: "false"}},
+					},
+				},
+				expect: expect{
+					volumeDeleteCount:    2,
+					remainingDataVolumes: []string{"logs"},
+				},
+			}),
+			Entry("confirms floating IPs have been released", &data{
+				setup: setup{
+					ipBlock: mock.NewIPBlockState("192.0.2.1", "192.0.2.2"),
+				},
+				expect: expect{
+					volumeDeleteCount: 1,
+				},
+			}),
+			Entry("fails if floating IPs have not been released", &data{
+				setup: setup{
+					ipBlock: &mock.IPBlockState{IPs: []string{"192.0.2.1"}, RetainConsumers: true},
+				},
+				expect: expect{
+					errToHaveOccurred: true,
+					errCode:           codes.Unavailable,
+				},
+			}),
+		)
+	})
+
+	Describe("#ListMachines", func() {
+		type setup struct {
+			serverAvailabilityZone string
+		}
+
+		type action struct {
+			availabilityZones map[string]string
+		}
+
+		type expect struct {
+			machineList map[string]string
+		}
+
+		type data struct {
+			setup  setup
+			action action
+			expect expect
+		}
+
+		DescribeTable("##table",
+			func(data *data) {
+				ctx := context.Background()
+
+				state := mock.NewMachineState(machineName)
+				state.VolumeExists = true
+				state.ServerExists = true
+				state.ServerVMState = "RUNNING"
+				state.ServerLabels = mock.GetTestServerLabels(machineName)
+				state.ServerAvailabilityZone = data.setup.serverAvailabilityZone
+
+				mock.SetupMachineStateEndpointsOnMux(mockTestEnv.Mux, state)
+
+				providerSpec := mock.NewProviderSpec()
+				providerSpec.AvailabilityZones = data.action.availabilityZones
+
+				providerSpecJSON, err := json.Marshal(providerSpec)
+				Expect(err).NotTo(HaveOccurred())
+
+				response, err := provider.ListMachines(ctx, &driver.ListMachinesRequest{
+					MachineClass: mock.NewMachineClassWithProviderSpec(providerSpecJSON),
+					Secret:       providerSecret,
+				})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(response.MachineList).To(Equal(data.expect.machineList))
+			},
+
+			Entry("lists the server without availability zone mapping", &data{
+				setup: setup{
+					serverAvailabilityZone: apis.AvailabilityZone2,
+				},
+				expect: expect{
+					machineList: map[string]string{
+						fmt.Sprintf("ionos:///%s/%s", mock.TestProviderSpecDatacenterID, mock.TestServerID): machineName,
+					},
+				},
+			}),
+			Entry("lists the server placed in the availability zone mapped", &data{
+				setup: setup{
+					serverAvailabilityZone: apis.AvailabilityZone2,
+				},
+				action: action{
+					availabilityZones: map[string]string{mock.TestProviderSpecZone: apis.AvailabilityZone2},
+				},
+				expect: expect{
+					machineList: map[string]string{
+						fmt.Sprintf("ionos:///%s/%s", mock.TestProviderSpecDatacenterID, mock.TestServerID): machineName,
+					},
+				},
+			}),
+			Entry("ignores the server placed in another availability zone", &data{
+				setup: setup{
+					serverAvailabilityZone: apis.AvailabilityZone1,
+				},
+				action: action{
+					availabilityZones: map[string]string{mock.TestProviderSpecZone: apis.AvailabilityZone2},
+				},
+				expect: expect{
+					machineList: map[string]string{},
+				},
+			}),
+		)
+	})
+})
+
+var _ = Describe("MachineControllerWithFakeSession", func() {
+	var session *mock.FakeSession
+
+	providerSecret := &corev1.Secret{
+		Data: map[string][]byte{
+			"user":     []byte("dummy-user"),
+			"password": []byte("dummy-password"),
+			"userData": []byte("dummy-user-data"),
+		},
+	}
+
+	newServer := func(ctx context.Context, name string, labels map[string]string) string {
+		server, err := session.CreateServer(ctx, mock.TestProviderSpecDatacenterID, ionossdk.Server{
+			Properties: &ionossdk.ServerProperties{Name: ionossdk.PtrString(name)},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		for key, value := range labels {
+			Expect(session.AddServerLabel(ctx, mock.TestProviderSpecDatacenterID, *server.Id, key, value)).To(Succeed())
+		}
+
+		return *server.Id
+	}
+
+	var _ = BeforeEach(func() {
+		session = mock.NewFakeSession()
+		provider = &MachineProvider{SPI: mock.NewSessionProvider(session)}
+	})
+
+	Describe("#DeleteMachine", func() {
+		It("deletes the server together with all volumes not retained", func() {
+			ctx := context.Background()
+			serverID := newServer(ctx, "machine-fake", mock.GetTestServerLabels("machine-fake"))
+
+			volumeIDs := []string{}
+
+			for _, deleteOnTermination := range []string{"true", "false"} {
+				volume, err := session.CreateVolume(ctx, mock.TestProviderSpecDatacenterID, ionossdk.Volume{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(session.AddVolumeLabel(ctx, mock.TestProviderSpecDatacenterID, *volume.Id, labelKeyDeleteOnTermination, deleteOnTermination)).To(Succeed())
+				Expect(session.AttachVolume(ctx, mock.TestProviderSpecDatacenterID, serverID, *volume.Id)).To(Succeed())
+
+				volumeIDs = append(volumeIDs, *volume.Id)
+			}
+
+			_, err := provider.DeleteMachine(ctx, &driver.DeleteMachineRequest{
+				Machine:      mock.NewMachine(serverID),
+				MachineClass: mock.NewMachineClass(),
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, ok := session.GetServerByID(mock.TestProviderSpecDatacenterID, serverID)
+			Expect(ok).To(BeFalse())
+			_, ok = session.GetVolumeByID(mock.TestProviderSpecDatacenterID, volumeIDs[0])
+			Expect(ok).To(BeFalse())
+			_, ok = session.GetVolumeByID(mock.TestProviderSpecDatacenterID, volumeIDs[1])
 			Expect(ok).To(BeTrue())
-			Expect(errStatus.Code()).To(Equal(codes.Unimplemented))
+		})
+
+		It("deletes a server without volumes", func() {
+			ctx := context.Background()
+
+			server, err := session.CreateServer(ctx, mock.TestProviderSpecDatacenterID, ionossdk.Server{
+				Properties: &ionossdk.ServerProperties{Name: ionossdk.PtrString("machine-fake")},
+				Entities:   &ionossdk.ServerEntities{Volumes: &ionossdk.AttachedVolumes{}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = provider.DeleteMachine(ctx, &driver.DeleteMachineRequest{
+				Machine:      mock.NewMachine(*server.Id),
+				MachineClass: mock.NewMachineClass(),
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, ok := session.GetServerByID(mock.TestProviderSpecDatacenterID, *server.Id)
+			Expect(ok).To(BeFalse())
+		})
+
+		It("succeeds if the server does not exist", func() {
+			_, err := provider.DeleteMachine(context.Background(), &driver.DeleteMachineRequest{
+				Machine:      mock.NewMachine(mock.TestServerID),
+				MachineClass: mock.NewMachineClass(),
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
 		})
 	})
 
-	Describe("#GenerateMachineClassForMigration", func() {
-		It("is not implemented", func() {
+	Describe("#GetMachineStatus", func() {
+		It("returns the node name of an existing server", func() {
 			ctx := context.Background()
-			req := &driver.GenerateMachineClassForMigrationRequest{}
+			serverID := newServer(ctx, "machine-fake", nil)
+
+			response, err := provider.GetMachineStatus(ctx, &driver.GetMachineStatusRequest{
+				Machine:      mock.NewMachine(serverID),
+				MachineClass: mock.NewMachineClass(),
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.NodeName).To(Equal("machine-fake"))
+		})
 
-			_, err := provider.GenerateMachineClassForMigration(ctx, req)
+		It("fails with NotFound if the server does not exist", func() {
+			_, err := provider.GetMachineStatus(context.Background(), &driver.GetMachineStatusRequest{
+				Machine:      mock.NewMachine(mock.TestServerID),
+				MachineClass: mock.NewMachineClass(),
+				Secret:       providerSecret,
+			})
 			Expect(err).To(HaveOccurred())
 
 			errStatus, ok := err.(*status.Status)
 			Expect(ok).To(BeTrue())
-			Expect(errStatus.Code()).To(Equal(codes.Unimplemented))
+			Expect(errStatus.Code()).To(Equal(codes.NotFound))
+		})
+
+		type setup struct {
+			state            string
+			vmState          string
+			restartOnShutoff bool
+			machineCreated   bool
+		}
+
+		type expect struct {
+			errStatus codes.Code
+			vmState   string
+		}
+
+		type data struct {
+			setup  setup
+			expect expect
+		}
+
+		DescribeTable("##table",
+			func(data *data) {
+				ctx := context.Background()
+				serverID := newServer(ctx, "machine-fake", nil)
+				Expect(session.SetServerState(mock.TestProviderSpecDatacenterID, serverID, data.setup.state, data.setup.vmState)).To(Succeed())
+
+				machine := mock.NewMachine(serverID)
+				if !data.setup.machineCreated {
+					machine.Status.Node = ""
+				}
+
+				providerSpec := mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{"RestartOnShutoff": data.setup.restartOnShutoff})
+				providerSpecJSON, _ := json.Marshal(providerSpec)
+
+				_, err := provider.GetMachineStatus(ctx, &driver.GetMachineStatusRequest{
+					Machine:      machine,
+					MachineClass: mock.NewMachineClassWithProviderSpec(providerSpecJSON),
+					Secret:       providerSecret,
+				})
+				Expect(err).To(HaveOccurred())
+
+				errStatus, ok := err.(*status.Status)
+				Expect(ok).To(BeTrue())
+				Expect(errStatus.Code()).To(Equal(data.expect.errStatus))
+
+				server, _ := session.GetServerByID(mock.TestProviderSpecDatacenterID, serverID)
+				Expect(*server.Properties.VmState).To(Equal(data.expect.vmState))
+			},
+
+			Entry("reports a BUSY server being created as uninitialized", &data{
+				setup:  setup{state: "BUSY", vmState: "SHUTOFF"},
+				expect: expect{errStatus: codeUninitialized, vmState: "SHUTOFF"},
+			}),
+			Entry("reports a CRASHED server as unavailable", &data{
+				setup:  setup{state: "AVAILABLE", vmState: "CRASHED", machineCreated: true, restartOnShutoff: true},
+				expect: expect{errStatus: codes.Unavailable, vmState: "CRASHED"},
+			}),
+			Entry("reports a SHUTOFF server as unavailable if no restart is requested", &data{
+				setup:  setup{state: "AVAILABLE", vmState: "SHUTOFF", machineCreated: true},
+				expect: expect{errStatus: codes.Unavailable, vmState: "SHUTOFF"},
+			}),
+			Entry("does not restart a SHUTOFF server of a machine being created", &data{
+				setup:  setup{state: "AVAILABLE", vmState: "SHUTOFF", restartOnShutoff: true},
+				expect: expect{errStatus: codes.Unavailable, vmState: "SHUTOFF"},
+			}),
+			Entry("restarts a SHUTOFF server if requested", &data{
+				setup:  setup{state: "AVAILABLE", vmState: "SHUTOFF", machineCreated: true, restartOnShutoff: true},
+				expect: expect{errStatus: codeUninitialized, vmState: "RUNNING"},
+			}),
+		)
+	})
+
+	Describe("#ListMachines", func() {
+		It("lists only servers labeled for the cluster and zone", func() {
+			ctx := context.Background()
+			serverID := newServer(ctx, "machine-fake", mock.GetTestServerLabels("machine-fake"))
+			_ = newServer(ctx, "machine-foreign", map[string]string{"cluster": "foreign", "role": "node"})
+			_ = newServer(ctx, "machine-unlabeled", nil)
+
+			response, err := provider.ListMachines(ctx, &driver.ListMachinesRequest{
+				MachineClass: mock.NewMachineClass(),
+				Secret:       providerSecret,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.MachineList).To(Equal(map[string]string{
+				fmt.Sprintf("ionos:///%s/%s", mock.TestProviderSpecDatacenterID, serverID): "machine-fake",
+			}))
 		})
 	})
 })
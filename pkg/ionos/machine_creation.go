@@ -0,0 +1,886 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ionos contains the IONOS provider specific implementations to manage machines
+package ionos
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis"
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/spi"
+	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
+	"k8s.io/component-base/version"
+	"k8s.io/klog/v2"
+)
+
+// createMachineWorkflow holds the state of a resumable machine creation
+type createMachineWorkflow struct {
+	session      spi.Session
+	machine      *v1alpha1.Machine
+	providerSpec *apis.ProviderSpec
+	image        *ionossdk.Image
+	userData     []byte
+	sshKeys      []string
+	resultData   *CreateMachineMethodData
+	labelManager *labelManager
+
+	volumeLabels      map[string]string
+	dataVolumeIDs     map[string]string
+	dataVolumeLabels  map[string]map[string]string
+	attachedVolumeIDs map[string]bool
+	serverLabels      map[string]string
+	serverLANs        map[int32]bool
+	serverNicIDs      map[int32]string
+	serverNicRules    map[int32]map[string]bool
+	serverVMState     string
+}
+
+// createMachineWorkflowSteps contains the step implementations indexed by CreateMachineStep
+var createMachineWorkflowSteps = []func(*createMachineWorkflow, context.Context) error{
+	(*createMachineWorkflow).createVolume,
+	(*createMachineWorkflow).labelVolume,
+	(*createMachineWorkflow).createDataVolumes,
+	(*createMachineWorkflow).createServer,
+	(*createMachineWorkflow).attachDataVolumes,
+	(*createMachineWorkflow).stopServer,
+	(*createMachineWorkflow).labelServer,
+	(*createMachineWorkflow).attachNICs,
+	(*createMachineWorkflow).startServer,
+}
+
+// newCreateMachineWorkflow returns a new workflow for the machine given.
+//
+// PARAMETERS
+// session      spi.Session               IONOS session
+// machine      *v1alpha1.Machine         Machine to create
+// providerSpec *apis.ProviderSpec        Provider specification of the machine
+// image        *ionossdk.Image           Image to boot from
+// userData     []byte                    User data to provide
+// sshKeys      []string                  SSH public keys to authorize
+// resultData   *CreateMachineMethodData  Method data to store created resource IDs in
+func newCreateMachineWorkflow(session spi.Session, machine *v1alpha1.Machine, providerSpec *apis.ProviderSpec, image *ionossdk.Image, userData []byte, sshKeys []string, resultData *CreateMachineMethodData) *createMachineWorkflow {
+	return &createMachineWorkflow{
+		session:           session,
+		machine:           machine,
+		providerSpec:      providerSpec,
+		image:             image,
+		userData:          userData,
+		sshKeys:           sshKeys,
+		resultData:        resultData,
+		labelManager:      newLabelManager(session, providerSpec.DatacenterID),
+		volumeLabels:      map[string]string{},
+		dataVolumeIDs:     map[string]string{},
+		dataVolumeLabels:  map[string]map[string]string{},
+		attachedVolumeIDs: map[string]bool{},
+		serverLabels:      map[string]string{},
+		serverLANs:        map[int32]bool{},
+		serverNicIDs:      map[int32]string{},
+		serverNicRules:    map[int32]map[string]bool{},
+	}
+}
+
+// run executes all steps beginning with the one given. Each step is limited to the remaining time of the context
+// given minus the time reserved for the steps following it.
+//
+// PARAMETERS
+// ctx  context.Context   Execution context
+// step CreateMachineStep Step to begin with
+func (w *createMachineWorkflow) run(ctx context.Context, step CreateMachineStep) error {
+	for ; step < CreateMachineStepCompleted; step++ {
+		stepCtx, cancel, err := withStepBudget(ctx, int(CreateMachineStepCompleted-step-1))
+		if nil != err {
+			return status.Error(codes.DeadlineExceeded, fmt.Sprintf("No time left for machine creation step %s of %q", step, w.machine.Name))
+		}
+
+		klog.V(3).Infof("Executing machine creation step %s for %q", step, w.machine.Name)
+
+		err = createMachineWorkflowSteps[step](w, stepCtx)
+		deadlineExceeded := isDeadlineExceeded(stepCtx)
+		cancel()
+
+		if nil != err {
+			if deadlineExceeded {
+				return status.Error(codes.DeadlineExceeded, fmt.Sprintf("Machine creation step %s of %q exceeded its time budget: %v", step, w.machine.Name, err))
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// discover looks up resources of a previous, incomplete creation attempt and returns the step to resume with.
+//
+// PARAMETERS
+// ctx context.Context Execution context
+func (w *createMachineWorkflow) discover(ctx context.Context) (CreateMachineStep, error) {
+	datacenterID := w.providerSpec.DatacenterID
+	volumeName := w.getRootVolumeName()
+	dataVolumeNames := map[string]string{}
+
+	for _, dataVolume := range w.providerSpec.DataVolumes {
+		dataVolumeNames[w.getDataVolumeName(dataVolume)] = dataVolume.Name
+	}
+
+	volumes, err := w.session.ListVolumes(ctx, datacenterID, 1)
+	if nil != err {
+		return CreateMachineStepCreateVolume, err
+	}
+
+	for _, volume := range volumes {
+		if !volume.HasProperties() || !volume.Properties.HasName() {
+			continue
+		}
+
+		dataVolumeName, isDataVolume := dataVolumeNames[*volume.Properties.Name]
+
+		if isDataVolume {
+			if _, ok := w.dataVolumeIDs[dataVolumeName]; ok {
+				continue
+			}
+		} else if volumeName != *volume.Properties.Name || "" != w.resultData.VolumeID {
+			continue
+		}
+
+		labels, err := w.session.GetVolumeLabels(ctx, w.providerSpec.DatacenterID, *volume.Id)
+		if nil != err {
+			return CreateMachineStepCreateVolume, err
+		}
+
+		if _, ok := labels[labelKeyCluster]; ok && !isLabelMatching(labels, labelKeyCluster, w.providerSpec.Cluster) {
+			continue
+		}
+
+		if isDataVolume {
+			w.dataVolumeIDs[dataVolumeName] = *volume.Id
+			w.dataVolumeLabels[*volume.Id] = labels
+			w.resultData.DataVolumeIDs = append(w.resultData.DataVolumeIDs, *volume.Id)
+		} else {
+			w.resultData.VolumeID = *volume.Id
+			w.volumeLabels = labels
+		}
+	}
+
+	servers, err := w.session.ListServers(ctx, datacenterID, 3)
+	if nil != err {
+		return CreateMachineStepCreateVolume, err
+	}
+
+	for _, server := range servers {
+		if !server.HasProperties() || !server.Properties.HasName() || w.machine.Name != *server.Properties.Name {
+			continue
+		}
+
+		bootVolumeID := ""
+
+		if server.Properties.HasBootVolume() && server.Properties.BootVolume.HasId() {
+			bootVolumeID = *server.Properties.BootVolume.Id
+		}
+
+		labels, err := w.session.GetServerLabels(ctx, w.providerSpec.DatacenterID, *server.Id)
+		if nil != err {
+			return CreateMachineStepCreateVolume, err
+		}
+
+		if _, ok := labels[labelKeyCluster]; ok {
+			if !isLabelMatching(labels, labelKeyCluster, w.providerSpec.Cluster) {
+				continue
+			}
+		} else if "" == bootVolumeID || w.resultData.VolumeID != bootVolumeID {
+			continue
+		}
+
+		err = w.adoptServer(ctx, server, labels)
+		if nil != err {
+			return CreateMachineStepCreateVolume, err
+		}
+
+		break
+	}
+
+	return w.getResumeStep(), nil
+}
+
+// adoptServer takes over the server given found by discover.
+//
+// PARAMETERS
+// ctx    context.Context   Execution context
+// server ionossdk.Server   Server found
+// labels map[string]string Labels of the server
+func (w *createMachineWorkflow) adoptServer(ctx context.Context, server ionossdk.Server, labels map[string]string) error {
+	w.resultData.ServerID = *server.Id
+	w.serverLabels = labels
+
+	if "" == w.resultData.VolumeID && server.Properties.HasBootVolume() && server.Properties.BootVolume.HasId() {
+		volumeLabels, err := w.session.GetVolumeLabels(ctx, w.providerSpec.DatacenterID, *server.Properties.BootVolume.Id)
+		if nil != err {
+			return err
+		}
+
+		w.resultData.VolumeID = *server.Properties.BootVolume.Id
+		w.volumeLabels = volumeLabels
+	}
+
+	if server.HasEntities() && server.Entities.HasVolumes() && server.Entities.Volumes.HasItems() {
+		for _, volume := range *server.Entities.Volumes.Items {
+			w.attachedVolumeIDs[*volume.Id] = true
+		}
+	}
+
+	if server.HasEntities() && server.Entities.HasNics() && server.Entities.Nics.HasItems() {
+		for _, nic := range *server.Entities.Nics.Items {
+			if nic.HasProperties() && nic.Properties.HasLan() {
+				w.serverLANs[*nic.Properties.Lan] = true
+				w.serverNicIDs[*nic.Properties.Lan] = *nic.Id
+			}
+		}
+	}
+
+	for _, networkInterface := range w.providerSpec.GetNetworkInterfaces() {
+		lanID, _ := strconv.Atoi(networkInterface.LANID)
+		nicID, ok := w.serverNicIDs[int32(lanID)]
+
+		if !ok || 0 == len(networkInterface.FirewallRules) {
+			continue
+		}
+
+		ruleNames, err := getFirewallRuleNames(ctx, w.session, w.providerSpec.DatacenterID, w.resultData.ServerID, nicID)
+		if nil != err {
+			return err
+		}
+
+		w.serverNicRules[int32(lanID)] = ruleNames
+	}
+
+	if server.HasMetadata() && server.Metadata.HasState() && "BUSY" == *server.Metadata.State {
+		result, err := w.session.WaitForServer(ctx, w.providerSpec.DatacenterID, w.resultData.ServerID)
+		if nil != err {
+			return err
+		}
+
+		server = result
+	}
+
+	if server.Properties.HasVmState() {
+		w.serverVMState = *server.Properties.VmState
+	}
+
+	return nil
+}
+
+// getResumeStep returns the first step whose result is not yet present.
+func (w *createMachineWorkflow) getResumeStep() CreateMachineStep {
+	switch {
+	case "" == w.resultData.VolumeID:
+		return CreateMachineStepCreateVolume
+	case !hasAllLabels(w.volumeLabels, w.getVolumeLabels()):
+		return CreateMachineStepLabelVolume
+	case !w.hasAllDataVolumes():
+		return CreateMachineStepCreateDataVolumes
+	case "" == w.resultData.ServerID:
+		return CreateMachineStepCreateServer
+	case !w.hasAllDataVolumesAttached():
+		return CreateMachineStepAttachDataVolumes
+	case !w.hasAllNICs():
+		return CreateMachineStepStopServer
+	case !w.hasAllServerLabels():
+		return CreateMachineStepLabelServer
+	case "RUNNING" != w.serverVMState:
+		return CreateMachineStepStartServer
+	}
+
+	return CreateMachineStepCompleted
+}
+
+// createVolume creates the root volume if it does not exist.
+//
+// PARAMETERS
+// ctx context.Context Execution context
+func (w *createMachineWorkflow) createVolume(ctx context.Context) error {
+	// The DAS root volume of CUBE servers is created together with the server
+	if "" != w.resultData.VolumeID || w.providerSpec.IsCube() {
+		return nil
+	}
+
+	providerSpec := w.providerSpec
+	volumeProperties := w.getRootVolumeProperties()
+
+	volume, err := w.session.CreateVolume(ctx, providerSpec.DatacenterID, ionossdk.Volume{Properties: &volumeProperties})
+	if spi.IsNotFoundError(err) {
+		return status.Error(codes.Canceled, "datacenterID given is invalid")
+	} else if nil != err {
+		return newCreateMachineStepError(err)
+	}
+
+	w.resultData.VolumeID = *volume.Id
+
+	return nil
+}
+
+// labelVolume waits for the root volume to become available and labels it.
+//
+// PARAMETERS
+// ctx context.Context Execution context
+func (w *createMachineWorkflow) labelVolume(ctx context.Context) error {
+	if "" == w.resultData.VolumeID && w.providerSpec.IsCube() {
+		return nil
+	}
+
+	_, err := w.session.WaitForVolume(ctx, w.providerSpec.DatacenterID, w.resultData.VolumeID)
+	if nil != err {
+		return newCreateMachineStepError(err)
+	}
+
+	err = w.labelManager.applyVolumeLabels(ctx, w.resultData.VolumeID, w.getVolumeLabels(), w.volumeLabels)
+	if nil != err {
+		return newCreateMachineStepError(err)
+	}
+
+	return nil
+}
+
+// createDataVolumes creates all missing data volumes, waits for them to become available and labels them.
+//
+// PARAMETERS
+// ctx context.Context Execution context
+func (w *createMachineWorkflow) createDataVolumes(ctx context.Context) error {
+	providerSpec := w.providerSpec
+
+	for _, dataVolume := range providerSpec.DataVolumes {
+		if _, ok := w.dataVolumeIDs[dataVolume.Name]; ok {
+			continue
+		}
+
+		volumeName := w.getDataVolumeName(dataVolume)
+		volumeSize := getVolumeSizeInGB(dataVolume.Size)
+		volumeType := dataVolume.GetType()
+		volumeBus := dataVolume.GetBus()
+
+		volumeAvailabilityZone := dataVolume.AvailabilityZone
+
+		if "" == volumeAvailabilityZone {
+			volumeAvailabilityZone = providerSpec.GetAvailabilityZone()
+		}
+
+		volumeProperties := ionossdk.VolumeProperties{
+			Type: &volumeType,
+			Name: &volumeName,
+			Size: &volumeSize,
+			Bus:  &volumeBus,
+		}
+
+		if "" != volumeAvailabilityZone {
+			volumeProperties.AvailabilityZone = &volumeAvailabilityZone
+		}
+
+		volume, err := w.session.CreateVolume(ctx, providerSpec.DatacenterID, ionossdk.Volume{Properties: &volumeProperties})
+		if nil != err {
+			return newCreateMachineStepError(err)
+		}
+
+		w.dataVolumeIDs[dataVolume.Name] = *volume.Id
+		w.dataVolumeLabels[*volume.Id] = map[string]string{}
+		w.resultData.DataVolumeIDs = append(w.resultData.DataVolumeIDs, *volume.Id)
+	}
+
+	for _, dataVolume := range providerSpec.DataVolumes {
+		volumeID := w.dataVolumeIDs[dataVolume.Name]
+		labels := w.getDataVolumeLabels(dataVolume)
+
+		if hasAllLabels(w.dataVolumeLabels[volumeID], labels) {
+			continue
+		}
+
+		_, err := w.session.WaitForVolume(ctx, providerSpec.DatacenterID, volumeID)
+		if nil != err {
+			return newCreateMachineStepError(err)
+		}
+
+		err = w.labelManager.applyVolumeLabels(ctx, volumeID, labels, w.dataVolumeLabels[volumeID])
+		if nil != err {
+			return newCreateMachineStepError(err)
+		}
+	}
+
+	return nil
+}
+
+// createServer creates the server booting from the root volume if it does not exist.
+//
+// PARAMETERS
+// ctx context.Context Execution context
+func (w *createMachineWorkflow) createServer(ctx context.Context) error {
+	if "" != w.resultData.ServerID {
+		return nil
+	}
+
+	providerSpec := w.providerSpec
+	volumeID := w.resultData.VolumeID
+	serverType := providerSpec.GetType()
+
+	serverAvailabilityZone := providerSpec.GetAvailabilityZone()
+
+	serverProperties := ionossdk.ServerProperties{
+		Name: &w.machine.Name,
+		Type: &serverType,
+	}
+
+	if "" != serverAvailabilityZone {
+		serverProperties.AvailabilityZone = &serverAvailabilityZone
+	}
+
+	volumes := []ionossdk.Volume{}
+
+	if providerSpec.IsCube() {
+		volumeProperties := w.getRootVolumeProperties()
+
+		serverProperties.TemplateUuid = &providerSpec.TemplateUUID
+		volumes = append(volumes, ionossdk.Volume{Properties: &volumeProperties})
+	} else {
+		cores := int32(providerSpec.Cores)
+		memory := int32(providerSpec.Memory)
+
+		serverProperties.Cores = &cores
+		serverProperties.Ram = &memory
+		serverProperties.BootVolume = &ionossdk.ResourceReference{Id: &volumeID}
+
+		if "" != providerSpec.CPUFamily {
+			serverProperties.CpuFamily = &providerSpec.CPUFamily
+		}
+
+		volumes = append(volumes, ionossdk.Volume{Id: &volumeID})
+	}
+
+	for _, dataVolume := range providerSpec.DataVolumes {
+		volumes = append(volumes, ionossdk.Volume{Id: ionossdk.PtrString(w.dataVolumeIDs[dataVolume.Name])})
+	}
+
+	serverEntities := ionossdk.ServerEntities{
+		Volumes: &ionossdk.AttachedVolumes{Items: &volumes},
+	}
+
+	server, err := w.session.CreateServer(ctx, providerSpec.DatacenterID, ionossdk.Server{Entities: &serverEntities, Properties: &serverProperties})
+	if nil != err {
+		return newCreateMachineStepError(err)
+	}
+
+	w.resultData.ServerID = *server.Id
+
+	for _, volume := range volumes {
+		if volume.HasId() {
+			w.attachedVolumeIDs[*volume.Id] = true
+		}
+	}
+
+	server, err = w.session.WaitForServer(ctx, providerSpec.DatacenterID, w.resultData.ServerID)
+	if nil != err {
+		return newCreateMachineStepError(err)
+	}
+
+	if providerSpec.IsCube() {
+		if !server.HasProperties() || !server.Properties.HasBootVolume() || !server.Properties.BootVolume.HasId() {
+			return status.Error(codes.Internal, "CUBE server created has no boot volume")
+		}
+
+		w.resultData.VolumeID = *server.Properties.BootVolume.Id
+		w.attachedVolumeIDs[w.resultData.VolumeID] = true
+
+		err = w.labelVolume(ctx)
+		if nil != err {
+			return err
+		}
+	}
+
+	w.serverVMState = "RUNNING"
+
+	if server.HasProperties() && server.Properties.HasVmState() {
+		w.serverVMState = *server.Properties.VmState
+	}
+
+	return nil
+}
+
+// attachDataVolumes attaches all data volumes not yet attached to the server.
+//
+// PARAMETERS
+// ctx context.Context Execution context
+func (w *createMachineWorkflow) attachDataVolumes(ctx context.Context) error {
+	if w.hasAllDataVolumesAttached() {
+		return nil
+	}
+
+	for _, dataVolume := range w.providerSpec.DataVolumes {
+		volumeID := w.dataVolumeIDs[dataVolume.Name]
+
+		if w.attachedVolumeIDs[volumeID] {
+			continue
+		}
+
+		err := w.session.AttachVolume(ctx, w.providerSpec.DatacenterID, w.resultData.ServerID, volumeID)
+		if nil != err {
+			return newCreateMachineStepError(err)
+		}
+
+		w.attachedVolumeIDs[volumeID] = true
+	}
+
+	_, err := w.session.WaitForServer(ctx, w.providerSpec.DatacenterID, w.resultData.ServerID)
+	if nil != err {
+		return newCreateMachineStepError(err)
+	}
+
+	return nil
+}
+
+// stopServer stops the server if NICs still need to be attached.
+//
+// PARAMETERS
+// ctx context.Context Execution context
+func (w *createMachineWorkflow) stopServer(ctx context.Context) error {
+	if w.hasAllNICs() || "SHUTOFF" == w.serverVMState {
+		return nil
+	}
+
+	err := w.session.StopServer(ctx, w.providerSpec.DatacenterID, w.resultData.ServerID)
+	if nil != err {
+		return newCreateMachineStepError(err)
+	}
+
+	_, err = w.session.WaitForServer(ctx, w.providerSpec.DatacenterID, w.resultData.ServerID)
+	if nil != err {
+		return newCreateMachineStepError(err)
+	}
+
+	w.serverVMState = "SHUTOFF"
+
+	return nil
+}
+
+// labelServer adds all missing labels to the server.
+//
+// PARAMETERS
+// ctx context.Context Execution context
+func (w *createMachineWorkflow) labelServer(ctx context.Context) error {
+	err := w.labelManager.applyServerLabels(ctx, w.resultData.ServerID, w.getServerLabels(), w.serverLabels)
+	if nil != err {
+		return newCreateMachineStepError(err)
+	}
+
+	return nil
+}
+
+// attachNICs attaches all missing NICs to the server.
+//
+// PARAMETERS
+// ctx context.Context Execution context
+func (w *createMachineWorkflow) attachNICs(ctx context.Context) error {
+	providerSpec := w.providerSpec
+
+	for _, networkInterface := range providerSpec.GetNetworkInterfaces() {
+		lanID, _ := strconv.Atoi(networkInterface.LANID)
+
+		if !w.serverLANs[int32(lanID)] {
+			nic, err := attachNetworkInterface(ctx, w.session, providerSpec.DatacenterID, w.resultData.ServerID, networkInterface)
+			if nil != err {
+				return newCreateMachineStepError(err)
+			}
+
+			w.serverLANs[int32(lanID)] = true
+			w.serverNicIDs[int32(lanID)] = *nic.Id
+		}
+
+		err := w.createFirewallRules(ctx, int32(lanID), networkInterface)
+		if nil != err {
+			return err
+		}
+	}
+
+	_, err := w.session.WaitForServer(ctx, providerSpec.DatacenterID, w.resultData.ServerID)
+	if nil != err {
+		return newCreateMachineStepError(err)
+	}
+
+	return nil
+}
+
+// createFirewallRules creates all missing firewall rules of the network interface attached to the LAN ID given.
+//
+// PARAMETERS
+// ctx              context.Context       Execution context
+// lanID            int32                 LAN ID of the network interface
+// networkInterface apis.NetworkInterface Network interface specification
+func (w *createMachineWorkflow) createFirewallRules(ctx context.Context, lanID int32, networkInterface apis.NetworkInterface) error {
+	if 0 == len(networkInterface.FirewallRules) {
+		return nil
+	}
+
+	nicID := w.serverNicIDs[lanID]
+
+	if nil == w.serverNicRules[lanID] {
+		w.serverNicRules[lanID] = map[string]bool{}
+	}
+
+	for index, firewallRule := range networkInterface.FirewallRules {
+		ruleName := firewallRule.GetName(index)
+
+		if w.serverNicRules[lanID][ruleName] {
+			continue
+		}
+
+		err := createFirewallRule(ctx, w.session, w.providerSpec.DatacenterID, w.resultData.ServerID, nicID, firewallRule, index)
+		if nil != err {
+			return newCreateMachineStepError(err)
+		}
+
+		w.serverNicRules[lanID][ruleName] = true
+	}
+
+	_, err := w.session.WaitForNic(ctx, w.providerSpec.DatacenterID, w.resultData.ServerID, nicID)
+	if nil != err {
+		return newCreateMachineStepError(err)
+	}
+
+	return nil
+}
+
+// startServer starts the server if it is not running.
+//
+// PARAMETERS
+// ctx context.Context Execution context
+func (w *createMachineWorkflow) startServer(ctx context.Context) error {
+	if "RUNNING" == w.serverVMState {
+		return nil
+	}
+
+	err := w.session.StartServer(ctx, w.providerSpec.DatacenterID, w.resultData.ServerID)
+	if nil != err {
+		return newCreateMachineStepError(err)
+	}
+
+	w.serverVMState = "RUNNING"
+
+	return nil
+}
+
+// getCommonLabels returns the labels all resources of the machine are labeled with.
+func (w *createMachineWorkflow) getCommonLabels() map[string]string {
+	labels := map[string]string{
+		labelKeyCluster:         apis.EncodeLabelValue(w.providerSpec.Cluster),
+		labelKeyMachineName:     apis.EncodeLabelValue(w.machine.Name),
+		labelKeyProviderVersion: apis.EncodeLabelValue(version.Get().GitVersion),
+	}
+
+	if "" != w.machine.Spec.Class.Name {
+		labels[labelKeyMachineClass] = apis.EncodeLabelValue(w.machine.Spec.Class.Name)
+	}
+
+	// Machine deployments are referenced by the "name" label of the machines managed
+	if deploymentName, ok := w.machine.Labels["name"]; ok && "" != deploymentName {
+		labels[labelKeyMachineDeployment] = apis.EncodeLabelValue(deploymentName)
+	}
+
+	return labels
+}
+
+// getDataVolumeLabels returns the labels the data volume given is labeled with.
+//
+// PARAMETERS
+// dataVolume apis.DataVolume Data volume specification
+func (w *createMachineWorkflow) getDataVolumeLabels(dataVolume apis.DataVolume) map[string]string {
+	labels := w.getCommonLabels()
+	labels[labelKeyDeleteOnTermination] = strconv.FormatBool(dataVolume.IsDeletedOnTermination())
+
+	return labels
+}
+
+// getServerLabels returns the labels the server is labeled with.
+func (w *createMachineWorkflow) getServerLabels() map[string]string {
+	labels := w.getCommonLabels()
+	labels[labelKeyRole] = "node"
+	labels[labelKeyRegion] = apis.EncodeLabelValue(apis.GetRegionFromZone(w.providerSpec.Zone))
+	labels[labelKeyZone] = apis.EncodeLabelValue(w.providerSpec.Zone)
+
+	return labels
+}
+
+// getVolumeLabels returns the labels the root volume is labeled with.
+func (w *createMachineWorkflow) getVolumeLabels() map[string]string {
+	return w.getCommonLabels()
+}
+
+// getDataVolumeName returns the name of the data volume given.
+//
+// PARAMETERS
+// dataVolume apis.DataVolume Data volume specification
+func (w *createMachineWorkflow) getDataVolumeName(dataVolume apis.DataVolume) string {
+	return fmt.Sprintf("%s-%s", w.machine.Name, dataVolume.Name)
+}
+
+// getRootVolumeProperties returns the properties to create the root volume with.
+func (w *createMachineWorkflow) getRootVolumeProperties() ionossdk.VolumeProperties {
+	providerSpec := w.providerSpec
+
+	sshKeys := []string{}
+	for _, sshKey := range w.sshKeys {
+		sshKeys = append(sshKeys, fmt.Sprintf("%s\n", sshKey))
+	}
+
+	userDataBase64Enc := base64.StdEncoding.EncodeToString(w.userData)
+	volumeName := w.getRootVolumeName()
+	volumeType := providerSpec.GetVolumeType()
+	volumeBus := providerSpec.GetVolumeBus()
+
+	volumeProperties := ionossdk.VolumeProperties{
+		Type:     &volumeType,
+		Name:     &volumeName,
+		Bus:      &volumeBus,
+		Image:    w.image.Id,
+		SshKeys:  &sshKeys,
+		UserData: &userDataBase64Enc,
+	}
+
+	// The size of DAS root volumes is defined by the CUBE template
+	if !providerSpec.IsCube() {
+		volumeSize := providerSpec.VolumeSize
+
+		if 0 == volumeSize {
+			volumeSize = *w.image.Properties.Size
+		} else {
+			volumeSize = float32(math.Max(float64(getVolumeSizeInGB(volumeSize)), float64(*w.image.Properties.Size)))
+		}
+
+		volumeProperties.Size = &volumeSize
+	}
+
+	volumeAvailabilityZone := providerSpec.VolumeAvailabilityZone
+
+	// DAS root volumes are always placed with their CUBE server
+	if "" == volumeAvailabilityZone && !providerSpec.IsCube() {
+		volumeAvailabilityZone = providerSpec.GetAvailabilityZone()
+	}
+
+	if "" != volumeAvailabilityZone {
+		volumeProperties.AvailabilityZone = &volumeAvailabilityZone
+	}
+
+	return volumeProperties
+}
+
+// getRootVolumeName returns the name of the root volume.
+func (w *createMachineWorkflow) getRootVolumeName() string {
+	return fmt.Sprintf("%s-root-volume", w.machine.Name)
+}
+
+// hasAllDataVolumes returns true if all data volumes exist and are labeled.
+func (w *createMachineWorkflow) hasAllDataVolumes() bool {
+	for _, dataVolume := range w.providerSpec.DataVolumes {
+		volumeID, ok := w.dataVolumeIDs[dataVolume.Name]
+		if !ok {
+			return false
+		}
+
+		if !hasAllLabels(w.dataVolumeLabels[volumeID], w.getDataVolumeLabels(dataVolume)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasAllDataVolumesAttached returns true if all data volumes are attached to the server.
+func (w *createMachineWorkflow) hasAllDataVolumesAttached() bool {
+	for _, dataVolume := range w.providerSpec.DataVolumes {
+		if !w.attachedVolumeIDs[w.dataVolumeIDs[dataVolume.Name]] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasAllNICs returns true if all configured LANs are attached to the server with all firewall rules.
+func (w *createMachineWorkflow) hasAllNICs() bool {
+	for _, networkInterface := range w.providerSpec.GetNetworkInterfaces() {
+		lanID, _ := strconv.Atoi(networkInterface.LANID)
+
+		if !w.serverLANs[int32(lanID)] {
+			return false
+		}
+
+		for index, firewallRule := range networkInterface.FirewallRules {
+			if !w.serverNicRules[int32(lanID)][firewallRule.GetName(index)] {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// hasAllServerLabels returns true if all labels are set for the server.
+func (w *createMachineWorkflow) hasAllServerLabels() bool {
+	return hasAllLabels(w.serverLabels, w.getServerLabels())
+}
+
+// getVolumeSizeInGB returns the volume size in gigabytes rounded up for the given size in bytes.
+//
+// PARAMETERS
+// size float32 Volume size in bytes
+func getVolumeSizeInGB(size float32) float32 {
+	return float32(math.Ceil(float64(size) / 1073741824))
+}
+
+// newCreateMachineStepError returns the status error for the IONOS API error given. Transient errors are reported as
+// unavailable and may be resumed by a later CreateMachine call. All other errors are reported as internal ones.
+//
+// PARAMETERS
+// err error Error encountered
+func newCreateMachineStepError(err error) error {
+	if spi.IsTransientError(err) {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+
+	return status.Error(codes.Internal, err.Error())
+}
+
+// isCreateMachineErrorResumable returns true if a later CreateMachine call may resume after the error given. Only
+// exceeded deadlines and transient errors are resumable. Resources created are cleaned up for all other errors.
+//
+// PARAMETERS
+// err error Error encountered
+func isCreateMachineErrorResumable(err error) bool {
+	// Errors not reported as status are unexpected and never resumed
+	errStatus, ok := err.(*status.Status)
+	if !ok {
+		return false
+	}
+
+	switch errStatus.Code() {
+	case codes.DeadlineExceeded, codes.Unavailable:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,723 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ionos contains the IONOS provider specific implementations to manage machines
+package ionos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis"
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis/mock"
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/spi"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/driver"
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("MachineCreation", func() {
+	var mockTestEnv mock.MockTestEnv
+
+	machineName := fmt.Sprintf(mock.TestServerNameTemplate, "resumed")
+
+	providerSecret := &corev1.Secret{
+		Data: map[string][]byte{
+			"user":     []byte("dummy-user"),
+			"password": []byte("dummy-password"),
+			"userData": []byte("dummy-user-data"),
+		},
+	}
+
+	var _ = BeforeEach(func() {
+		mockTestEnv = mock.NewMockTestEnv()
+		provider = &MachineProvider{SPI: mock.NewSessionProvider(spi.NewClientSession(mockTestEnv.Client))}
+	})
+
+	var _ = AfterEach(func() {
+		mockTestEnv.Teardown()
+	})
+
+	Describe("#CreateMachine", func() {
+		type setup struct {
+			state            *mock.MachineState
+			providerSpecData map[string]interface{}
+			dataVolumes      []apis.DataVolume
+		}
+
+		type action struct {
+		}
+
+		type expect struct {
+			volumePostCount     int
+			dataVolumePostCount int
+			volumeAttachCount   int
+			serverPostCount     int
+			serverStopCount     int
+			serverStartCount    int
+			nicPostCount        int
+			labelPostCount      int
+			volumeProperties    *ionossdk.VolumeProperties
+			serverProperties    *ionossdk.ServerProperties
+
+			dataVolumeAvailabilityZone string
+			nicProperties              []*ionossdk.NicProperties
+			firewallRulePostCount      int
+			firewallRules              map[int32][]*ionossdk.FirewallruleProperties
+		}
+
+		type data struct {
+			setup  setup
+			action action
+			expect expect
+		}
+
+		newIPBlockState := func(ips []string, foreignIPs []string, racingIPs []string) *mock.IPBlockState {
+			state := mock.NewIPBlockState(ips...)
+			state.RacingIPs = racingIPs
+
+			for _, ip := range foreignIPs {
+				state.AddConsumer(ip, mock.TestForeignServerID)
+			}
+
+			return state
+		}
+
+		newDataVolumeLabels := func(deleteOnTermination bool) map[string]string {
+			labels := mock.GetTestVolumeLabels(machineName)
+			labels[labelKeyDeleteOnTermination] = strconv.FormatBool(deleteOnTermination)
+
+			return labels
+		}
+
+		newState := func(data map[string]interface{}) *mock.MachineState {
+			state := mock.NewMachineState(machineName)
+
+			for key, value := range data {
+				switch key {
+				case "VolumeExists":
+					state.VolumeExists = value.(bool)
+				case "VolumeState":
+					state.VolumeState = value.(string)
+				case "VolumeLabels":
+					state.VolumeLabels = value.(map[string]string)
+				case "ServerExists":
+					state.ServerExists = value.(bool)
+				case "ServerState":
+					state.ServerState = value.(string)
+				case "ServerVMState":
+					state.ServerVMState = value.(string)
+				case "ServerLabels":
+					state.ServerLabels = value.(map[string]string)
+				case "ServerNicLANs":
+					state.ServerNicLANs = value.([]int32)
+				case "ServerNicFirewallRules":
+					state.ServerNicFirewallRules = value.(map[int32][]*ionossdk.FirewallruleProperties)
+				case "IPBlock":
+					state.IPBlock = value.(*mock.IPBlockState)
+				case "DataVolumes":
+					for _, dataVolume := range value.([]mock.MachineStateDataVolume) {
+						state.AddDataVolume(dataVolume.Name, dataVolume.Labels, dataVolume.Attached)
+					}
+				}
+			}
+
+			return state
+		}
+
+		DescribeTable("##table",
+			func(data *data) {
+				ctx := context.Background()
+				state := data.setup.state
+
+				mock.SetupMachineStateEndpointsOnMux(mockTestEnv.Mux, state)
+
+				machine := mock.NewMachine("")
+				machine.Name = machineName
+
+				providerSpec := mock.ManipulateProviderSpec(mock.NewProviderSpec(), data.setup.providerSpecData)
+				providerSpec.DataVolumes = data.setup.dataVolumes
+
+				providerSpecJSON, err := json.Marshal(providerSpec)
+				Expect(err).NotTo(HaveOccurred())
+
+				response, err := provider.CreateMachine(ctx, &driver.CreateMachineRequest{
+					Machine:      machine,
+					MachineClass: mock.NewMachineClassWithProviderSpec(providerSpecJSON),
+					Secret:       providerSecret,
+				})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(response.NodeName).To(Equal(machineName))
+
+				Expect(state.VolumePostCount).To(Equal(data.expect.volumePostCount))
+				Expect(state.DataVolumePostCount).To(Equal(data.expect.dataVolumePostCount))
+				Expect(state.VolumeAttachCount).To(Equal(data.expect.volumeAttachCount))
+				Expect(state.ServerPostCount).To(Equal(data.expect.serverPostCount))
+				Expect(state.ServerStopCount).To(Equal(data.expect.serverStopCount))
+				Expect(state.ServerStartCount).To(Equal(data.expect.serverStartCount))
+				Expect(state.NicPostCount).To(Equal(data.expect.nicPostCount))
+				Expect(state.LabelPostCount).To(Equal(data.expect.labelPostCount))
+
+				Expect(state.VolumeLabels).To(Equal(mock.GetTestVolumeLabels(machineName)))
+				Expect(state.ServerLabels).To(Equal(mock.GetTestServerLabels(machineName)))
+				for _, networkInterface := range providerSpec.GetNetworkInterfaces() {
+					lanID, err := strconv.Atoi(networkInterface.LANID)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(state.ServerNicLANs).To(ContainElement(int32(lanID)))
+				}
+
+				Expect(state.FirewallRulePostCount).To(Equal(data.expect.firewallRulePostCount))
+
+				if nil != data.expect.firewallRules {
+					Expect(state.ServerNicFirewallRules).To(Equal(data.expect.firewallRules))
+				}
+
+				if nil != data.expect.nicProperties {
+					Expect(state.ServerNicProperties).To(Equal(data.expect.nicProperties))
+				}
+				Expect(state.ServerVMState).To(Equal("RUNNING"))
+
+				if nil != data.expect.volumeProperties {
+					Expect(state.VolumeProperties.Type).To(Equal(data.expect.volumeProperties.Type))
+					Expect(state.VolumeProperties.Bus).To(Equal(data.expect.volumeProperties.Bus))
+					Expect(state.VolumeProperties.AvailabilityZone).To(Equal(data.expect.volumeProperties.AvailabilityZone))
+				}
+
+				if nil != data.expect.serverProperties {
+					Expect(state.ServerProperties.Type).To(Equal(data.expect.serverProperties.Type))
+					Expect(state.ServerProperties.TemplateUuid).To(Equal(data.expect.serverProperties.TemplateUuid))
+					Expect(state.ServerProperties.CpuFamily).To(Equal(data.expect.serverProperties.CpuFamily))
+					Expect(state.ServerProperties.Cores).To(Equal(data.expect.serverProperties.Cores))
+					Expect(state.ServerProperties.Ram).To(Equal(data.expect.serverProperties.Ram))
+					Expect(state.ServerProperties.BootVolume).To(Equal(data.expect.serverProperties.BootVolume))
+					Expect(state.ServerProperties.AvailabilityZone).To(Equal(data.expect.serverProperties.AvailabilityZone))
+				}
+
+				if "" != data.expect.dataVolumeAvailabilityZone {
+					for _, dataVolume := range state.DataVolumes {
+						Expect(*dataVolume.Properties.AvailabilityZone).To(Equal(data.expect.dataVolumeAvailabilityZone))
+					}
+				}
+
+				Expect(state.DataVolumes).To(HaveLen(len(data.setup.dataVolumes)))
+
+				for _, dataVolume := range data.setup.dataVolumes {
+					stateDataVolume := state.GetDataVolume(dataVolume.Name)
+
+					Expect(stateDataVolume).NotTo(BeNil())
+					Expect(stateDataVolume.Attached).To(BeTrue())
+					Expect(stateDataVolume.Labels).To(HaveKeyWithValue("cluster", mock.GetTestClusterLabelValue()))
+					Expect(stateDataVolume.Labels).To(HaveKeyWithValue(labelKeyDeleteOnTermination, strconv.FormatBool(dataVolume.IsDeletedOnTermination())))
+				}
+			},
+
+			Entry("creates all resources if none exist", &data{
+				setup: setup{
+					state: newState(map[string]interface{}{}),
+				},
+				expect: expect{
+					volumePostCount:  1,
+					serverPostCount:  1,
+					serverStopCount:  1,
+					serverStartCount: 1,
+					nicPostCount:     1,
+					labelPostCount:   9,
+					volumeProperties: &ionossdk.VolumeProperties{
+						Type: ionossdk.PtrString(apis.VolumeTypeSSDPremium),
+						Bus:  ionossdk.PtrString(apis.VolumeBusVirtIO),
+					},
+				},
+			}),
+			Entry("creates the root volume with the volume settings given", &data{
+				setup: setup{
+					state: newState(map[string]interface{}{}),
+					providerSpecData: map[string]interface{}{
+						"VolumeType":             apis.VolumeTypeHDD,
+						"VolumeBus":              apis.VolumeBusIDE,
+						"VolumeAvailabilityZone": apis.AvailabilityZone1,
+					},
+				},
+				expect: expect{
+					volumePostCount:  1,
+					serverPostCount:  1,
+					serverStopCount:  1,
+					serverStartCount: 1,
+					nicPostCount:     1,
+					labelPostCount:   9,
+					volumeProperties: &ionossdk.VolumeProperties{
+						Type:             ionossdk.PtrString(apis.VolumeTypeHDD),
+						Bus:              ionossdk.PtrString(apis.VolumeBusIDE),
+						AvailabilityZone: ionossdk.PtrString(apis.AvailabilityZone1),
+					},
+				},
+			}),
+			Entry("creates an ENTERPRISE server with the CPU family given", &data{
+				setup: setup{
+					state: newState(map[string]interface{}{}),
+					providerSpecData: map[string]interface{}{
+						"CPUFamily": apis.CPUFamilyIntelSkylake,
+					},
+				},
+				expect: expect{
+					volumePostCount:  1,
+					serverPostCount:  1,
+					serverStopCount:  1,
+					serverStartCount: 1,
+					nicPostCount:     1,
+					labelPostCount:   9,
+					serverProperties: &ionossdk.ServerProperties{
+						Type:       ionossdk.PtrString(apis.ServerTypeEnterprise),
+						CpuFamily:  ionossdk.PtrString(apis.CPUFamilyIntelSkylake),
+						Cores:      ionossdk.PtrInt32(1),
+						Ram:        ionossdk.PtrInt32(1024),
+						BootVolume: &ionossdk.ResourceReference{Id: ionossdk.PtrString(mock.TestServerVolumeID)},
+					},
+				},
+			}),
+			Entry("creates a CUBE server together with its DAS root volume", &data{
+				setup: setup{
+					state: newState(map[string]interface{}{}),
+					providerSpecData: map[string]interface{}{
+						"Cores":        uint(0),
+						"Memory":       uint(0),
+						"Type":         apis.ServerTypeCube,
+						"TemplateUUID": "15c6dd2f-02d2-4987-b439-9a58dd59ecc3",
+					},
+				},
+				expect: expect{
+					volumePostCount:  1,
+					serverPostCount:  1,
+					serverStopCount:  1,
+					serverStartCount: 1,
+					nicPostCount:     1,
+					labelPostCount:   9,
+					volumeProperties: &ionossdk.VolumeProperties{
+						Type: ionossdk.PtrString(apis.VolumeTypeDAS),
+						Bus:  ionossdk.PtrString(apis.VolumeBusVirtIO),
+					},
+					serverProperties: &ionossdk.ServerProperties{
+						Type:         ionossdk.PtrString(apis.ServerTypeCube),
+						TemplateUuid: ionossdk.PtrString("15c6dd2f-02d2-4987-b439-9a58dd59ecc3"),
+					},
+				},
+			}),
+			Entry("places the server and all volumes in the availability zone mapped", &data{
+				setup: setup{
+					state: newState(map[string]interface{}{}),
+					providerSpecData: map[string]interface{}{
+						"AvailabilityZones": map[string]string{
+							mock.TestProviderSpecZone: apis.AvailabilityZone2,
+							"de-txl":                  apis.AvailabilityZone1,
+						},
+					},
+					dataVolumes: []apis.DataVolume{
+						{Name: "data", Size: 10737418240},
+					},
+				},
+				expect: expect{
+					volumePostCount:     1,
+					dataVolumePostCount: 1,
+					serverPostCount:     1,
+					serverStopCount:     1,
+					serverStartCount:    1,
+					nicPostCount:        1,
+					labelPostCount:      13,
+					volumeProperties: &ionossdk.VolumeProperties{
+						Type:             ionossdk.PtrString(apis.VolumeTypeSSDPremium),
+						Bus:              ionossdk.PtrString(apis.VolumeBusVirtIO),
+						AvailabilityZone: ionossdk.PtrString(apis.AvailabilityZone2),
+					},
+					serverProperties: &ionossdk.ServerProperties{
+						Type:             ionossdk.PtrString(apis.ServerTypeEnterprise),
+						Cores:            ionossdk.PtrInt32(1),
+						Ram:              ionossdk.PtrInt32(1024),
+						BootVolume:       &ionossdk.ResourceReference{Id: ionossdk.PtrString(mock.TestServerVolumeID)},
+						AvailabilityZone: ionossdk.PtrString(apis.AvailabilityZone2),
+					},
+					dataVolumeAvailabilityZone: apis.AvailabilityZone2,
+				},
+			}),
+			Entry("converts networkIDs and floatingPoolID to network interfaces", &data{
+				setup: setup{
+					state: newState(map[string]interface{}{
+						"IPBlock": newIPBlockState([]string{"192.0.2.1", "192.0.2.2"}, []string{"192.0.2.1"}, nil),
+					}),
+					providerSpecData: map[string]interface{}{
+						"FloatingPoolID": mock.TestIPBlockID,
+						"NetworkIDs":     &apis.NetworkIDs{WAN: "1", Workers: "2"},
+					},
+				},
+				expect: expect{
+					volumePostCount:  1,
+					serverPostCount:  1,
+					serverStopCount:  1,
+					serverStartCount: 1,
+					nicPostCount:     2,
+					labelPostCount:   9,
+					nicProperties: []*ionossdk.NicProperties{
+						{
+							Name:           ionossdk.PtrString("wan"),
+							Lan:            ionossdk.PtrInt32(1),
+							Dhcp:           ionossdk.PtrBool(true),
+							FirewallActive: ionossdk.PtrBool(true),
+							Ips:            &[]string{"192.0.2.2"},
+						},
+						{
+							Name:           ionossdk.PtrString("workers"),
+							Lan:            ionossdk.PtrInt32(2),
+							Dhcp:           ionossdk.PtrBool(false),
+							FirewallActive: ionossdk.PtrBool(false),
+						},
+					},
+				},
+			}),
+			Entry("retries with another floating IP if the one chosen has been consumed concurrently", &data{
+				setup: setup{
+					state: newState(map[string]interface{}{
+						"IPBlock": newIPBlockState([]string{"192.0.2.1", "192.0.2.2", "192.0.2.3"}, []string{"192.0.2.1"}, []string{"192.0.2.2"}),
+					}),
+					providerSpecData: map[string]interface{}{
+						"FloatingPoolID": mock.TestIPBlockID,
+						"NetworkIDs":     &apis.NetworkIDs{WAN: "1", Workers: "2"},
+					},
+				},
+				expect: expect{
+					volumePostCount:  1,
+					serverPostCount:  1,
+					serverStopCount:  1,
+					serverStartCount: 1,
+					nicPostCount:     2,
+					labelPostCount:   9,
+					nicProperties: []*ionossdk.NicProperties{
+						{
+							Name:           ionossdk.PtrString("wan"),
+							Lan:            ionossdk.PtrInt32(1),
+							Dhcp:           ionossdk.PtrBool(true),
+							FirewallActive: ionossdk.PtrBool(true),
+							Ips:            &[]string{"192.0.2.3"},
+						},
+						{
+							Name:           ionossdk.PtrString("workers"),
+							Lan:            ionossdk.PtrInt32(2),
+							Dhcp:           ionossdk.PtrBool(false),
+							FirewallActive: ionossdk.PtrBool(false),
+						},
+					},
+				},
+			}),
+			Entry("attaches all network interfaces specified", &data{
+				setup: setup{
+					state: newState(map[string]interface{}{}),
+					providerSpecData: map[string]interface{}{
+						"NetworkIDs": (*apis.NetworkIDs)(nil),
+						"NetworkInterfaces": []apis.NetworkInterface{
+							{Name: "internal", LANID: "3", DHCP: new(bool), IPs: []string{"10.1.0.5"}},
+							{Name: "storage", LANID: "4", FirewallActive: true, FirewallType: apis.FirewallTypeEgress},
+						},
+					},
+				},
+				expect: expect{
+					volumePostCount:  1,
+					serverPostCount:  1,
+					serverStopCount:  1,
+					serverStartCount: 1,
+					nicPostCount:     2,
+					labelPostCount:   9,
+					nicProperties: []*ionossdk.NicProperties{
+						{
+							Name:           ionossdk.PtrString("internal"),
+							Lan:            ionossdk.PtrInt32(3),
+							Dhcp:           ionossdk.PtrBool(false),
+							FirewallActive: ionossdk.PtrBool(false),
+							Ips:            &[]string{"10.1.0.5"},
+						},
+						{
+							Name:           ionossdk.PtrString("storage"),
+							Lan:            ionossdk.PtrInt32(4),
+							Dhcp:           ionossdk.PtrBool(true),
+							FirewallActive: ionossdk.PtrBool(true),
+							FirewallType:   ionossdk.PtrString(apis.FirewallTypeEgress),
+						},
+					},
+				},
+			}),
+			Entry("creates the firewall rules of all network interfaces", &data{
+				setup: setup{
+					state: newState(map[string]interface{}{}),
+					providerSpecData: map[string]interface{}{
+						"NetworkIDs": (*apis.NetworkIDs)(nil),
+						"NetworkInterfaces": []apis.NetworkInterface{
+							{
+								Name:           "wan",
+								LANID:          "1",
+								FirewallActive: true,
+								FirewallRules: []apis.FirewallRule{
+									{Protocol: apis.FirewallRuleProtocolTCP, PortRangeStart: ionossdk.PtrInt32(22)},
+									{Name: "ping", Protocol: apis.FirewallRuleProtocolICMP, SourceIP: "10.0.0.0/8", ICMPType: ionossdk.PtrInt32(8)},
+								},
+							},
+						},
+					},
+				},
+				expect: expect{
+					volumePostCount:       1,
+					serverPostCount:       1,
+					serverStopCount:       1,
+					serverStartCount:      1,
+					nicPostCount:          1,
+					labelPostCount:        9,
+					firewallRulePostCount: 2,
+					firewallRules: map[int32][]*ionossdk.FirewallruleProperties{
+						1: {
+							{
+								Name:           ionossdk.PtrString("rule-0"),
+								Protocol:       ionossdk.PtrString(apis.FirewallRuleProtocolTCP),
+								PortRangeStart: ionossdk.PtrInt32(22),
+								PortRangeEnd:   ionossdk.PtrInt32(22),
+							},
+							{
+								Name:     ionossdk.PtrString("ping"),
+								Protocol: ionossdk.PtrString(apis.FirewallRuleProtocolICMP),
+								SourceIp: ionossdk.PtrString("10.0.0.0/8"),
+								IcmpType: ionossdk.PtrInt32(8),
+							},
+						},
+					},
+				},
+			}),
+			Entry("creates missing firewall rules of an attached network interface", &data{
+				setup: setup{
+					state: newState(map[string]interface{}{
+						"VolumeExists":  true,
+						"VolumeLabels":  mock.GetTestVolumeLabels(machineName),
+						"ServerExists":  true,
+						"ServerVMState": "RUNNING",
+						"ServerLabels":  mock.GetTestServerLabels(machineName),
+						"ServerNicLANs": []int32{1},
+						"ServerNicFirewallRules": map[int32][]*ionossdk.FirewallruleProperties{
+							1: {{Name: ionossdk.PtrString("ssh"), Protocol: ionossdk.PtrString(apis.FirewallRuleProtocolTCP)}},
+						},
+					}),
+					providerSpecData: map[string]interface{}{
+						"NetworkIDs": (*apis.NetworkIDs)(nil),
+						"NetworkInterfaces": []apis.NetworkInterface{
+							{
+								Name:           "wan",
+								LANID:          "1",
+								FirewallActive: true,
+								FirewallRules: []apis.FirewallRule{
+									{Name: "ssh", Protocol: apis.FirewallRuleProtocolTCP},
+									{Name: "dns", Protocol: apis.FirewallRuleProtocolUDP, PortRangeStart: ionossdk.PtrInt32(53), Direction: apis.FirewallTypeEgress},
+								},
+							},
+						},
+					},
+				},
+				expect: expect{
+					serverStopCount:       1,
+					serverStartCount:      1,
+					firewallRulePostCount: 1,
+				},
+			}),
+			Entry("adopts a busy, unlabeled volume", &data{
+				setup: setup{
+					state: newState(map[string]interface{}{
+						"VolumeExists": true,
+						"VolumeState":  "BUSY",
+					}),
+				},
+				expect: expect{
+					serverPostCount:  1,
+					serverStopCount:  1,
+					serverStartCount: 1,
+					nicPostCount:     1,
+					labelPostCount:   9,
+				},
+			}),
+			Entry("adopts a labeled volume", &data{
+				setup: setup{
+					state: newState(map[string]interface{}{
+						"VolumeExists": true,
+						"VolumeLabels": mock.GetTestVolumeLabels(machineName),
+					}),
+				},
+				expect: expect{
+					serverPostCount:  1,
+					serverStopCount:  1,
+					serverStartCount: 1,
+					nicPostCount:     1,
+					labelPostCount:   6,
+				},
+			}),
+			Entry("ignores a volume labeled for another cluster", &data{
+				setup: setup{
+					state: newState(map[string]interface{}{
+						"VolumeExists": true,
+						"VolumeLabels": map[string]string{"cluster": "other"},
+					}),
+				},
+				expect: expect{
+					volumePostCount:  1,
+					serverPostCount:  1,
+					serverStopCount:  1,
+					serverStartCount: 1,
+					nicPostCount:     1,
+					labelPostCount:   9,
+				},
+			}),
+			Entry("adopts a busy server without labels and NICs", &data{
+				setup: setup{
+					state: newState(map[string]interface{}{
+						"VolumeExists":  true,
+						"VolumeLabels":  mock.GetTestVolumeLabels(machineName),
+						"ServerExists":  true,
+						"ServerState":   "BUSY",
+						"ServerVMState": "RUNNING",
+					}),
+				},
+				expect: expect{
+					serverStopCount:  1,
+					serverStartCount: 1,
+					nicPostCount:     1,
+					labelPostCount:   6,
+				},
+			}),
+			Entry("adopts a server with an unlabeled volume", &data{
+				setup: setup{
+					state: newState(map[string]interface{}{
+						"VolumeExists":  true,
+						"ServerExists":  true,
+						"ServerVMState": "SHUTOFF",
+					}),
+				},
+				expect: expect{
+					serverStartCount: 1,
+					nicPostCount:     1,
+					labelPostCount:   9,
+				},
+			}),
+			Entry("adopts a stopped, labeled server without NICs", &data{
+				setup: setup{
+					state: newState(map[string]interface{}{
+						"VolumeExists":  true,
+						"VolumeLabels":  mock.GetTestVolumeLabels(machineName),
+						"ServerExists":  true,
+						"ServerVMState": "SHUTOFF",
+						"ServerLabels":  mock.GetTestServerLabels(machineName),
+					}),
+				},
+				expect: expect{
+					serverStartCount: 1,
+					nicPostCount:     1,
+				},
+			}),
+			Entry("adopts a stopped server with NICs but missing labels", &data{
+				setup: setup{
+					state: newState(map[string]interface{}{
+						"VolumeExists":  true,
+						"VolumeLabels":  mock.GetTestVolumeLabels(machineName),
+						"ServerExists":  true,
+						"ServerVMState": "SHUTOFF",
+						"ServerLabels":  map[string]string{"cluster": mock.GetTestClusterLabelValue()},
+						"ServerNicLANs": []int32{1},
+					}),
+				},
+				expect: expect{
+					serverStartCount: 1,
+					labelPostCount:   5,
+				},
+			}),
+			Entry("adopts a completely created server", &data{
+				setup: setup{
+					state: newState(map[string]interface{}{
+						"VolumeExists":  true,
+						"VolumeLabels":  mock.GetTestVolumeLabels(machineName),
+						"ServerExists":  true,
+						"ServerVMState": "RUNNING",
+						"ServerLabels":  mock.GetTestServerLabels(machineName),
+						"ServerNicLANs": []int32{1},
+					}),
+				},
+				expect: expect{},
+			}),
+			Entry("creates all data volumes if none exist", &data{
+				setup: setup{
+					state: newState(map[string]interface{}{}),
+					dataVolumes: []apis.DataVolume{
+						{Name: "data", Size: 10737418240},
+						{Name: "logs", Size: 5368709120, Type: apis.VolumeTypeHDD, DeleteOnTermination: new(bool)},
+					},
+				},
+				expect: expect{
+					volumePostCount:     1,
+					dataVolumePostCount: 2,
+					serverPostCount:     1,
+					serverStopCount:     1,
+					serverStartCount:    1,
+					nicPostCount:        1,
+					labelPostCount:      17,
+				},
+			}),
+			Entry("labels an unlabeled data volume before creating the server", &data{
+				setup: setup{
+					state: newState(map[string]interface{}{
+						"VolumeExists": true,
+						"VolumeLabels": mock.GetTestVolumeLabels(machineName),
+						"DataVolumes": []mock.MachineStateDataVolume{
+							{Name: "data", Labels: map[string]string{}},
+						},
+					}),
+					dataVolumes: []apis.DataVolume{
+						{Name: "data", Size: 10737418240},
+					},
+				},
+				expect: expect{
+					serverPostCount:  1,
+					serverStopCount:  1,
+					serverStartCount: 1,
+					nicPostCount:     1,
+					labelPostCount:   10,
+				},
+			}),
+			Entry("attaches a data volume missing on a completely created server", &data{
+				setup: setup{
+					state: newState(map[string]interface{}{
+						"VolumeExists":  true,
+						"VolumeLabels":  mock.GetTestVolumeLabels(machineName),
+						"ServerExists":  true,
+						"ServerVMState": "RUNNING",
+						"ServerLabels":  mock.GetTestServerLabels(machineName),
+						"ServerNicLANs": []int32{1},
+						"DataVolumes": []mock.MachineStateDataVolume{
+							{Name: "data", Labels: newDataVolumeLabels(true)},
+						},
+					}),
+					dataVolumes: []apis.DataVolume{
+						{Name: "data", Size: 10737418240},
+					},
+				},
+				expect: expect{
+					volumeAttachCount: 1,
+				},
+			}),
+		)
+	})
+})
@@ -0,0 +1,516 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ionos contains the IONOS provider specific implementations to manage machines
+package ionos
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis"
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis/mock"
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/spi"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/driver"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("MachineLifecycle", func() {
+	var (
+		api         *mock.FakeCloudAPI
+		mockTestEnv mock.MockTestEnv
+	)
+
+	providerSecret := &corev1.Secret{
+		Data: map[string][]byte{
+			"user":     []byte("dummy-user"),
+			"password": []byte("dummy-password"),
+			"userData": []byte("dummy-user-data"),
+		},
+	}
+
+	var _ = BeforeEach(func() {
+		mockTestEnv = mock.NewMockTestEnv()
+		provider = &MachineProvider{SPI: mock.NewSessionProvider(spi.NewClientSession(mockTestEnv.Client))}
+
+		api = mock.NewFakeCloudAPI()
+		api.SetupOnMux(mockTestEnv.Mux)
+
+		api.AddDatacenter(mock.TestProviderSpecDatacenterID, "de/fra")
+		api.AddImage(mock.TestProviderSpecImageID, ionossdk.ImageProperties{
+			Name:        ionossdk.PtrString("Ubuntu 20.04"),
+			Location:    ionossdk.PtrString("de/fra"),
+			Size:        ionossdk.PtrFloat32(10),
+			LicenceType: ionossdk.PtrString("LINUX"),
+			ImageType:   ionossdk.PtrString("HDD"),
+			CloudInit:   ionossdk.PtrString("V1"),
+		})
+	})
+
+	var _ = AfterEach(func() {
+		mockTestEnv.Teardown()
+	})
+
+	createMachine := func(ctx context.Context, machineName string) (*driver.CreateMachineResponse, error) {
+		machine := mock.NewMachine("")
+		machine.Name = machineName
+
+		return provider.CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      machine,
+			MachineClass: mock.NewMachineClass(),
+			Secret:       providerSecret,
+		})
+	}
+
+	It("creates, lists, gets and deletes a machine", func() {
+		ctx := context.Background()
+
+		response, err := createMachine(ctx, "machine-lifecycle")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.NodeName).To(Equal("machine-lifecycle"))
+
+		serverIDs := api.GetServerIDs(mock.TestProviderSpecDatacenterID)
+		Expect(serverIDs).To(HaveLen(1))
+
+		server, ok := api.GetServer(mock.TestProviderSpecDatacenterID, serverIDs[0])
+		Expect(ok).To(BeTrue())
+		Expect(*server.Metadata.State).To(Equal("AVAILABLE"))
+		Expect(*server.Properties.VmState).To(Equal("RUNNING"))
+		Expect(*server.Entities.Volumes.Items).To(HaveLen(1))
+		Expect(*server.Entities.Nics.Items).To(HaveLen(1))
+		Expect(api.GetServerLabels(mock.TestProviderSpecDatacenterID, serverIDs[0])).To(Equal(mock.GetTestServerLabels("machine-lifecycle")))
+
+		machine := mock.NewMachine(serverIDs[0])
+		machine.Spec.ProviderID = response.ProviderID
+
+		listResponse, err := provider.ListMachines(ctx, &driver.ListMachinesRequest{
+			MachineClass: mock.NewMachineClass(),
+			Secret:       providerSecret,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(listResponse.MachineList).To(Equal(map[string]string{response.ProviderID: "machine-lifecycle"}))
+
+		statusResponse, err := provider.GetMachineStatus(ctx, &driver.GetMachineStatusRequest{
+			Machine:      machine,
+			MachineClass: mock.NewMachineClass(),
+			Secret:       providerSecret,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statusResponse.NodeName).To(Equal("machine-lifecycle"))
+
+		_, err = provider.DeleteMachine(ctx, &driver.DeleteMachineRequest{
+			Machine:      machine,
+			MachineClass: mock.NewMachineClass(),
+			Secret:       providerSecret,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(api.GetServerIDs(mock.TestProviderSpecDatacenterID)).To(BeEmpty())
+		Expect(api.GetVolumeIDs(mock.TestProviderSpecDatacenterID)).To(BeEmpty())
+
+		_, err = provider.GetMachineStatus(ctx, &driver.GetMachineStatusRequest{
+			Machine:      machine,
+			MachineClass: mock.NewMachineClass(),
+			Secret:       providerSecret,
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(err.(*status.Status).Code()).To(Equal(codes.NotFound))
+	})
+
+	It("authorizes SSH keys referenced in the machine secret", func() {
+		providerSpec := mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+			"SSHKeySecretKeys": []string{"authorizedKeys"},
+		})
+		providerSpecJSON, _ := json.Marshal(providerSpec)
+
+		sshKey := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIC1xFkK3JrBEAWJ8qfusMvXIUw+xkDzE2wIlhxeSGkiB referenced@example.com"
+
+		machine := mock.NewMachine("")
+		machine.Name = "machine-ssh-keys"
+
+		_, err := provider.CreateMachine(context.Background(), &driver.CreateMachineRequest{
+			Machine:      machine,
+			MachineClass: mock.NewMachineClassWithProviderSpec(providerSpecJSON),
+			Secret: &corev1.Secret{Data: map[string][]byte{
+				"user":           []byte("dummy-user"),
+				"password":       []byte("dummy-password"),
+				"userData":       []byte("#!/bin/sh\n"),
+				"authorizedKeys": []byte(sshKey + "\n"),
+			}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		volumeIDs := api.GetVolumeIDs(mock.TestProviderSpecDatacenterID)
+		Expect(volumeIDs).To(HaveLen(1))
+
+		volume, _ := api.GetVolume(mock.TestProviderSpecDatacenterID, volumeIDs[0])
+		Expect(*volume.Properties.SshKeys).To(Equal([]string{mock.TestProviderSpecSSHKey + "\n", sshKey + "\n"}))
+
+		userData, err := base64.StdEncoding.DecodeString(*volume.Properties.UserData)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(userData)).To(ContainSubstring(sshKey))
+	})
+
+	It("boots from a snapshot", func() {
+		api.AddSnapshot("3c1c8b3e-0d3e-4b6e-9c5a-2f1e6c2f5d10", ionossdk.SnapshotProperties{
+			Name:        ionossdk.PtrString("golden-image"),
+			Location:    ionossdk.PtrString("de/fra"),
+			Size:        ionossdk.PtrFloat32(20),
+			LicenceType: ionossdk.PtrString("LINUX"),
+		})
+
+		providerSpec := mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+			"ImageID": "",
+			"Image":   &apis.ImageReference{SnapshotID: "3c1c8b3e-0d3e-4b6e-9c5a-2f1e6c2f5d10", SnapshotCloudInit: true},
+		})
+		providerSpecJSON, _ := json.Marshal(providerSpec)
+
+		machine := mock.NewMachine("")
+		machine.Name = "machine-snapshot"
+
+		_, err := provider.CreateMachine(context.Background(), &driver.CreateMachineRequest{
+			Machine:      machine,
+			MachineClass: mock.NewMachineClassWithProviderSpec(providerSpecJSON),
+			Secret:       providerSecret,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		volumeIDs := api.GetVolumeIDs(mock.TestProviderSpecDatacenterID)
+		Expect(volumeIDs).To(HaveLen(1))
+
+		volume, _ := api.GetVolume(mock.TestProviderSpecDatacenterID, volumeIDs[0])
+		Expect(*volume.Properties.Image).To(Equal("3c1c8b3e-0d3e-4b6e-9c5a-2f1e6c2f5d10"))
+		Expect(*volume.Properties.Size).To(Equal(float32(20)))
+	})
+
+	It("fails with InvalidArgument if the user data exceeds the IONOS limit", func() {
+		userData := make([]byte, 2*base64.StdEncoding.DecodedLen(userDataMaxEncodedSize))
+		_, _ = rand.New(rand.NewSource(1)).Read(userData)
+
+		machine := mock.NewMachine("")
+		machine.Name = "machine-oversized"
+
+		_, err := provider.CreateMachine(context.Background(), &driver.CreateMachineRequest{
+			Machine:      machine,
+			MachineClass: mock.NewMachineClass(),
+			Secret:       &corev1.Secret{Data: map[string][]byte{"user": []byte("dummy-user"), "password": []byte("dummy-password"), "userData": userData}},
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(err.(*status.Status).Code()).To(Equal(codes.InvalidArgument))
+		Expect(err.Error()).To(ContainSubstring("exceeds the allowed size"))
+
+		Expect(api.GetVolumeIDs(mock.TestProviderSpecDatacenterID)).To(BeEmpty())
+	})
+
+	It("resumes the machine creation after the server creation failed", func() {
+		api.InjectFault(&mock.Fault{
+			Method:     http.MethodPost,
+			Path:       fmt.Sprintf("/datacenters/%s/servers", mock.TestProviderSpecDatacenterID),
+			StatusCode: http.StatusInternalServerError,
+			Message:    "Injected failure",
+			Count:      1,
+		})
+
+		ctx := context.Background()
+
+		_, err := createMachine(ctx, "machine-resumed")
+		Expect(err).To(HaveOccurred())
+		Expect(err.(*status.Status).Code()).To(Equal(codes.Unavailable))
+
+		Expect(api.GetServerIDs(mock.TestProviderSpecDatacenterID)).To(BeEmpty())
+		volumeIDs := api.GetVolumeIDs(mock.TestProviderSpecDatacenterID)
+		Expect(volumeIDs).To(HaveLen(1))
+
+		_, err = createMachine(ctx, "machine-resumed")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(api.GetServerIDs(mock.TestProviderSpecDatacenterID)).To(HaveLen(1))
+		Expect(api.GetVolumeIDs(mock.TestProviderSpecDatacenterID)).To(Equal(volumeIDs))
+		Expect(api.GetRequestCount(http.MethodPost, "/datacenters/*/servers")).To(Equal(2))
+		Expect(api.GetRequestCount(http.MethodPost, "/datacenters/*/volumes")).To(Equal(1))
+	})
+
+	It("cleans up the server and all volumes after an internal failure", func() {
+		api.InjectFault(&mock.Fault{
+			Method:     http.MethodPost,
+			Path:       fmt.Sprintf("/datacenters/%s/servers/*/nics", mock.TestProviderSpecDatacenterID),
+			StatusCode: http.StatusBadRequest,
+			Message:    "Injected failure",
+			Count:      1,
+		})
+
+		_, err := createMachine(context.Background(), "machine-failed")
+		Expect(err).To(HaveOccurred())
+		Expect(err.(*status.Status).Code()).To(Equal(codes.Internal))
+
+		Expect(api.GetRequestCount(http.MethodPost, "/datacenters/*/servers")).To(Equal(1))
+		Expect(api.GetRequestCount(http.MethodDelete, "/datacenters/*/servers/*")).To(Equal(1))
+		Expect(api.GetServerIDs(mock.TestProviderSpecDatacenterID)).To(BeEmpty())
+		Expect(api.GetVolumeIDs(mock.TestProviderSpecDatacenterID)).To(BeEmpty())
+	})
+
+	It("cleans up the root and data volumes after the server creation was rejected", func() {
+		api.InjectFault(&mock.Fault{
+			Method:     http.MethodPost,
+			Path:       fmt.Sprintf("/datacenters/%s/servers", mock.TestProviderSpecDatacenterID),
+			StatusCode: http.StatusUnprocessableEntity,
+			Message:    "Quota exceeded for cores",
+		})
+
+		providerSpec, err := json.Marshal(mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{
+			"DataVolumes": []apis.DataVolume{{Name: "data", Size: 10 * 1073741824}},
+		}))
+		Expect(err).NotTo(HaveOccurred())
+
+		machine := mock.NewMachine("")
+		machine.Name = "machine-rejected"
+
+		_, err = provider.CreateMachine(context.Background(), &driver.CreateMachineRequest{
+			Machine:      machine,
+			MachineClass: mock.NewMachineClassWithProviderSpec(providerSpec),
+			Secret:       providerSecret,
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(err.(*status.Status).Code()).To(Equal(codes.Internal))
+
+		Expect(api.GetRequestCount(http.MethodPost, "/datacenters/*/volumes")).To(Equal(2))
+		Expect(api.GetServerIDs(mock.TestProviderSpecDatacenterID)).To(BeEmpty())
+		Expect(api.GetVolumeIDs(mock.TestProviderSpecDatacenterID)).To(BeEmpty())
+	})
+
+	It("resumes the machine creation after attaching a NIC was temporarily unavailable", func() {
+		api.InjectFault(&mock.Fault{
+			Method:     http.MethodPost,
+			Path:       fmt.Sprintf("/datacenters/%s/servers/*/nics", mock.TestProviderSpecDatacenterID),
+			StatusCode: http.StatusServiceUnavailable,
+			Message:    "Injected failure",
+		})
+
+		ctx := context.Background()
+
+		_, err := createMachine(ctx, "machine-unavailable")
+		Expect(err).To(HaveOccurred())
+		Expect(err.(*status.Status).Code()).To(Equal(codes.Unavailable))
+
+		serverIDs := api.GetServerIDs(mock.TestProviderSpecDatacenterID)
+		Expect(serverIDs).To(HaveLen(1))
+		volumeIDs := api.GetVolumeIDs(mock.TestProviderSpecDatacenterID)
+		Expect(volumeIDs).To(HaveLen(1))
+		Expect(api.GetRequestCount(http.MethodDelete, "/datacenters/*/servers/*")).To(Equal(0))
+
+		api.ClearFaults()
+
+		_, err = createMachine(ctx, "machine-unavailable")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(api.GetServerIDs(mock.TestProviderSpecDatacenterID)).To(Equal(serverIDs))
+		Expect(api.GetVolumeIDs(mock.TestProviderSpecDatacenterID)).To(Equal(volumeIDs))
+		Expect(api.GetRequestCount(http.MethodPost, "/datacenters/*/servers")).To(Equal(1))
+	})
+
+	It("fails with DeadlineExceeded and resumes the machine creation with the next call", func() {
+		api.TransitionDelay = 500 * time.Millisecond
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		_, err := createMachine(ctx, "machine-deadline")
+		Expect(err).To(HaveOccurred())
+		Expect(err.(*status.Status).Code()).To(Equal(codes.DeadlineExceeded))
+		Expect(err.Error()).To(ContainSubstring(CreateMachineStepLabelVolume.String()))
+		Expect(ctx.Err()).NotTo(HaveOccurred())
+
+		volumeIDs := api.GetVolumeIDs(mock.TestProviderSpecDatacenterID)
+		Expect(volumeIDs).To(HaveLen(1))
+
+		api.TransitionDelay = 0
+
+		_, err = createMachine(context.Background(), "machine-deadline")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(api.GetServerIDs(mock.TestProviderSpecDatacenterID)).To(HaveLen(1))
+		Expect(api.GetVolumeIDs(mock.TestProviderSpecDatacenterID)).To(Equal(volumeIDs))
+	})
+
+	It("resumes the machine deletion with the progress of the previous call", func() {
+		ctx := context.Background()
+
+		response, err := createMachine(ctx, "machine-deleted")
+		Expect(err).NotTo(HaveOccurred())
+
+		serverIDs := api.GetServerIDs(mock.TestProviderSpecDatacenterID)
+		volumeIDs := api.GetVolumeIDs(mock.TestProviderSpecDatacenterID)
+		Expect(volumeIDs).To(HaveLen(1))
+
+		stopCount := api.GetRequestCount(http.MethodPost, "/datacenters/*/servers/*/stop")
+
+		machine := mock.NewMachine(serverIDs[0])
+		machine.Spec.ProviderID = response.ProviderID
+		machine.Status.LastKnownState = encodeLastKnownState(&DeleteMachineMethodData{
+			ServerID:         serverIDs[0],
+			ServerStopped:    true,
+			DeletedVolumeIDs: volumeIDs,
+		})
+
+		_, err = provider.DeleteMachine(ctx, &driver.DeleteMachineRequest{
+			Machine:      machine,
+			MachineClass: mock.NewMachineClass(),
+			Secret:       providerSecret,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(api.GetRequestCount(http.MethodPost, "/datacenters/*/servers/*/stop")).To(Equal(stopCount))
+		Expect(api.GetRequestCount(http.MethodDelete, "/datacenters/*/volumes/*")).To(Equal(0))
+		Expect(api.GetServerIDs(mock.TestProviderSpecDatacenterID)).To(BeEmpty())
+
+		// Progress of other servers is ignored
+		machine.Status.LastKnownState = encodeLastKnownState(&DeleteMachineMethodData{ServerID: "other-server", ServerDeleted: true})
+
+		_, err = provider.DeleteMachine(ctx, &driver.DeleteMachineRequest{
+			Machine:      machine,
+			MachineClass: mock.NewMachineClass(),
+			Secret:       providerSecret,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(api.GetRequestCount(http.MethodPost, "/datacenters/*/servers/*/stop")).To(Equal(stopCount + 1))
+	})
+
+	It("keeps modified resources BUSY until their request has been executed", func() {
+		api.TransitionDelay = 50 * time.Millisecond
+		ctx := context.Background()
+
+		volume, apiResponse, err := mockTestEnv.Client.VolumesApi.DatacentersVolumesPost(ctx, mock.TestProviderSpecDatacenterID).Volume(ionossdk.Volume{
+			Properties: &ionossdk.VolumeProperties{Name: ionossdk.PtrString("busy"), Size: ionossdk.PtrFloat32(10)},
+		}).Execute()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*volume.Metadata.State).To(Equal("BUSY"))
+
+		location := apiResponse.Header.Get("Location")
+		Expect(location).To(HaveSuffix("/status"))
+
+		requestID := strings.TrimSuffix(location[strings.LastIndex(location, "/requests/")+len("/requests/"):], "/status")
+		requestStatus, _, err := mockTestEnv.Client.RequestsApi.RequestsStatusGet(ctx, requestID).Execute()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*requestStatus.Metadata.Status).To(Equal("RUNNING"))
+
+		Eventually(func() string {
+			volume, _ := api.GetVolume(mock.TestProviderSpecDatacenterID, *volume.Id)
+			return *volume.Metadata.State
+		}).Should(Equal("AVAILABLE"))
+
+		requestStatus, _, err = mockTestEnv.Client.RequestsApi.RequestsStatusGet(ctx, requestID).Execute()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*requestStatus.Metadata.Status).To(Equal("DONE"))
+	})
+
+	It("fails requests asynchronously", func() {
+		api.InjectFault(&mock.Fault{
+			Method:  http.MethodPost,
+			Path:    fmt.Sprintf("/datacenters/%s/volumes", mock.TestProviderSpecDatacenterID),
+			Message: "Injected asynchronous failure",
+			Async:   true,
+			Count:   1,
+		})
+
+		ctx := context.Background()
+		session := spi.NewClientSession(mockTestEnv.Client)
+
+		volume, err := session.CreateVolume(ctx, mock.TestProviderSpecDatacenterID, ionossdk.Volume{
+			Properties: &ionossdk.VolumeProperties{Name: ionossdk.PtrString("failed"), Size: ionossdk.PtrFloat32(10)},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = session.WaitForVolume(ctx, mock.TestProviderSpecDatacenterID, *volume.Id)
+		Expect(err).To(HaveOccurred())
+		Expect(err).To(BeAssignableToTypeOf(&spi.RequestFailedError{}))
+		Expect(err.Error()).To(ContainSubstring("Injected asynchronous failure"))
+
+		failedVolume, _ := api.GetVolume(mock.TestProviderSpecDatacenterID, *volume.Id)
+		Expect(*failedVolume.Metadata.State).To(Equal("FAILED"))
+
+		volume, err = session.CreateVolume(ctx, mock.TestProviderSpecDatacenterID, ionossdk.Volume{
+			Properties: &ionossdk.VolumeProperties{Name: ionossdk.PtrString("available"), Size: ionossdk.PtrFloat32(10)},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*volume.Metadata.State).To(Equal("AVAILABLE"))
+	})
+
+	It("reports IPs in use by failed asynchronous requests", func() {
+		api.InjectFault(&mock.Fault{
+			Method:  http.MethodPost,
+			Path:    fmt.Sprintf("/datacenters/%s/servers/*/nics", mock.TestProviderSpecDatacenterID),
+			Message: "IP 192.0.2.1 is already in use",
+			Async:   true,
+			Count:   1,
+		})
+
+		ctx := context.Background()
+		session := spi.NewClientSession(mockTestEnv.Client)
+
+		serverID := api.AddServer(mock.TestProviderSpecDatacenterID, ionossdk.Server{
+			Properties: &ionossdk.ServerProperties{Name: ionossdk.PtrString("machine-ip")},
+		}, nil)
+
+		nic, err := session.CreateNic(ctx, mock.TestProviderSpecDatacenterID, serverID, ionossdk.Nic{
+			Properties: &ionossdk.NicProperties{Lan: ionossdk.PtrInt32(1), Ips: &[]string{"192.0.2.1"}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = session.WaitForNic(ctx, mock.TestProviderSpecDatacenterID, serverID, *nic.Id)
+		Expect(err).To(BeAssignableToTypeOf(&spi.RequestFailedError{}))
+		Expect(spi.IsIPInUseError(err)).To(BeTrue())
+	})
+
+	It("rejects IPs already in use", func() {
+		ctx := context.Background()
+		session := spi.NewClientSession(mockTestEnv.Client)
+
+		api.AddIPBlock(mock.TestIPBlockID, "de/fra", "192.0.2.1")
+		serverID := api.AddServer(mock.TestProviderSpecDatacenterID, ionossdk.Server{
+			Properties: &ionossdk.ServerProperties{Name: ionossdk.PtrString("machine-ip")},
+		}, nil)
+
+		nic := ionossdk.Nic{Properties: &ionossdk.NicProperties{Lan: ionossdk.PtrInt32(1), Ips: &[]string{"192.0.2.1"}}}
+
+		_, err := session.CreateNic(ctx, mock.TestProviderSpecDatacenterID, serverID, nic)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = session.CreateNic(ctx, mock.TestProviderSpecDatacenterID, serverID, nic)
+		Expect(spi.IsIPInUseError(err)).To(BeTrue())
+
+		nic = ionossdk.Nic{Properties: &ionossdk.NicProperties{Ips: &[]string{"192.0.2.2"}}}
+
+		_, err = session.CreateNic(ctx, mock.TestProviderSpecDatacenterID, serverID, nic)
+		Expect(spi.GetErrorStatusCode(err)).To(Equal(http.StatusUnprocessableEntity))
+		Expect(spi.IsIPInUseError(err)).To(BeFalse())
+
+		ipBlock, err := session.GetIPBlock(ctx, mock.TestIPBlockID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*ipBlock.Properties.IpConsumers).To(HaveLen(1))
+		Expect(*(*ipBlock.Properties.IpConsumers)[0].ServerId).To(Equal(serverID))
+	})
+})
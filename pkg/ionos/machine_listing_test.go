@@ -0,0 +1,212 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ionos contains the IONOS provider specific implementations to manage machines
+package ionos
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis/mock"
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/spi"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/driver"
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Constant listMachinesServerCount is the number of servers ListMachines is tested and benchmarked with
+const listMachinesServerCount = 500
+
+// newListMachinesTestEnv returns a fake Cloud API with the number of servers given together with the machine
+// provider and request to list them. Every fifth server belongs to a foreign cluster and every tenth server has been
+// labeled with hex encoded values by a previous version.
+//
+// PARAMETERS
+// serverCount int Number of servers to add
+func newListMachinesTestEnv(serverCount int) (mock.MockTestEnv, *mock.FakeCloudAPI, *MachineProvider, *driver.ListMachinesRequest, int) {
+	mockTestEnv := mock.NewMockTestEnv()
+
+	api := mock.NewFakeCloudAPI()
+	api.SetupOnMux(mockTestEnv.Mux)
+	api.AddDatacenter(mock.TestProviderSpecDatacenterID, "de/fra")
+	api.AddDatacenter("foreign-datacenter", "de/fra")
+
+	expectedCount := 0
+
+	for index := 0; index < serverCount; index++ {
+		machineName := fmt.Sprintf(mock.TestServerNameTemplate, fmt.Sprint(index))
+		labels := mock.GetTestServerLabels(machineName)
+
+		switch {
+		case 0 == index%5:
+			labels["cluster"] = "foreign"
+		case 1 == index%10:
+			labels["cluster"] = hex.EncodeToString([]byte(mock.TestProviderSpecCluster))
+			labels["zone"] = hex.EncodeToString([]byte(mock.TestProviderSpecZone))
+			delete(labels, "provider-version")
+			expectedCount++
+		default:
+			expectedCount++
+		}
+
+		api.AddServer(mock.TestProviderSpecDatacenterID, ionossdk.Server{
+			Properties: &ionossdk.ServerProperties{Name: ionossdk.PtrString(machineName)},
+		}, labels)
+	}
+
+	// Servers of other datacenters are not listed even if labeled for the cluster
+	api.AddServer("foreign-datacenter", ionossdk.Server{
+		Properties: &ionossdk.ServerProperties{Name: ionossdk.PtrString("machine-foreign")},
+	}, mock.GetTestServerLabels("machine-foreign"))
+
+	provider := &MachineProvider{SPI: mock.NewSessionProvider(spi.NewClientSession(mockTestEnv.Client))}
+
+	request := &driver.ListMachinesRequest{
+		MachineClass: mock.NewMachineClass(),
+		Secret: &corev1.Secret{
+			Data: map[string][]byte{
+				"user":     []byte("dummy-user"),
+				"password": []byte("dummy-password"),
+			},
+		},
+	}
+
+	return mockTestEnv, api, provider, request, expectedCount
+}
+
+// getTotalRequestCount returns the number of all requests received by the fake Cloud API given.
+//
+// PARAMETERS
+// api *mock.FakeCloudAPI Fake Cloud API
+func getTotalRequestCount(api *mock.FakeCloudAPI) int {
+	count := 0
+	pathPattern := ""
+
+	for depth := 0; depth < 8; depth++ {
+		pathPattern += "/*"
+		count += api.GetRequestCount("", pathPattern)
+	}
+
+	return count
+}
+
+var _ = Describe("MachineListing", func() {
+	It("lists machines with a constant number of API calls", func() {
+		mockTestEnv, api, provider, request, expectedCount := newListMachinesTestEnv(listMachinesServerCount)
+		defer mockTestEnv.Teardown()
+
+		response, err := provider.ListMachines(context.Background(), request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.MachineList).To(HaveLen(expectedCount))
+
+		for providerID, machineName := range response.MachineList {
+			Expect(providerID).To(HavePrefix(fmt.Sprintf("ionos:///%s/", mock.TestProviderSpecDatacenterID)))
+			Expect(machineName).NotTo(Equal("machine-foreign"))
+		}
+
+		Expect(api.GetRequestCount(http.MethodGet, "/datacenters/*/servers")).To(Equal(1))
+		Expect(api.GetRequestCount(http.MethodGet, "/labels")).To(Equal(1))
+		Expect(api.GetRequestCount(http.MethodGet, "/datacenters/*/servers/*/labels")).To(Equal(0))
+		Expect(getTotalRequestCount(api)).To(Equal(2))
+	})
+
+	It("does not list machines of a cluster whose name collides with the hex encoded cluster name", func() {
+		mockTestEnv := mock.NewMockTestEnv()
+		defer mockTestEnv.Teardown()
+
+		api := mock.NewFakeCloudAPI()
+		api.SetupOnMux(mockTestEnv.Mux)
+		api.AddDatacenter(mock.TestProviderSpecDatacenterID, "de/fra")
+
+		legacyCluster := "abc"
+		collidingCluster := hex.EncodeToString([]byte(legacyCluster))
+
+		legacyLabels := mock.GetTestServerLabels("machine-legacy")
+		legacyLabels["cluster"] = collidingCluster
+		legacyLabels["zone"] = hex.EncodeToString([]byte(mock.TestProviderSpecZone))
+		delete(legacyLabels, "provider-version")
+
+		api.AddServer(mock.TestProviderSpecDatacenterID, ionossdk.Server{
+			Properties: &ionossdk.ServerProperties{Name: ionossdk.PtrString("machine-legacy")},
+		}, legacyLabels)
+
+		collidingLabels := mock.GetTestServerLabels("machine-colliding")
+		collidingLabels["cluster"] = collidingCluster
+
+		api.AddServer(mock.TestProviderSpecDatacenterID, ionossdk.Server{
+			Properties: &ionossdk.ServerProperties{Name: ionossdk.PtrString("machine-colliding")},
+		}, collidingLabels)
+
+		provider := &MachineProvider{SPI: mock.NewSessionProvider(spi.NewClientSession(mockTestEnv.Client))}
+
+		request := &driver.ListMachinesRequest{
+			MachineClass: mock.NewMachineClass(),
+			Secret: &corev1.Secret{
+				Data: map[string][]byte{
+					"user":     []byte("dummy-user"),
+					"password": []byte("dummy-password"),
+				},
+			},
+		}
+
+		for cluster, machineName := range map[string]string{legacyCluster: "machine-legacy", collidingCluster: "machine-colliding"} {
+			providerSpec, err := json.Marshal(mock.ManipulateProviderSpec(mock.NewProviderSpec(), map[string]interface{}{"Cluster": cluster}))
+			Expect(err).NotTo(HaveOccurred())
+
+			request.MachineClass = mock.NewMachineClassWithProviderSpec(providerSpec)
+
+			response, err := provider.ListMachines(context.Background(), request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.MachineList).To(HaveLen(1), cluster)
+
+			for _, listedMachineName := range response.MachineList {
+				Expect(listedMachineName).To(Equal(machineName))
+			}
+		}
+	})
+})
+
+// BenchmarkListMachines measures ListMachines for a datacenter with 500 servers and reports the number of IONOS API
+// calls made per operation.
+func BenchmarkListMachines(b *testing.B) {
+	mockTestEnv, api, provider, request, expectedCount := newListMachinesTestEnv(listMachinesServerCount)
+	defer mockTestEnv.Teardown()
+
+	b.ResetTimer()
+
+	for iteration := 0; iteration < b.N; iteration++ {
+		response, err := provider.ListMachines(context.Background(), request)
+		if nil != err {
+			b.Fatal(err)
+		}
+
+		if expectedCount != len(response.MachineList) {
+			b.Fatalf("%d machines listed instead of %d", len(response.MachineList), expectedCount)
+		}
+	}
+
+	b.StopTimer()
+
+	b.ReportMetric(float64(getTotalRequestCount(api))/float64(b.N), "calls/op")
+	b.ReportMetric(float64(api.GetRequestCount(http.MethodGet, "/labels"))/float64(b.N), "label-calls/op")
+}
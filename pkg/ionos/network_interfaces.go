@@ -0,0 +1,167 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ionos contains the IONOS provider specific implementations to manage machines
+package ionos
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis"
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/spi"
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
+)
+
+// attachNetworkInterface attaches a network interface as specified to the server and waits for it to become available.
+//
+// PARAMETERS
+// ctx              context.Context       Execution context
+// session          spi.Session           IONOS session
+// datacenterID     string                Datacenter ID
+// serverID         string                Server ID
+// networkInterface apis.NetworkInterface Network interface specification
+func attachNetworkInterface(ctx context.Context, session spi.Session, datacenterID, serverID string, networkInterface apis.NetworkInterface) (ionossdk.Nic, error) {
+	lanID, err := strconv.Atoi(networkInterface.LANID)
+	if nil != err {
+		return ionossdk.Nic{}, err
+	}
+
+	nicProperties := ionossdk.NicProperties{
+		Lan:            ionossdk.PtrInt32(int32(lanID)),
+		Dhcp:           ionossdk.PtrBool(networkInterface.IsDHCPEnabled()),
+		FirewallActive: ionossdk.PtrBool(networkInterface.FirewallActive),
+	}
+
+	if "" != networkInterface.Name {
+		nicProperties.Name = &networkInterface.Name
+	}
+
+	if networkInterface.FirewallActive && "" != networkInterface.FirewallType {
+		nicProperties.FirewallType = &networkInterface.FirewallType
+	}
+
+	if "" != networkInterface.IPBlockID {
+		var nic ionossdk.Nic
+
+		err := floatingIPAllocator.allocate(ctx, session, networkInterface.IPBlockID, func(ip string) error {
+			var err error
+
+			nicProperties.Ips = &[]string{ip}
+			nic, err = createNic(ctx, session, datacenterID, serverID, nicProperties)
+
+			return err
+		})
+
+		return nic, err
+	}
+
+	if len(networkInterface.IPs) > 0 {
+		nicProperties.Ips = &networkInterface.IPs
+	}
+
+	return createNic(ctx, session, datacenterID, serverID, nicProperties)
+}
+
+// createNic creates a network interface with the properties given and waits for it to become available.
+//
+// PARAMETERS
+// ctx           context.Context         Execution context
+// session       spi.Session             IONOS session
+// datacenterID  string                  Datacenter ID
+// serverID      string                  Server ID
+// nicProperties ionossdk.NicProperties  Network interface properties
+func createNic(ctx context.Context, session spi.Session, datacenterID, serverID string, nicProperties ionossdk.NicProperties) (ionossdk.Nic, error) {
+	nic, err := session.CreateNic(ctx, datacenterID, serverID, ionossdk.Nic{Properties: &nicProperties})
+	if nil != err {
+		return ionossdk.Nic{}, err
+	}
+
+	return session.WaitForNic(ctx, datacenterID, serverID, *nic.Id)
+}
+
+// createFirewallRule creates the firewall rule at the index given for the network interface.
+//
+// PARAMETERS
+// ctx          context.Context   Execution context
+// session      spi.Session       IONOS session
+// datacenterID string            Datacenter ID
+// serverID     string            Server ID
+// nicID        string            Network interface ID
+// firewallRule apis.FirewallRule Firewall rule specification
+// index        int               Index of the firewall rule
+func createFirewallRule(ctx context.Context, session spi.Session, datacenterID, serverID, nicID string, firewallRule apis.FirewallRule, index int) error {
+	ruleProperties := ionossdk.FirewallruleProperties{
+		Name:     ionossdk.PtrString(firewallRule.GetName(index)),
+		Protocol: ionossdk.PtrString(firewallRule.Protocol),
+		IcmpType: firewallRule.ICMPType,
+		IcmpCode: firewallRule.ICMPCode,
+	}
+
+	if "" != firewallRule.SourceIP {
+		ruleProperties.SourceIp = &firewallRule.SourceIP
+	}
+
+	if "" != firewallRule.SourceMAC {
+		ruleProperties.SourceMac = &firewallRule.SourceMAC
+	}
+
+	if "" != firewallRule.TargetIP {
+		ruleProperties.TargetIp = &firewallRule.TargetIP
+	}
+
+	if nil != firewallRule.PortRangeStart {
+		ruleProperties.PortRangeStart = firewallRule.PortRangeStart
+		ruleProperties.PortRangeEnd = firewallRule.PortRangeStart
+
+		if nil != firewallRule.PortRangeEnd {
+			ruleProperties.PortRangeEnd = firewallRule.PortRangeEnd
+		}
+	}
+
+	if "" != firewallRule.Direction {
+		ruleProperties.Type = &firewallRule.Direction
+	}
+
+	_, err := session.CreateFirewallRule(ctx, datacenterID, serverID, nicID, ionossdk.FirewallRule{Properties: &ruleProperties})
+
+	return err
+}
+
+// getFirewallRuleNames returns the names of all firewall rules of the network interface given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// session      spi.Session     IONOS session
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+// nicID        string          Network interface ID
+func getFirewallRuleNames(ctx context.Context, session spi.Session, datacenterID, serverID, nicID string) (map[string]bool, error) {
+	rules, err := session.ListFirewallRules(ctx, datacenterID, serverID, nicID)
+	if nil != err {
+		return nil, err
+	}
+
+	ruleNames := map[string]bool{}
+
+	for _, rule := range rules {
+		if rule.HasProperties() && rule.Properties.HasName() {
+			ruleNames[*rule.Properties.Name] = true
+		}
+	}
+
+	return ruleNames, nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ionos contains the IONOS provider specific implementations to manage machines
+package ionos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	// Constant operationResponseReserve is the time reserved to return a response before the deadline of a call
+	operationResponseReserve = 2 * time.Second
+	// Constant operationStepReserve is the time reserved for each step following the one being executed
+	operationStepReserve = 5 * time.Second
+	// Constant operationMinStepDuration is the minimum time a step needs to be started
+	operationMinStepDuration = 50 * time.Millisecond
+)
+
+// errOperationBudgetExhausted is returned if there is not enough time left to start a step
+var errOperationBudgetExhausted = errors.New("operation budget exhausted")
+
+// withOperationBudget returns a context expiring early enough to return a response before the deadline of the
+// context given. At most a tenth of the remaining time is reserved.
+//
+// PARAMETERS
+// ctx context.Context Execution context
+func withOperationBudget(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+
+	reserve := time.Until(deadline) / 10
+
+	if reserve > operationResponseReserve {
+		reserve = operationResponseReserve
+	}
+
+	return context.WithDeadline(ctx, deadline.Add(-reserve))
+}
+
+// withStepBudget returns a context for a step followed by the number of steps given. Time is reserved for the
+// following steps to be started but at most half of the remaining time.
+//
+// PARAMETERS
+// ctx            context.Context Execution context
+// followingSteps int             Number of steps following the one to execute
+func withStepBudget(ctx context.Context, followingSteps int) (context.Context, context.CancelFunc, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		stepCtx, cancel := context.WithCancel(ctx)
+		return stepCtx, cancel, nil
+	}
+
+	remaining := time.Until(deadline)
+	reserve := time.Duration(followingSteps) * operationStepReserve
+
+	if reserve > remaining/2 {
+		reserve = remaining / 2
+	}
+
+	if remaining-reserve < operationMinStepDuration {
+		return nil, nil, errOperationBudgetExhausted
+	}
+
+	stepCtx, cancel := context.WithDeadline(ctx, deadline.Add(-reserve))
+	return stepCtx, cancel, nil
+}
+
+// isDeadlineExceeded returns true if the deadline of the context given has been exceeded.
+//
+// PARAMETERS
+// ctx context.Context Execution context
+func isDeadlineExceeded(ctx context.Context) bool {
+	return context.DeadlineExceeded == ctx.Err()
+}
+
+// encodeLastKnownState returns the JSON encoded progress data given to be reported as last known state.
+//
+// PARAMETERS
+// data interface{} Progress data
+func encodeLastKnownState(data interface{}) string {
+	lastKnownState, err := json.Marshal(data)
+	if nil != err {
+		return ""
+	}
+
+	return string(lastKnownState)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ionos contains the IONOS provider specific implementations to manage machines
+package ionos
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OperationBudget", func() {
+	Describe("#withOperationBudget", func() {
+		It("should reserve time to return a response", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+
+			budgetCtx, budgetCancel := withOperationBudget(ctx)
+			defer budgetCancel()
+
+			deadline, _ := ctx.Deadline()
+			budgetDeadline, ok := budgetCtx.Deadline()
+			Expect(ok).To(BeTrue())
+			Expect(budgetDeadline).To(BeTemporally("~", deadline.Add(-operationResponseReserve), 10*time.Millisecond))
+		})
+
+		It("should not set a deadline if the context has none", func() {
+			budgetCtx, budgetCancel := withOperationBudget(context.Background())
+			defer budgetCancel()
+
+			_, ok := budgetCtx.Deadline()
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("#withStepBudget", func() {
+		It("should reserve time for the following steps", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+
+			stepCtx, stepCancel, err := withStepBudget(ctx, 3)
+			Expect(err).NotTo(HaveOccurred())
+			defer stepCancel()
+
+			deadline, _ := ctx.Deadline()
+			stepDeadline, _ := stepCtx.Deadline()
+			Expect(stepDeadline).To(BeTemporally("~", deadline.Add(-3*operationStepReserve), 10*time.Millisecond))
+		})
+
+		It("should reserve at most half of the remaining time", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			stepCtx, stepCancel, err := withStepBudget(ctx, 8)
+			Expect(err).NotTo(HaveOccurred())
+			defer stepCancel()
+
+			deadline, _ := ctx.Deadline()
+			stepDeadline, _ := stepCtx.Deadline()
+			Expect(deadline.Sub(stepDeadline)).To(BeNumerically("~", 5*time.Second, 10*time.Millisecond))
+		})
+
+		It("should fail if there is not enough time left to start a step", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			_, _, err := withStepBudget(ctx, 1)
+			Expect(err).To(MatchError(errOperationBudgetExhausted))
+		})
+	})
+})
@@ -0,0 +1,92 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ionos contains the IONOS provider specific implementations to manage machines
+package ionos
+
+import (
+	"fmt"
+
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
+)
+
+// Constant codeUninitialized is reported for servers not yet initialized. The machine codes of the MCM version used
+// do not provide "Uninitialized" yet. "Aborted" is retried by the machine controller in the same way.
+const codeUninitialized = codes.Aborted
+
+// getServerState returns the metadata state and VM state of the server given.
+//
+// PARAMETERS
+// server *ionossdk.Server Server
+func getServerState(server *ionossdk.Server) (string, string) {
+	state := ""
+	vmState := ""
+
+	if server.HasMetadata() && server.Metadata.HasState() {
+		state = *server.Metadata.State
+	}
+
+	if server.HasProperties() && server.Properties.HasVmState() {
+		vmState = *server.Properties.VmState
+	}
+
+	return state, vmState
+}
+
+// getServerStatusError returns the machine error for the state of the server given or nil if it is running.
+//
+// PARAMETERS
+// server   *ionossdk.Server Server
+// serverID string           Server ID
+func getServerStatusError(server *ionossdk.Server, serverID string) error {
+	state, vmState := getServerState(server)
+
+	name := serverID
+	if server.HasProperties() && server.Properties.HasName() {
+		name = *server.Properties.Name
+	}
+
+	switch state {
+	case "INACTIVE":
+		return status.Error(codes.NotFound, fmt.Sprintf("VM %s (%s) does not exist", name, serverID))
+	case "FAILED":
+		return status.Error(codes.Unavailable, fmt.Sprintf("VM %s (%s) failed to be provisioned", name, serverID))
+	case "BUSY", "DEPLOYING":
+		if "RUNNING" != vmState {
+			return status.Error(codeUninitialized, fmt.Sprintf("VM %s (%s) is being provisioned", name, serverID))
+		}
+	}
+
+	switch vmState {
+	case "RUNNING":
+		return nil
+	case "", "NOSTATE":
+		return status.Error(codeUninitialized, fmt.Sprintf("VM %s (%s) is not yet initialized", name, serverID))
+	}
+
+	return status.Error(codes.Unavailable, fmt.Sprintf("VM %s (%s) is %s", name, serverID, vmState))
+}
+
+// isServerRestartable returns true if the server given is SHUTOFF and may be started.
+//
+// PARAMETERS
+// server *ionossdk.Server Server
+func isServerRestartable(server *ionossdk.Server) bool {
+	state, vmState := getServerState(server)
+	return "AVAILABLE" == state && "SHUTOFF" == vmState
+}
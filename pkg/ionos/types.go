@@ -18,8 +18,72 @@ limitations under the License.
 package ionos
 
 type CreateMachineMethodData struct {
-	ServerID string
-	VolumeID string
+	ServerID      string
+	VolumeID      string
+	DataVolumeIDs []string
+}
+
+// DeleteMachineMethodData holds the progress of a machine deletion. It is reported as last known state if the
+// deadline is exceeded and taken over by the next DeleteMachine call.
+type DeleteMachineMethodData struct {
+	ServerID         string   `json:"serverID,omitempty"`
+	ServerStopped    bool     `json:"serverStopped,omitempty"`
+	DeletedVolumeIDs []string `json:"deletedVolumeIDs,omitempty"`
+	ServerDeleted    bool     `json:"serverDeleted,omitempty"`
+}
+
+// CreateMachineStep is a step of the resumable machine creation workflow
+type CreateMachineStep int
+
+const (
+	// CreateMachineStepCreateVolume creates the root volume
+	CreateMachineStepCreateVolume CreateMachineStep = iota
+	// CreateMachineStepLabelVolume waits for the root volume and labels it
+	CreateMachineStepLabelVolume
+	// CreateMachineStepCreateDataVolumes creates and labels all data volumes
+	CreateMachineStepCreateDataVolumes
+	// CreateMachineStepCreateServer creates the server booting from the root volume
+	CreateMachineStepCreateServer
+	// CreateMachineStepAttachDataVolumes attaches data volumes not yet attached to the server
+	CreateMachineStepAttachDataVolumes
+	// CreateMachineStepStopServer stops the server before NICs are attached
+	CreateMachineStepStopServer
+	// CreateMachineStepLabelServer labels the server
+	CreateMachineStepLabelServer
+	// CreateMachineStepAttachNICs attaches all configured NICs to the server
+	CreateMachineStepAttachNICs
+	// CreateMachineStepStartServer starts the server
+	CreateMachineStepStartServer
+	// CreateMachineStepCompleted is reached after all steps have been executed
+	CreateMachineStepCompleted
+)
+
+// String returns a human readable name of the step
+func (step CreateMachineStep) String() string {
+	switch step {
+	case CreateMachineStepCreateVolume:
+		return "CreateVolume"
+	case CreateMachineStepLabelVolume:
+		return "LabelVolume"
+	case CreateMachineStepCreateDataVolumes:
+		return "CreateDataVolumes"
+	case CreateMachineStepCreateServer:
+		return "CreateServer"
+	case CreateMachineStepAttachDataVolumes:
+		return "AttachDataVolumes"
+	case CreateMachineStepStopServer:
+		return "StopServer"
+	case CreateMachineStepLabelServer:
+		return "LabelServer"
+	case CreateMachineStepAttachNICs:
+		return "AttachNICs"
+	case CreateMachineStepStartServer:
+		return "StartServer"
+	case CreateMachineStepCompleted:
+		return "Completed"
+	}
+
+	return "Unknown"
 }
 
 type CtxWrapDataKey string
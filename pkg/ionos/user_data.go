@@ -0,0 +1,465 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ionos contains the IONOS provider specific implementations to manage machines
+package ionos
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/klog/v2"
+)
+
+// Constant userDataCloudConfigHeader is the header line of cloud-config user data
+const userDataCloudConfigHeader = "#cloud-config"
+
+// Constant userDataMaxEncodedSize is the maximum size of base64 encoded user data accepted by the IONOS API
+const userDataMaxEncodedSize = 65536
+
+// Constant userDataMergeType is the cloud-init merge type of the cloud-config part added to MIME multipart user data
+const userDataMergeType = "list(append)+dict(recursive_update)+str()"
+
+// Variable userDataShellInterpreters contains the interpreters of shell scripts the hostname and SSH keys are injected into
+var userDataShellInterpreters = map[string]bool{"ash": true, "bash": true, "dash": true, "ksh": true, "sh": true, "zsh": true}
+
+// userDataRenderer injects the hostname and SSH keys into user data
+type userDataRenderer struct {
+	hostname string
+	sshKeys  []string
+}
+
+// newUserDataRenderer returns a new renderer for the hostname and SSH keys given.
+//
+// PARAMETERS
+// hostname string   Hostname to set
+// sshKeys  []string SSH public keys to authorize
+func newUserDataRenderer(hostname string, sshKeys []string) *userDataRenderer {
+	return &userDataRenderer{hostname: hostname, sshKeys: sshKeys}
+}
+
+// hasPrefixFold returns true if the data given starts with the prefix ignoring the case.
+//
+// PARAMETERS
+// data   []byte Data to check
+// prefix string Prefix
+func hasPrefixFold(data []byte, prefix string) bool {
+	return len(data) >= len(prefix) && strings.EqualFold(string(data[:len(prefix)]), prefix)
+}
+
+// quoteShellString returns the string given quoted for POSIX shells.
+//
+// PARAMETERS
+// value string String to quote
+func quoteShellString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'"'"'`) + "'"
+}
+
+// render returns the user data given with the hostname and SSH keys injected. Shell scripts, cloud-config and MIME
+// multipart user data are extended in place. Any other user data is wrapped into a MIME multipart archive.
+//
+// PARAMETERS
+// userData []byte User data to render
+func (r *userDataRenderer) render(userData []byte) ([]byte, error) {
+	if bytes.HasPrefix(userData, []byte{0x1f, 0x8b}) {
+		reader, err := gzip.NewReader(bytes.NewReader(userData))
+		if nil != err {
+			return nil, fmt.Errorf("userData given can not be decompressed: %w", err)
+		}
+
+		userData, err = io.ReadAll(reader)
+		if nil != err {
+			return nil, fmt.Errorf("userData given can not be decompressed: %w", err)
+		}
+	}
+
+	switch {
+	case hasPrefixFold(userData, userDataCloudConfigHeader):
+		return r.renderCloudConfig(userData)
+	case r.isMultipart(userData):
+		return r.renderMultipart(userData)
+	case r.isShellScript(userData):
+		return r.renderShellScript(userData), nil
+	}
+
+	return r.wrapInMultipart(userData)
+}
+
+// isMultipart returns true if the user data given is a MIME message. cloud-init looks for the MIME-Version header
+// within the first 4096 bytes.
+//
+// PARAMETERS
+// userData []byte User data to check
+func (r *userDataRenderer) isMultipart(userData []byte) bool {
+	header := userData
+	if len(header) > 4096 {
+		header = header[:4096]
+	}
+
+	return bytes.Contains(bytes.ToLower(header), []byte("mime-version:"))
+}
+
+// isShellScript returns true if the user data given is a script interpreted by a POSIX compatible shell.
+//
+// PARAMETERS
+// userData []byte User data to check
+func (r *userDataRenderer) isShellScript(userData []byte) bool {
+	if !bytes.HasPrefix(userData, []byte("#!")) {
+		return false
+	}
+
+	shebang := string(userData[2:])
+	if index := strings.IndexByte(shebang, '\n'); index > -1 {
+		shebang = shebang[:index]
+	}
+
+	fields := strings.Fields(shebang)
+	if 0 == len(fields) {
+		return false
+	}
+
+	interpreter := path.Base(fields[0])
+
+	if "env" == interpreter && len(fields) > 1 {
+		interpreter = fields[1]
+	}
+
+	return userDataShellInterpreters[interpreter]
+}
+
+// renderShellScript returns the shell script given with commands to set the hostname and to authorize the SSH keys
+// inserted after the shebang line.
+//
+// PARAMETERS
+// userData []byte Shell script to render
+func (r *userDataRenderer) renderShellScript(userData []byte) []byte {
+	index := bytes.IndexByte(userData, '\n')
+	if -1 == index {
+		userData = append(userData, '\n')
+		index = len(userData) - 1
+	}
+
+	quotedHostname := quoteShellString(r.hostname)
+
+	var commands bytes.Buffer
+
+	commands.WriteString(fmt.Sprintf("echo %s > /etc/hostname\n", quotedHostname))
+	commands.WriteString(fmt.Sprintf("hostname %s\n", quotedHostname))
+
+	if len(r.sshKeys) > 0 {
+		commands.WriteString("mkdir -p /root/.ssh && chmod 700 /root/.ssh\n")
+
+		for _, sshKey := range r.sshKeys {
+			commands.WriteString(fmt.Sprintf("echo %s >> /root/.ssh/authorized_keys\n", quoteShellString(sshKey)))
+		}
+
+		commands.WriteString("chmod 600 /root/.ssh/authorized_keys\n")
+	}
+
+	rendered := make([]byte, 0, len(userData)+commands.Len())
+	rendered = append(rendered, userData[:index+1]...)
+	rendered = append(rendered, commands.Bytes()...)
+	rendered = append(rendered, userData[index+1:]...)
+
+	return rendered
+}
+
+// getYAMLMappingValue returns the value node of the key given or nil if it is not defined.
+//
+// PARAMETERS
+// mapping *yaml.Node YAML mapping node
+// key     string     Key to look up
+func getYAMLMappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if key == mapping.Content[i].Value {
+			return mapping.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// setYAMLMappingValue sets the value node of the key given.
+//
+// PARAMETERS
+// mapping *yaml.Node YAML mapping node
+// key     string     Key to set
+// value   *yaml.Node Value node
+func setYAMLMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if key == mapping.Content[i].Value {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// renderCloudConfig returns the cloud-config given with the hostname, FQDN and authorized SSH keys merged.
+//
+// PARAMETERS
+// userData []byte cloud-config to render
+func (r *userDataRenderer) renderCloudConfig(userData []byte) ([]byte, error) {
+	content := []byte{}
+	if index := bytes.IndexByte(userData, '\n'); index > -1 {
+		content = userData[index+1:]
+	}
+
+	var document yaml.Node
+
+	err := yaml.Unmarshal(content, &document)
+	if nil != err {
+		return nil, fmt.Errorf("userData #cloud-config given is invalid: %w", err)
+	}
+
+	if 0 == len(document.Content) {
+		document = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+
+	config := document.Content[0]
+	if yaml.MappingNode != config.Kind {
+		return nil, errors.New("userData #cloud-config given is not a mapping")
+	}
+
+	setYAMLMappingValue(config, "hostname", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: r.hostname})
+	setYAMLMappingValue(config, "preserve_hostname", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "false"})
+
+	// cloud-init prefers the FQDN over the hostname. Its host part is replaced while the domain is kept.
+	if fqdn := getYAMLMappingValue(config, "fqdn"); nil != fqdn {
+		value := r.hostname
+
+		if index := strings.IndexByte(fqdn.Value, '.'); index > -1 {
+			value += fqdn.Value[index:]
+		}
+
+		setYAMLMappingValue(config, "fqdn", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+	}
+
+	if len(r.sshKeys) > 0 {
+		sshKeys := getYAMLMappingValue(config, "ssh_authorized_keys")
+
+		if nil == sshKeys || yaml.SequenceNode != sshKeys.Kind {
+			sshKeys = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			setYAMLMappingValue(config, "ssh_authorized_keys", sshKeys)
+		}
+
+		authorizedKeys := map[string]bool{}
+
+		for _, node := range sshKeys.Content {
+			authorizedKeys[node.Value] = true
+		}
+
+		for _, sshKey := range r.sshKeys {
+			if !authorizedKeys[sshKey] {
+				sshKeys.Content = append(sshKeys.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: sshKey})
+				authorizedKeys[sshKey] = true
+			}
+		}
+	}
+
+	var buffer bytes.Buffer
+
+	buffer.WriteString(userDataCloudConfigHeader + "\n")
+
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+
+	err = encoder.Encode(&document)
+	if nil != err {
+		return nil, err
+	}
+
+	err = encoder.Close()
+	if nil != err {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// getCloudConfigPart returns the cloud-config MIME part containing the hostname and SSH keys.
+func (r *userDataRenderer) getCloudConfigPart() (textproto.MIMEHeader, []byte, error) {
+	content, err := r.renderCloudConfig([]byte(userDataCloudConfigHeader + "\n"))
+	if nil != err {
+		return nil, nil, err
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", `text/cloud-config; charset="utf-8"`)
+	header.Set("Merge-Type", userDataMergeType)
+
+	return header, content, nil
+}
+
+// renderMultipart returns the MIME multipart archive given with a cloud-config part appended.
+//
+// PARAMETERS
+// userData []byte MIME multipart archive to render
+func (r *userDataRenderer) renderMultipart(userData []byte) ([]byte, error) {
+	message, err := mail.ReadMessage(bytes.NewReader(userData))
+	if nil != err {
+		return nil, fmt.Errorf("userData MIME message given is invalid: %w", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if nil != err {
+		return nil, fmt.Errorf("userData MIME message given is invalid: %w", err)
+	} else if !strings.HasPrefix(mediaType, "multipart/") || "" == params["boundary"] {
+		return nil, fmt.Errorf("userData MIME message given has unsupported content type %q", mediaType)
+	}
+
+	boundary := params["boundary"]
+	reader := multipart.NewReader(message.Body, boundary)
+
+	for {
+		part, err := reader.NextPart()
+		if io.EOF == err {
+			break
+		} else if nil != err {
+			return nil, fmt.Errorf("userData MIME message given is invalid: %w", err)
+		}
+
+		_ = part.Close()
+	}
+
+	// The closing delimiter is preceded by a line break belonging to it. The part is inserted in front of it.
+	index := bytes.LastIndex(userData, []byte("--"+boundary+"--"))
+	if -1 == index {
+		return nil, errors.New("userData MIME message given is not terminated")
+	}
+
+	header, content, err := r.getCloudConfigPart()
+	if nil != err {
+		return nil, err
+	}
+
+	var part bytes.Buffer
+
+	part.WriteString("--" + boundary + "\r\n")
+
+	for _, key := range []string{"Content-Type", "Merge-Type"} {
+		part.WriteString(fmt.Sprintf("%s: %s\r\n", key, header.Get(key)))
+	}
+
+	part.WriteString("\r\n")
+	part.Write(content)
+	part.WriteString("\r\n")
+
+	rendered := make([]byte, 0, len(userData)+part.Len())
+	rendered = append(rendered, userData[:index]...)
+	rendered = append(rendered, part.Bytes()...)
+	rendered = append(rendered, userData[index:]...)
+
+	return rendered, nil
+}
+
+// wrapInMultipart returns a MIME multipart archive containing the user data given followed by a cloud-config part.
+// cloud-init detects the type of "text/plain" parts by their content.
+//
+// PARAMETERS
+// userData []byte User data to wrap
+func (r *userDataRenderer) wrapInMultipart(userData []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	writer := multipart.NewWriter(&buffer)
+
+	buffer.WriteString(fmt.Sprintf("Content-Type: multipart/mixed; boundary=\"%s\"\r\nMIME-Version: 1.0\r\n\r\n", writer.Boundary()))
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", `text/plain; charset="utf-8"`)
+
+	part, err := writer.CreatePart(header)
+	if nil != err {
+		return nil, err
+	}
+
+	_, err = part.Write(userData)
+	if nil != err {
+		return nil, err
+	}
+
+	header, content, err := r.getCloudConfigPart()
+	if nil != err {
+		return nil, err
+	}
+
+	part, err = writer.CreatePart(header)
+	if nil != err {
+		return nil, err
+	}
+
+	_, err = part.Write(content)
+	if nil != err {
+		return nil, err
+	}
+
+	err = writer.Close()
+	if nil != err {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// compressUserData returns the user data given gzip compressed if its base64 encoded size exceeds the IONOS limit.
+// cloud-init detects and decompresses gzip compressed user data.
+//
+// PARAMETERS
+// userData []byte User data to compress if required
+func compressUserData(userData []byte) ([]byte, error) {
+	size := base64.StdEncoding.EncodedLen(len(userData))
+	if size <= userDataMaxEncodedSize {
+		return userData, nil
+	}
+
+	var buffer bytes.Buffer
+
+	writer, err := gzip.NewWriterLevel(&buffer, gzip.BestCompression)
+	if nil != err {
+		return nil, err
+	}
+
+	_, err = writer.Write(userData)
+	if nil != err {
+		return nil, err
+	}
+
+	err = writer.Close()
+	if nil != err {
+		return nil, err
+	}
+
+	compressedSize := base64.StdEncoding.EncodedLen(buffer.Len())
+	if compressedSize > userDataMaxEncodedSize {
+		return nil, fmt.Errorf("userData size of %d bytes (%d bytes compressed) exceeds the allowed size of %d bytes", size, compressedSize, userDataMaxEncodedSize)
+	}
+
+	klog.V(3).Infof("Compressed userData from %d to %d bytes to fit into %d bytes", size, compressedSize, userDataMaxEncodedSize)
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,182 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ionos contains the IONOS provider specific implementations to manage machines
+package ionos
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"math/rand"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v3"
+)
+
+var _ = Describe("UserDataRenderer", func() {
+	renderer := newUserDataRenderer("machine-1", []string{"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIDummy user@example.com"})
+
+	type multipartPart struct {
+		contentType string
+		content     string
+	}
+
+	decodeCloudConfig := func(userData []byte) map[string]interface{} {
+		Expect(string(userData)).To(HavePrefix(userDataCloudConfigHeader + "\n"))
+
+		config := map[string]interface{}{}
+		Expect(yaml.Unmarshal(userData, &config)).To(Succeed())
+
+		return config
+	}
+
+	decodeMultipart := func(userData []byte) []multipartPart {
+		message, err := mail.ReadMessage(bytes.NewReader(userData))
+		Expect(err).NotTo(HaveOccurred())
+
+		mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mediaType).To(Equal("multipart/mixed"))
+
+		parts := []multipartPart{}
+		reader := multipart.NewReader(message.Body, params["boundary"])
+
+		for {
+			part, err := reader.NextPart()
+			if io.EOF == err {
+				break
+			}
+			Expect(err).NotTo(HaveOccurred())
+
+			content, err := io.ReadAll(part)
+			Expect(err).NotTo(HaveOccurred())
+
+			parts = append(parts, multipartPart{contentType: part.Header.Get("Content-Type"), content: string(content)})
+		}
+
+		return parts
+	}
+
+	Describe("#render", func() {
+		It("should inject commands after the shebang of shell scripts", func() {
+			userData, err := renderer.render([]byte("#!/bin/bash\nset -e\nexit 0\n"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(userData)).To(Equal("#!/bin/bash\n" +
+				"echo 'machine-1' > /etc/hostname\n" +
+				"hostname 'machine-1'\n" +
+				"mkdir -p /root/.ssh && chmod 700 /root/.ssh\n" +
+				"echo 'ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIDummy user@example.com' >> /root/.ssh/authorized_keys\n" +
+				"chmod 600 /root/.ssh/authorized_keys\n" +
+				"set -e\nexit 0\n"))
+		})
+
+		It("should merge the hostname, FQDN and SSH keys into cloud-config", func() {
+			userData, err := renderer.render([]byte("#cloud-config\nhostname: other\nfqdn: other.example.com\nssh_authorized_keys:\n- ssh-rsa AAAAB3NzaC1yc2EDummy\nruncmd:\n- [echo, 1000000]\n"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(decodeCloudConfig(userData)).To(Equal(map[string]interface{}{
+				"hostname":          "machine-1",
+				"fqdn":              "machine-1.example.com",
+				"preserve_hostname": false,
+				"ssh_authorized_keys": []interface{}{
+					"ssh-rsa AAAAB3NzaC1yc2EDummy",
+					"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIDummy user@example.com",
+				},
+				"runcmd": []interface{}{[]interface{}{"echo", 1000000}},
+			}))
+		})
+
+		It("should reject cloud-config not being a mapping", func() {
+			_, err := renderer.render([]byte("#cloud-config\n- invalid\n"))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should append a cloud-config part to MIME multipart archives", func() {
+			userData, err := renderer.render([]byte("Content-Type: multipart/mixed; boundary=\"BOUNDARY\"\r\nMIME-Version: 1.0\r\n\r\n" +
+				"--BOUNDARY\r\nContent-Type: text/x-shellscript\r\n\r\n#!/usr/bin/env python3\nprint(1)\n\r\n--BOUNDARY--\r\n"))
+			Expect(err).NotTo(HaveOccurred())
+
+			parts := decodeMultipart(userData)
+			Expect(parts).To(HaveLen(2))
+			Expect(parts[0]).To(Equal(multipartPart{contentType: "text/x-shellscript", content: "#!/usr/bin/env python3\nprint(1)\n"}))
+			Expect(parts[1].contentType).To(HavePrefix("text/cloud-config"))
+			Expect(decodeCloudConfig([]byte(parts[1].content))).To(HaveKeyWithValue("hostname", "machine-1"))
+		})
+
+		It("should reject MIME messages not being multipart", func() {
+			_, err := renderer.render([]byte("Content-Type: text/x-shellscript\r\nMIME-Version: 1.0\r\n\r\n#!/bin/sh\n"))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should wrap scripts of other interpreters into MIME multipart archives", func() {
+			userData, err := renderer.render([]byte("#!/usr/bin/env python3\nprint(1)\n"))
+			Expect(err).NotTo(HaveOccurred())
+
+			parts := decodeMultipart(userData)
+			Expect(parts).To(HaveLen(2))
+			Expect(parts[0].content).To(Equal("#!/usr/bin/env python3\nprint(1)\n"))
+			Expect(decodeCloudConfig([]byte(parts[1].content))).To(HaveKeyWithValue("hostname", "machine-1"))
+		})
+
+		It("should decompress gzip compressed user data", func() {
+			var buffer bytes.Buffer
+
+			writer := gzip.NewWriter(&buffer)
+			_, err := writer.Write([]byte("#!/bin/sh\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(writer.Close()).To(Succeed())
+
+			userData, err := renderer.render(buffer.Bytes())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(userData)).To(HavePrefix("#!/bin/sh\necho 'machine-1' > /etc/hostname\n"))
+		})
+	})
+
+	Describe("#compressUserData", func() {
+		It("should keep user data fitting into the IONOS limit", func() {
+			userData := []byte("#!/bin/sh\n")
+
+			Expect(compressUserData(userData)).To(Equal(userData))
+		})
+
+		It("should gzip compress oversized user data", func() {
+			userData := bytes.Repeat([]byte("echo 'bootstrap'\n"), userDataMaxEncodedSize)
+
+			compressed, err := compressUserData(userData)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(base64.StdEncoding.EncodedLen(len(compressed))).To(BeNumerically("<=", userDataMaxEncodedSize))
+
+			reader, err := gzip.NewReader(bytes.NewReader(compressed))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(io.ReadAll(reader)).To(Equal(userData))
+		})
+
+		It("should fail if compressed user data still exceeds the IONOS limit", func() {
+			userData := make([]byte, userDataMaxEncodedSize)
+			_, _ = rand.New(rand.NewSource(1)).Read(userData)
+
+			_, err := compressUserData(userData)
+			Expect(err).To(MatchError(ContainSubstring("exceeds the allowed size of 65536 bytes")))
+		})
+	})
+})
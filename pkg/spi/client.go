@@ -0,0 +1,216 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package spi provides the session interface used to access the IONOS API
+package spi
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis"
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/component-base/version"
+	"k8s.io/klog/v2"
+)
+
+// Constant userAgentProgramName is the program name sent as part of the User-Agent header
+const userAgentProgramName = "machine-controller-manager-provider-ionos"
+
+// ionosClientCache caches IONOS clients shared by all concurrent requests
+var ionosClientCache = newClientCache(newClientForConfiguration)
+
+// clientConfiguration contains the IONOS API endpoint, transport settings and credentials of a client
+type clientConfiguration struct {
+	apis.ClientSettings
+	Credentials apis.Credentials
+}
+
+// clientCache is a thread-safe cache of IONOS clients keyed by a hash of the endpoint and credentials used. Each
+// owner (usually a secret) references at most one cached client. A client is evicted if the credentials of its
+// owner have been rotated. Credentials without a known owner share the empty owner and only their latest client is
+// kept.
+type clientCache struct {
+	mutex     sync.Mutex
+	clients   map[string]*ionossdk.APIClient
+	ownerKeys map[string]string
+	newClient func(configuration *clientConfiguration) (*ionossdk.APIClient, error)
+}
+
+// newClientCache returns a new, empty IONOS client cache.
+//
+// PARAMETERS
+// newClient func(*clientConfiguration) (*ionossdk.APIClient, error) Function to create new clients with
+func newClientCache(newClient func(configuration *clientConfiguration) (*ionossdk.APIClient, error)) *clientCache {
+	return &clientCache{
+		clients:   map[string]*ionossdk.APIClient{},
+		ownerKeys: map[string]string{},
+		newClient: newClient,
+	}
+}
+
+// get returns the cached IONOS client for the configuration given or creates a new one. A client previously cached
+// for the owner given with a different configuration is evicted. This applies to the empty owner as well.
+//
+// PARAMETERS
+// owner         string               Owner of the credentials or an empty string if unknown
+// configuration *clientConfiguration IONOS API client configuration
+func (c *clientCache) get(owner string, configuration *clientConfiguration) (*ionossdk.APIClient, error) {
+	key := getClientCacheKey(configuration)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	client, ok := c.clients[key]
+
+	if ok {
+		clientCacheHits.Inc()
+	} else {
+		clientCacheMisses.Inc()
+
+		var err error
+
+		client, err = c.newClient(configuration)
+		if nil != err {
+			return nil, err
+		}
+
+		c.clients[key] = client
+	}
+
+	previousKey, ok := c.ownerKeys[owner]
+	c.ownerKeys[owner] = key
+
+	if ok && key != previousKey {
+		if "" != owner {
+			klog.V(3).Infof("Credentials of %s have been rotated", owner)
+		}
+
+		c.evict(previousKey)
+	}
+
+	return client, nil
+}
+
+// evict removes the client cached for the key given unless it is still referenced by an owner. The mutex must be
+// held by the caller.
+//
+// PARAMETERS
+// key string Client cache key
+func (c *clientCache) evict(key string) {
+	for _, ownerKey := range c.ownerKeys {
+		if key == ownerKey {
+			return
+		}
+	}
+
+	if _, ok := c.clients[key]; ok {
+		delete(c.clients, key)
+		clientCacheEvictions.Inc()
+	}
+}
+
+// getClientCacheKey returns the cache key for the client configuration given.
+//
+// PARAMETERS
+// configuration *clientConfiguration IONOS API client configuration
+func getClientCacheKey(configuration *clientConfiguration) string {
+	hash := sha256.New()
+
+	for _, value := range []string{
+		configuration.APIURL,
+		string(configuration.CABundle),
+		configuration.ProxyURL,
+		configuration.RequestTimeout.String(),
+		configuration.Credentials.Token,
+		configuration.Credentials.User,
+		configuration.Credentials.Password,
+	} {
+		hash.Write([]byte(value))
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// getClientCacheOwner returns the owner of the credentials given used to detect credential rotations.
+//
+// PARAMETERS
+// secret      *corev1.Secret    Kubernetes secret that contains the credentials
+// credentials *apis.Credentials IONOS API credentials
+func getClientCacheOwner(secret *corev1.Secret, credentials *apis.Credentials) string {
+	if "" != secret.Name {
+		return fmt.Sprintf("secret %s/%s", secret.Namespace, secret.Name)
+	} else if !credentials.IsTokenBased() {
+		return fmt.Sprintf("user %s", credentials.User)
+	}
+
+	return ""
+}
+
+// getUserAgent returns the User-Agent header value identifying the provider version.
+//
+// PARAMETERS
+// sdkUserAgent string User-Agent of the IONOS SDK
+func getUserAgent(sdkUserAgent string) string {
+	return fmt.Sprintf("%s/%s %s", userAgentProgramName, version.Get().GitVersion, sdkUserAgent)
+}
+
+// newClientForConfiguration returns a new IONOS client for the configuration given.
+//
+// PARAMETERS
+// configuration *clientConfiguration IONOS API client configuration
+func newClientForConfiguration(configuration *clientConfiguration) (*ionossdk.APIClient, error) {
+	credentials := configuration.Credentials
+
+	config := ionossdk.NewConfiguration(credentials.User, credentials.Password, credentials.Token, configuration.APIURL)
+	config.UserAgent = getUserAgent(config.UserAgent)
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if len(configuration.CABundle) > 0 {
+		rootCAs := x509.NewCertPool()
+
+		if !rootCAs.AppendCertsFromPEM(configuration.CABundle) {
+			return nil, fmt.Errorf("IONOS API CA bundle does not contain any PEM encoded certificate")
+		}
+
+		transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}
+	}
+
+	if "" != configuration.ProxyURL {
+		proxyURL, err := url.Parse(configuration.ProxyURL)
+		if nil != err {
+			return nil, fmt.Errorf("IONOS API proxy URL is invalid: %v", err)
+		}
+
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	config.HTTPClient = &http.Client{
+		Timeout:   configuration.RequestTimeout,
+		Transport: transport,
+	}
+
+	return ionossdk.NewAPIClient(config), nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package spi provides the session interface used to access the IONOS API
+package spi
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis"
+	"github.com/spf13/pflag"
+)
+
+// ClientOptions contains the IONOS API client settings configured by command line flags. Settings given in the
+// secret of a machine class take precedence.
+type ClientOptions struct {
+	APIURL         string
+	CABundleFile   string
+	ProxyURL       string
+	RequestTimeout time.Duration
+
+	settingsOnce sync.Once
+	settings     *apis.ClientSettings
+	settingsErr  error
+}
+
+// AddFlags adds the IONOS API client flags to the flag set given.
+//
+// PARAMETERS
+// fs *pflag.FlagSet Flag set to add flags to
+func (o *ClientOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.APIURL, "ionos-api-url", o.APIURL, "IONOS API URL to use instead of the default one")
+	fs.StringVar(&o.CABundleFile, "ionos-ca-bundle", o.CABundleFile, "Path of a PEM encoded CA bundle trusted for the IONOS API")
+	fs.StringVar(&o.ProxyURL, "ionos-proxy-url", o.ProxyURL, "URL of the proxy used to access the IONOS API")
+	fs.DurationVar(&o.RequestTimeout, "ionos-request-timeout", o.RequestTimeout, "Timeout of IONOS API requests (0 to disable)")
+}
+
+// GetClientSettings returns the validated IONOS API client settings configured. The settings are loaded on the first
+// call after the flags have been parsed and reused afterwards. The CA bundle file is therefore read only once.
+func (o *ClientOptions) GetClientSettings() (*apis.ClientSettings, error) {
+	o.settingsOnce.Do(func() {
+		o.settings, o.settingsErr = o.loadClientSettings()
+	})
+
+	return o.settings, o.settingsErr
+}
+
+// loadClientSettings reads and validates the IONOS API client settings configured.
+func (o *ClientOptions) loadClientSettings() (*apis.ClientSettings, error) {
+	settings := &apis.ClientSettings{
+		APIURL:         o.APIURL,
+		ProxyURL:       o.ProxyURL,
+		RequestTimeout: o.RequestTimeout,
+	}
+
+	if "" != o.CABundleFile {
+		caBundle, err := os.ReadFile(o.CABundleFile)
+		if nil != err {
+			return nil, fmt.Errorf("Failed to read IONOS API CA bundle: %v", err)
+		}
+
+		settings.CABundle = caBundle
+	}
+
+	err := settings.Validate()
+	if nil != err {
+		return nil, err
+	}
+
+	return settings, nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package spi provides the session interface used to access the IONOS API
+package spi
+
+import (
+	"sync"
+
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis"
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// getCounterValue returns the current value of the counter given.
+//
+// PARAMETERS
+// counter prometheus.Counter Counter to read
+func getCounterValue(counter prometheus.Counter) float64 {
+	metric := &dto.Metric{}
+	Expect(counter.Write(metric)).To(Succeed())
+
+	return metric.GetCounter().GetValue()
+}
+
+var _ = Describe("ClientCache", func() {
+	var (
+		cache          *clientCache
+		configurations []*clientConfiguration
+		mutex          sync.Mutex
+	)
+
+	newConfiguration := func(apiURL string, credentials apis.Credentials) *clientConfiguration {
+		return &clientConfiguration{ClientSettings: apis.ClientSettings{APIURL: apiURL}, Credentials: credentials}
+	}
+
+	var _ = BeforeEach(func() {
+		configurations = []*clientConfiguration{}
+
+		cache = newClientCache(func(configuration *clientConfiguration) (*ionossdk.APIClient, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			configurations = append(configurations, configuration)
+
+			return newClientForConfiguration(configuration)
+		})
+	})
+
+	Describe("#get", func() {
+		It("should return the same client for concurrent requests with the same credentials", func() {
+			hits := getCounterValue(clientCacheHits)
+			configuration := newConfiguration("", apis.Credentials{User: "dummy-user", Password: "dummy-password"})
+			clients := make(chan *ionossdk.APIClient, 32)
+
+			var waitGroup sync.WaitGroup
+
+			for i := 0; i < cap(clients); i++ {
+				waitGroup.Add(1)
+
+				go func() {
+					defer GinkgoRecover()
+					defer waitGroup.Done()
+
+					client, err := cache.get("secret default/ionos", configuration)
+					Expect(err).NotTo(HaveOccurred())
+
+					clients <- client
+				}()
+			}
+
+			waitGroup.Wait()
+			close(clients)
+
+			first := <-clients
+			for client := range clients {
+				Expect(client).To(BeIdenticalTo(first))
+			}
+
+			Expect(configurations).To(HaveLen(1))
+			Expect(getCounterValue(clientCacheHits) - hits).To(Equal(float64(31)))
+		})
+
+		It("should evict the client of rotated credentials", func() {
+			evictions := getCounterValue(clientCacheEvictions)
+
+			first, err := cache.get("secret default/ionos", newConfiguration("", apis.Credentials{User: "dummy-user", Password: "dummy-password"}))
+			Expect(err).NotTo(HaveOccurred())
+			second, err := cache.get("secret default/ionos", newConfiguration("", apis.Credentials{User: "dummy-user", Password: "rotated-password"}))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(second).NotTo(BeIdenticalTo(first))
+			Expect(cache.clients).To(HaveLen(1))
+			Expect(getCounterValue(clientCacheEvictions) - evictions).To(Equal(float64(1)))
+		})
+
+		It("should keep clients still referenced by another owner", func() {
+			evictions := getCounterValue(clientCacheEvictions)
+			configuration := newConfiguration("", apis.Credentials{User: "dummy-user", Password: "dummy-password"})
+
+			_, _ = cache.get("secret default/first", configuration)
+			_, _ = cache.get("secret default/second", configuration)
+			_, _ = cache.get("secret default/first", newConfiguration("", apis.Credentials{Token: "dummy-token"}))
+
+			Expect(cache.clients).To(HaveLen(2))
+			Expect(getCounterValue(clientCacheEvictions) - evictions).To(Equal(float64(0)))
+		})
+
+		It("should only keep the latest client of credentials without owner", func() {
+			evictions := getCounterValue(clientCacheEvictions)
+
+			for _, token := range []string{"first-token", "second-token", "third-token"} {
+				_, err := cache.get("", newConfiguration("", apis.Credentials{Token: token}))
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			Expect(cache.clients).To(HaveLen(1))
+			Expect(getCounterValue(clientCacheEvictions) - evictions).To(Equal(float64(2)))
+		})
+
+		It("should use different clients for different endpoints", func() {
+			credentials := apis.Credentials{Token: "dummy-token"}
+
+			first, err := cache.get("", newConfiguration("https://api.example.com/cloudapi/v6", credentials))
+			Expect(err).NotTo(HaveOccurred())
+			second, err := cache.get("", newConfiguration("https://api.example.org/cloudapi/v6", credentials))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(second).NotTo(BeIdenticalTo(first))
+			Expect(configurations).To(HaveLen(2))
+		})
+	})
+})
@@ -0,0 +1,164 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package spi provides the session interface used to access the IONOS API
+package spi
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
+)
+
+// ipInUseMessages contains lowercase fragments of IONOS API error messages reporting an IP already in use
+var ipInUseMessages = []string{"already in use", "already used"}
+
+// APIError is an error returned by the IONOS API together with its HTTP status code
+type APIError struct {
+	StatusCode int
+	Err        error
+}
+
+// Error returns the error message of the underlying error.
+func (e *APIError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// newAPIError returns an *APIError for the IONOS API response and error given. The error is returned unchanged if
+// no HTTP status code is known.
+//
+// PARAMETERS
+// httpResponse *ionossdk.APIResponse IONOS API response
+// err          error                 Error returned by the IONOS SDK
+func newAPIError(httpResponse *ionossdk.APIResponse, err error) error {
+	if nil == err {
+		return nil
+	}
+
+	if nil == httpResponse || nil == httpResponse.Response {
+		return err
+	}
+
+	return &APIError{StatusCode: httpResponse.StatusCode, Err: err}
+}
+
+// GetErrorStatusCode returns the HTTP status code of the error given or 0 if it is unknown.
+//
+// PARAMETERS
+// err error Error to check
+func GetErrorStatusCode(err error) int {
+	var apiErr *APIError
+
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+
+	return 0
+}
+
+// IsTransientError returns true if the IONOS API request may succeed if repeated. Errors without HTTP status code,
+// rate limited requests and server errors are transient. Asynchronous requests failed are not.
+//
+// PARAMETERS
+// err error Error to check
+func IsTransientError(err error) bool {
+	var requestErr *RequestFailedError
+
+	if errors.As(err, &requestErr) {
+		return false
+	}
+
+	statusCode := GetErrorStatusCode(err)
+	return 0 == statusCode || http.StatusTooManyRequests == statusCode || statusCode >= http.StatusInternalServerError
+}
+
+// IsNotFoundError returns true if the IONOS API reported that the resource requested does not exist.
+//
+// PARAMETERS
+// err error Error to check
+func IsNotFoundError(err error) bool {
+	return http.StatusNotFound == GetErrorStatusCode(err)
+}
+
+// GetErrorMessages returns the error messages reported by the IONOS API for the error given. The message of
+// asynchronous requests failed is returned for *RequestFailedError.
+//
+// PARAMETERS
+// err error Error to check
+func GetErrorMessages(err error) []string {
+	var requestErr *RequestFailedError
+
+	if errors.As(err, &requestErr) {
+		return []string{requestErr.Message}
+	}
+
+	var openAPIErr ionossdk.GenericOpenAPIError
+
+	if !errors.As(err, &openAPIErr) {
+		return []string{}
+	}
+
+	errModel, ok := openAPIErr.Model().(ionossdk.Error)
+	if !ok || !errModel.HasMessages() {
+		return []string{}
+	}
+
+	messages := []string{}
+
+	for _, errMessage := range *errModel.Messages {
+		if errMessage.HasMessage() {
+			messages = append(messages, *errMessage.Message)
+		}
+	}
+
+	return messages
+}
+
+// IsIPInUseError returns true if the IONOS API reported that an IP requested is already in use. The error is either
+// returned for the request itself with HTTP status code 409 or 422 or for its asynchronous execution.
+//
+// PARAMETERS
+// err error Error to check
+func IsIPInUseError(err error) bool {
+	var requestErr *RequestFailedError
+
+	if !errors.As(err, &requestErr) {
+		statusCode := GetErrorStatusCode(err)
+
+		if http.StatusConflict != statusCode && http.StatusUnprocessableEntity != statusCode {
+			return false
+		}
+	}
+
+	for _, message := range GetErrorMessages(err) {
+		message = strings.ToLower(message)
+
+		for _, ipInUseMessage := range ipInUseMessages {
+			if strings.Contains(message, ipInUseMessage) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,57 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package spi provides the session interface used to access the IONOS API
+package spi
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// metricsNamespace is the namespace of all metrics exported by this provider
+	metricsNamespace = "mcm"
+	// metricsSubsystem is the subsystem of all metrics exported by this provider
+	metricsSubsystem = "ionos"
+)
+
+var (
+	// clientCacheHits counts IONOS clients returned from the client cache
+	clientCacheHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "client_cache_hits_total",
+		Help:      "Number of IONOS clients returned from the client cache.",
+	})
+	// clientCacheMisses counts IONOS clients created because none was cached
+	clientCacheMisses = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "client_cache_misses_total",
+		Help:      "Number of IONOS clients created because none was cached.",
+	})
+	// clientCacheEvictions counts IONOS clients evicted after credentials have been rotated
+	clientCacheEvictions = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "client_cache_evictions_total",
+		Help:      "Number of IONOS clients evicted from the client cache after credentials have been rotated.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(clientCacheHits, clientCacheMisses, clientCacheEvictions)
+}
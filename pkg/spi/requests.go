@@ -0,0 +1,251 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package spi provides the session interface used to access the IONOS API
+package spi
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// Constant requestPollInitialInterval is the time to wait before polling a request status again
+	requestPollInitialInterval = 500 * time.Millisecond
+	// Constant requestPollMaxInterval is the maximum time to wait between polling a request status
+	requestPollMaxInterval = 15 * time.Second
+	// Constant requestPollBackoffFactor is the factor the polling interval is multiplied with after each poll
+	requestPollBackoffFactor = 2
+	// Constant requestPollTimeout is the maximum time to wait for requests if the context has no deadline
+	requestPollTimeout = 10 * time.Minute
+)
+
+// RequestFailedError is returned if an asynchronous IONOS API request failed
+type RequestFailedError struct {
+	RequestID string
+	Message   string
+}
+
+// Error returns the error message reported for the request.
+func (e *RequestFailedError) Error() string {
+	return fmt.Sprintf("request %s failed: %s", e.RequestID, e.Message)
+}
+
+// requestTracker records the asynchronous IONOS API requests pending for a resource
+type requestTracker struct {
+	mutex   sync.Mutex
+	pending map[string][]string
+}
+
+// newRequestTracker returns a new request tracker without pending requests.
+func newRequestTracker() *requestTracker {
+	return &requestTracker{pending: map[string][]string{}}
+}
+
+// getResourceKey returns the key of the resource identified by the path segments given.
+//
+// PARAMETERS
+// segments ...string Resource path segments
+func getResourceKey(segments ...string) string {
+	return strings.Join(segments, "/")
+}
+
+// getRequestID returns the ID of the request referenced by the Location header of the IONOS API response given or
+// an empty string if it is not present.
+//
+// PARAMETERS
+// httpResponse *ionossdk.APIResponse IONOS API response
+func getRequestID(httpResponse *ionossdk.APIResponse) string {
+	if nil == httpResponse || nil == httpResponse.Response {
+		return ""
+	}
+
+	location, err := url.Parse(httpResponse.Header.Get("Location"))
+	if nil != err {
+		return ""
+	}
+
+	// The Location header references the request status, e.g. "<API URL>/requests/<ID>/status"
+	segments := strings.Split(strings.Trim(location.Path, "/"), "/")
+
+	for index := len(segments) - 2; index >= 0; index-- {
+		if "requests" == segments[index] {
+			return segments[index+1]
+		}
+	}
+
+	return ""
+}
+
+// track records the request referenced by the IONOS API response given as pending for all resources given.
+//
+// PARAMETERS
+// httpResponse *ionossdk.APIResponse IONOS API response
+// resourceKeys ...string             Keys of the resources modified by the request
+func (t *requestTracker) track(httpResponse *ionossdk.APIResponse, resourceKeys ...string) {
+	requestID := getRequestID(httpResponse)
+	if "" == requestID {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, resourceKey := range resourceKeys {
+		t.pending[resourceKey] = append(t.pending[resourceKey], requestID)
+	}
+}
+
+// take returns and forgets the requests pending for the resource given.
+//
+// PARAMETERS
+// resourceKey string Resource key
+func (t *requestTracker) take(resourceKey string) []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	requestIDs := t.pending[resourceKey]
+	delete(t.pending, resourceKey)
+
+	return requestIDs
+}
+
+// pollWithBackoff calls the condition given with an exponentially growing interval until it is done, fails or the
+// context is done.
+//
+// PARAMETERS
+// ctx       context.Context      Execution context
+// condition func() (bool, error) Condition returning true once done
+func pollWithBackoff(ctx context.Context, condition func() (bool, error)) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, requestPollTimeout)
+		defer cancel()
+	}
+
+	interval := requestPollInitialInterval
+
+	for {
+		done, err := condition()
+		if nil != err || done {
+			return err
+		}
+
+		timer := time.NewTimer(interval)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		interval *= requestPollBackoffFactor
+
+		if interval > requestPollMaxInterval {
+			interval = requestPollMaxInterval
+		}
+	}
+}
+
+// waitForRequests waits until all requests pending for the resource given have been executed.
+//
+// PARAMETERS
+// ctx         context.Context Execution context
+// resourceKey string          Resource key
+func (s *clientSession) waitForRequests(ctx context.Context, resourceKey string) error {
+	for _, requestID := range s.requests.take(resourceKey) {
+		err := s.waitForRequest(ctx, requestID)
+		if nil != err {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// waitForRequest waits until the request with the ID given has been executed.
+//
+// PARAMETERS
+// ctx       context.Context Execution context
+// requestID string          Request ID
+func (s *clientSession) waitForRequest(ctx context.Context, requestID string) error {
+	return pollWithBackoff(ctx, func() (bool, error) {
+		requestStatus, err := s.GetRequestStatus(ctx, requestID)
+		if nil != err {
+			if !IsTransientError(err) {
+				return false, err
+			}
+
+			klog.V(3).Infof("Failed to get the status of request %s, retrying: %v", requestID, err)
+			return false, nil
+		}
+
+		if !requestStatus.HasMetadata() || !requestStatus.Metadata.HasStatus() {
+			return false, nil
+		}
+
+		metadata := requestStatus.Metadata
+
+		switch *metadata.Status {
+		case ionossdk.RequestStatusDone:
+			return true, nil
+		case ionossdk.RequestStatusFailed:
+			message := "no error message reported"
+
+			if metadata.HasMessage() {
+				message = *metadata.Message
+			}
+
+			return false, &RequestFailedError{RequestID: requestID, Message: message}
+		}
+
+		klog.V(3).Infof("Waiting for request %s being %s", requestID, *metadata.Status)
+
+		return false, nil
+	})
+}
+
+// waitForResource waits until all requests pending for the resource given have been executed and its state is no
+// longer BUSY. The resource state is polled for modifications requested by other sessions.
+//
+// PARAMETERS
+// ctx         context.Context                                     Execution context
+// resourceKey string                                              Resource key
+// get         func() (*ionossdk.DatacenterElementMetadata, error) Function returning the current resource metadata
+func (s *clientSession) waitForResource(ctx context.Context, resourceKey string, get func() (*ionossdk.DatacenterElementMetadata, error)) error {
+	err := s.waitForRequests(ctx, resourceKey)
+	if nil != err {
+		return err
+	}
+
+	return pollWithBackoff(ctx, func() (bool, error) {
+		metadata, err := get()
+		if nil != err {
+			return false, err
+		}
+
+		return nil == metadata || !metadata.HasState() || "BUSY" != *metadata.State, nil
+	})
+}
@@ -0,0 +1,204 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package spi provides the session interface used to access the IONOS API
+package spi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RequestTracker", func() {
+	var (
+		mutex         sync.Mutex
+		requestStatus []string
+		requestPolls  int
+		resourcePolls int
+		server        *httptest.Server
+		session       Session
+	)
+
+	writeJSON := func(res http.ResponseWriter, statusCode int, body string) {
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(statusCode)
+		_, _ = res.Write([]byte(body))
+	}
+
+	var _ = BeforeEach(func() {
+		requestStatus = []string{"DONE"}
+		requestPolls = 0
+		resourcePolls = 0
+
+		mux := http.NewServeMux()
+		server = httptest.NewServer(mux)
+
+		mux.HandleFunc("/cloudapi/v6/datacenters/dc/volumes", func(res http.ResponseWriter, req *http.Request) {
+			res.Header().Set("Location", fmt.Sprintf("%s/cloudapi/v6/requests/request-1/status", server.URL))
+			writeJSON(res, http.StatusAccepted, `{"id": "volume-1", "metadata": {"state": "BUSY"}}`)
+		})
+
+		mux.HandleFunc("/cloudapi/v6/datacenters/dc/volumes/volume-1/labels", func(res http.ResponseWriter, req *http.Request) {
+			res.Header().Set("Location", fmt.Sprintf("%s/cloudapi/v6/requests/request-1/status", server.URL))
+			writeJSON(res, http.StatusAccepted, `{"id": "role", "properties": {"key": "role", "value": "node"}}`)
+		})
+
+		mux.HandleFunc("/cloudapi/v6/datacenters/dc/volumes/volume-1", func(res http.ResponseWriter, req *http.Request) {
+			mutex.Lock()
+			resourcePolls++
+			mutex.Unlock()
+
+			writeJSON(res, http.StatusOK, `{"id": "volume-1", "metadata": {"state": "AVAILABLE"}}`)
+		})
+
+		mux.HandleFunc("/cloudapi/v6/requests/request-1/status", func(res http.ResponseWriter, req *http.Request) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			status := requestStatus[0]
+
+			if len(requestStatus) > 1 {
+				requestStatus = requestStatus[1:]
+			}
+
+			requestPolls++
+
+			// Status codes are returned as HTTP errors instead of request states
+			if statusCode, err := strconv.Atoi(status); nil == err {
+				writeJSON(res, statusCode, fmt.Sprintf(`{"httpStatus": %d}`, statusCode))
+				return
+			}
+
+			writeJSON(res, http.StatusOK, fmt.Sprintf(`{"id": "request-1", "metadata": {"status": %q, "message": "Request is %s"}}`, status, status))
+		})
+
+		session = NewClientSession(ionossdk.NewAPIClient(ionossdk.NewConfiguration("dummy-user", "dummy-password", "", server.URL)))
+	})
+
+	var _ = AfterEach(func() {
+		server.Close()
+	})
+
+	createVolume := func() {
+		_, err := session.CreateVolume(context.Background(), "dc", ionossdk.Volume{Properties: &ionossdk.VolumeProperties{Size: ionossdk.PtrFloat32(10)}})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	Describe("#getRequestID", func() {
+		It("should return the request ID of the Location header", func() {
+			httpResponse := &ionossdk.APIResponse{Response: &http.Response{Header: http.Header{}}}
+			httpResponse.Header.Set("Location", "https://api.ionos.com/cloudapi/v6/requests/3f6c2c2e-1a2b/status")
+
+			Expect(getRequestID(httpResponse)).To(Equal("3f6c2c2e-1a2b"))
+		})
+
+		It("should return an empty string without Location header", func() {
+			Expect(getRequestID(&ionossdk.APIResponse{Response: &http.Response{Header: http.Header{}}})).To(BeEmpty())
+			Expect(getRequestID(nil)).To(BeEmpty())
+		})
+	})
+
+	Describe("#WaitForVolume", func() {
+		It("should poll the request status until the request is done", func() {
+			requestStatus = []string{"QUEUED", "RUNNING", "DONE"}
+			createVolume()
+
+			volume, err := session.WaitForVolume(context.Background(), "dc", "volume-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*volume.Metadata.State).To(Equal("AVAILABLE"))
+
+			Expect(requestPolls).To(Equal(3))
+			Expect(resourcePolls).To(Equal(1))
+		})
+
+		It("should return the error message of failed requests", func() {
+			requestStatus = []string{"RUNNING", "FAILED"}
+			createVolume()
+
+			_, err := session.WaitForVolume(context.Background(), "dc", "volume-1")
+
+			var requestErr *RequestFailedError
+			Expect(errors.As(err, &requestErr)).To(BeTrue())
+			Expect(requestErr.RequestID).To(Equal("request-1"))
+			Expect(requestErr.Message).To(Equal("Request is FAILED"))
+			Expect(resourcePolls).To(Equal(0))
+		})
+
+		It("should retry transient errors getting the request status", func() {
+			requestStatus = []string{"RUNNING", "500", "DONE"}
+			createVolume()
+
+			_, err := session.WaitForVolume(context.Background(), "dc", "volume-1")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requestPolls).To(Equal(3))
+			Expect(resourcePolls).To(Equal(1))
+		})
+
+		It("should fail if the request does not exist", func() {
+			requestStatus = []string{"RUNNING", "404"}
+			createVolume()
+
+			_, err := session.WaitForVolume(context.Background(), "dc", "volume-1")
+			Expect(IsNotFoundError(err)).To(BeTrue())
+
+			Expect(requestPolls).To(Equal(2))
+			Expect(resourcePolls).To(Equal(0))
+		})
+
+		It("should wait for labels added", func() {
+			requestStatus = []string{"RUNNING", "DONE"}
+
+			err := session.AddVolumeLabel(context.Background(), "dc", "volume-1", "role", "node")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = session.WaitForVolume(context.Background(), "dc", "volume-1")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requestPolls).To(Equal(2))
+		})
+
+		It("should honour the context deadline", func() {
+			requestStatus = []string{"RUNNING"}
+			createVolume()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			_, err := session.WaitForVolume(ctx, "dc", "volume-1")
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+		})
+
+		It("should poll the resource state without pending requests", func() {
+			volume, err := session.WaitForVolume(context.Background(), "dc", "volume-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*volume.Id).To(Equal("volume-1"))
+
+			Expect(requestPolls).To(Equal(0))
+			Expect(resourcePolls).To(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,528 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package spi provides the session interface used to access the IONOS API
+package spi
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
+)
+
+// clientSession implements Session based on an IONOS SDK client
+type clientSession struct {
+	client   *ionossdk.APIClient
+	requests *requestTracker
+}
+
+// NewClientSession returns a session using the IONOS client given.
+//
+// PARAMETERS
+// client *ionossdk.APIClient IONOS client
+func NewClientSession(client *ionossdk.APIClient) Session {
+	return &clientSession{client: client, requests: newRequestTracker()}
+}
+
+// GetDatacenter returns the datacenter with the ID given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+func (s *clientSession) GetDatacenter(ctx context.Context, datacenterID string) (ionossdk.Datacenter, error) {
+	datacenter, httpResponse, err := s.client.DataCentersApi.DatacentersFindById(ctx, datacenterID).Execute()
+	return datacenter, newAPIError(httpResponse, err)
+}
+
+// GetImage returns the image with the ID given.
+//
+// PARAMETERS
+// ctx     context.Context Execution context
+// imageID string          Image ID
+func (s *clientSession) GetImage(ctx context.Context, imageID string) (ionossdk.Image, error) {
+	image, httpResponse, err := s.client.ImagesApi.ImagesFindById(ctx, imageID).Depth(1).Execute()
+	return image, newAPIError(httpResponse, err)
+}
+
+// ListImages returns all images accessible.
+//
+// PARAMETERS
+// ctx context.Context Execution context
+func (s *clientSession) ListImages(ctx context.Context) ([]ionossdk.Image, error) {
+	images, httpResponse, err := s.client.ImagesApi.ImagesGet(ctx).Depth(1).Execute()
+	if nil != err {
+		return nil, newAPIError(httpResponse, err)
+	}
+
+	if !images.HasItems() {
+		return []ionossdk.Image{}, nil
+	}
+
+	return *images.Items, nil
+}
+
+// GetSnapshot returns the snapshot with the ID given.
+//
+// PARAMETERS
+// ctx        context.Context Execution context
+// snapshotID string          Snapshot ID
+func (s *clientSession) GetSnapshot(ctx context.Context, snapshotID string) (ionossdk.Snapshot, error) {
+	snapshot, httpResponse, err := s.client.SnapshotsApi.SnapshotsFindById(ctx, snapshotID).Depth(1).Execute()
+	return snapshot, newAPIError(httpResponse, err)
+}
+
+// ListServers returns all servers of the datacenter given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// depth        int32           Depth of nested resources returned
+func (s *clientSession) ListServers(ctx context.Context, datacenterID string, depth int32) ([]ionossdk.Server, error) {
+	servers, httpResponse, err := s.client.ServersApi.DatacentersServersGet(ctx, datacenterID).Depth(depth).Execute()
+	if nil != err {
+		return nil, newAPIError(httpResponse, err)
+	}
+
+	if !servers.HasItems() {
+		return []ionossdk.Server{}, nil
+	}
+
+	return *servers.Items, nil
+}
+
+// GetServer returns the server with the ID given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+// depth        int32           Depth of nested resources returned
+func (s *clientSession) GetServer(ctx context.Context, datacenterID, serverID string, depth int32) (ionossdk.Server, error) {
+	server, httpResponse, err := s.client.ServersApi.DatacentersServersFindById(ctx, datacenterID, serverID).Depth(depth).Execute()
+	return server, newAPIError(httpResponse, err)
+}
+
+// CreateServer requests the creation of the server given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// server       ionossdk.Server Server to create
+func (s *clientSession) CreateServer(ctx context.Context, datacenterID string, server ionossdk.Server) (ionossdk.Server, error) {
+	server, httpResponse, err := s.client.ServersApi.DatacentersServersPost(ctx, datacenterID).Depth(0).Server(server).Execute()
+	if nil != err {
+		return server, newAPIError(httpResponse, err)
+	}
+
+	if server.HasId() {
+		s.requests.track(httpResponse, getResourceKey(datacenterID, "servers", *server.Id))
+	}
+
+	return server, nil
+}
+
+// DeleteServer requests the deletion of the server with the ID given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+func (s *clientSession) DeleteServer(ctx context.Context, datacenterID, serverID string) error {
+	httpResponse, err := s.client.ServersApi.DatacentersServersDelete(ctx, datacenterID, serverID).Depth(0).Execute()
+	s.requests.track(httpResponse, getResourceKey(datacenterID, "servers", serverID))
+
+	return newAPIError(httpResponse, err)
+}
+
+// StartServer requests to start the server with the ID given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+func (s *clientSession) StartServer(ctx context.Context, datacenterID, serverID string) error {
+	httpResponse, err := s.client.ServersApi.DatacentersServersStartPost(ctx, datacenterID, serverID).Execute()
+	s.requests.track(httpResponse, getResourceKey(datacenterID, "servers", serverID))
+
+	return newAPIError(httpResponse, err)
+}
+
+// StopServer requests to stop the server with the ID given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+func (s *clientSession) StopServer(ctx context.Context, datacenterID, serverID string) error {
+	httpResponse, err := s.client.ServersApi.DatacentersServersStopPost(ctx, datacenterID, serverID).Execute()
+	s.requests.track(httpResponse, getResourceKey(datacenterID, "servers", serverID))
+
+	return newAPIError(httpResponse, err)
+}
+
+// AttachVolume requests to attach the volume to the server with the IDs given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+// volumeID     string          Volume ID
+func (s *clientSession) AttachVolume(ctx context.Context, datacenterID, serverID, volumeID string) error {
+	volumeApiAttachRequest := s.client.ServersApi.DatacentersServersVolumesPost(ctx, datacenterID, serverID).Depth(0)
+	_, httpResponse, err := volumeApiAttachRequest.Volume(ionossdk.Volume{Id: ionossdk.PtrString(volumeID)}).Execute()
+	s.requests.track(httpResponse, getResourceKey(datacenterID, "servers", serverID), getResourceKey(datacenterID, "volumes", volumeID))
+
+	return newAPIError(httpResponse, err)
+}
+
+// WaitForServer waits until all requested modifications of the server have been applied.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+func (s *clientSession) WaitForServer(ctx context.Context, datacenterID, serverID string) (ionossdk.Server, error) {
+	var server ionossdk.Server
+
+	err := s.waitForResource(ctx, getResourceKey(datacenterID, "servers", serverID), func() (*ionossdk.DatacenterElementMetadata, error) {
+		var err error
+
+		server, err = s.GetServer(ctx, datacenterID, serverID, 0)
+		return server.Metadata, err
+	})
+
+	return server, err
+}
+
+// ListVolumes returns all volumes of the datacenter given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// depth        int32           Depth of nested resources returned
+func (s *clientSession) ListVolumes(ctx context.Context, datacenterID string, depth int32) ([]ionossdk.Volume, error) {
+	volumes, httpResponse, err := s.client.VolumesApi.DatacentersVolumesGet(ctx, datacenterID).Depth(depth).Execute()
+	if nil != err {
+		return nil, newAPIError(httpResponse, err)
+	}
+
+	if !volumes.HasItems() {
+		return []ionossdk.Volume{}, nil
+	}
+
+	return *volumes.Items, nil
+}
+
+// CreateVolume requests the creation of the volume given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// volume       ionossdk.Volume Volume to create
+func (s *clientSession) CreateVolume(ctx context.Context, datacenterID string, volume ionossdk.Volume) (ionossdk.Volume, error) {
+	volume, httpResponse, err := s.client.VolumesApi.DatacentersVolumesPost(ctx, datacenterID).Depth(0).Volume(volume).Execute()
+	if nil != err {
+		return volume, newAPIError(httpResponse, err)
+	}
+
+	if volume.HasId() {
+		s.requests.track(httpResponse, getResourceKey(datacenterID, "volumes", *volume.Id))
+	}
+
+	return volume, nil
+}
+
+// DeleteVolume requests the deletion of the volume with the ID given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// volumeID     string          Volume ID
+func (s *clientSession) DeleteVolume(ctx context.Context, datacenterID, volumeID string) error {
+	httpResponse, err := s.client.VolumesApi.DatacentersVolumesDelete(ctx, datacenterID, volumeID).Depth(0).Execute()
+	s.requests.track(httpResponse, getResourceKey(datacenterID, "volumes", volumeID))
+
+	return newAPIError(httpResponse, err)
+}
+
+// WaitForVolume waits until all requested modifications of the volume have been applied.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// volumeID     string          Volume ID
+func (s *clientSession) WaitForVolume(ctx context.Context, datacenterID, volumeID string) (ionossdk.Volume, error) {
+	var volume ionossdk.Volume
+
+	err := s.waitForResource(ctx, getResourceKey(datacenterID, "volumes", volumeID), func() (*ionossdk.DatacenterElementMetadata, error) {
+		var httpResponse *ionossdk.APIResponse
+		var err error
+
+		volume, httpResponse, err = s.client.VolumesApi.DatacentersVolumesFindById(ctx, datacenterID, volumeID).Depth(0).Execute()
+		return volume.Metadata, newAPIError(httpResponse, err)
+	})
+
+	return volume, err
+}
+
+// CreateNic requests the creation of the NIC given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+// nic          ionossdk.Nic    NIC to create
+func (s *clientSession) CreateNic(ctx context.Context, datacenterID, serverID string, nic ionossdk.Nic) (ionossdk.Nic, error) {
+	nic, httpResponse, err := s.client.NetworkInterfacesApi.DatacentersServersNicsPost(ctx, datacenterID, serverID).Depth(0).Nic(nic).Execute()
+	if nil != err {
+		return nic, newAPIError(httpResponse, err)
+	}
+
+	if nic.HasId() {
+		s.requests.track(httpResponse, getResourceKey(datacenterID, "servers", serverID, "nics", *nic.Id), getResourceKey(datacenterID, "servers", serverID))
+	}
+
+	return nic, nil
+}
+
+// WaitForNic waits until all requested modifications of the NIC have been applied.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+// nicID        string          NIC ID
+func (s *clientSession) WaitForNic(ctx context.Context, datacenterID, serverID, nicID string) (ionossdk.Nic, error) {
+	var nic ionossdk.Nic
+
+	err := s.waitForResource(ctx, getResourceKey(datacenterID, "servers", serverID, "nics", nicID), func() (*ionossdk.DatacenterElementMetadata, error) {
+		var httpResponse *ionossdk.APIResponse
+		var err error
+
+		nic, httpResponse, err = s.client.NetworkInterfacesApi.DatacentersServersNicsFindById(ctx, datacenterID, serverID, nicID).Depth(0).Execute()
+		return nic.Metadata, newAPIError(httpResponse, err)
+	})
+
+	return nic, err
+}
+
+// ListFirewallRules returns all firewall rules of the NIC given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+// nicID        string          NIC ID
+func (s *clientSession) ListFirewallRules(ctx context.Context, datacenterID, serverID, nicID string) ([]ionossdk.FirewallRule, error) {
+	rules, httpResponse, err := s.client.FirewallRulesApi.DatacentersServersNicsFirewallrulesGet(ctx, datacenterID, serverID, nicID).Depth(1).Execute()
+	if nil != err {
+		return nil, newAPIError(httpResponse, err)
+	}
+
+	if !rules.HasItems() {
+		return []ionossdk.FirewallRule{}, nil
+	}
+
+	return *rules.Items, nil
+}
+
+// CreateFirewallRule requests the creation of the firewall rule given.
+//
+// PARAMETERS
+// ctx          context.Context       Execution context
+// datacenterID string                Datacenter ID
+// serverID     string                Server ID
+// nicID        string                NIC ID
+// rule         ionossdk.FirewallRule Firewall rule to create
+func (s *clientSession) CreateFirewallRule(ctx context.Context, datacenterID, serverID, nicID string, rule ionossdk.FirewallRule) (ionossdk.FirewallRule, error) {
+	ruleApiCreateRequest := s.client.FirewallRulesApi.DatacentersServersNicsFirewallrulesPost(ctx, datacenterID, serverID, nicID)
+	rule, httpResponse, err := ruleApiCreateRequest.Firewallrule(rule).Execute()
+	s.requests.track(httpResponse, getResourceKey(datacenterID, "servers", serverID, "nics", nicID), getResourceKey(datacenterID, "servers", serverID))
+
+	return rule, newAPIError(httpResponse, err)
+}
+
+// GetServerLabels returns the labels of the server with the ID given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+func (s *clientSession) GetServerLabels(ctx context.Context, datacenterID, serverID string) (map[string]string, error) {
+	labels, httpResponse, err := s.client.LabelsApi.DatacentersServersLabelsGet(ctx, datacenterID, serverID).Depth(1).Execute()
+	if nil != err {
+		return nil, newAPIError(httpResponse, err)
+	}
+
+	return GetLabelMap(labels), nil
+}
+
+// ListServerLabels returns the labels with the keys given of all servers in the datacenter indexed by server ID. The
+// labels of all servers are requested once instead of once per server.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// keys         ...string       Label keys
+func (s *clientSession) ListServerLabels(ctx context.Context, datacenterID string, keys ...string) (map[string]map[string]string, error) {
+	serverLabels := map[string]map[string]string{}
+
+	labels, httpResponse, err := s.client.LabelsApi.LabelsGet(ctx).Depth(1).Filter("resourceType", "server").Execute()
+	if nil != err {
+		return nil, newAPIError(httpResponse, err)
+	}
+
+	if !labels.HasItems() {
+		return serverLabels, nil
+	}
+
+	keysRequested := map[string]bool{}
+	for _, key := range keys {
+		keysRequested[key] = true
+	}
+
+	for _, label := range *labels.Items {
+		if !label.HasProperties() || !label.Properties.HasResourceId() || !label.Properties.HasResourceHref() {
+			continue
+		}
+
+		properties := label.Properties
+
+		// Labels of all datacenters are returned
+		if !properties.HasKey() || !keysRequested[*properties.Key] || !properties.HasValue() || datacenterID != getServerDatacenterID(*properties.ResourceHref) {
+			continue
+		}
+
+		if _, ok := serverLabels[*properties.ResourceId]; !ok {
+			serverLabels[*properties.ResourceId] = map[string]string{}
+		}
+
+		serverLabels[*properties.ResourceId][*properties.Key] = *properties.Value
+	}
+
+	return serverLabels, nil
+}
+
+// AddServerLabel adds a label to the server with the ID given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// serverID     string          Server ID
+// key          string          Label key
+// value        string          Label value
+func (s *clientSession) AddServerLabel(ctx context.Context, datacenterID, serverID, key, value string) error {
+	label := ionossdk.LabelResource{Properties: &ionossdk.LabelResourceProperties{Key: &key, Value: &value}}
+
+	_, httpResponse, err := s.client.LabelsApi.DatacentersServersLabelsPost(ctx, datacenterID, serverID).Depth(0).Label(label).Execute()
+	s.requests.track(httpResponse, getResourceKey(datacenterID, "servers", serverID))
+
+	return newAPIError(httpResponse, err)
+}
+
+// GetVolumeLabels returns the labels of the volume with the ID given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// volumeID     string          Volume ID
+func (s *clientSession) GetVolumeLabels(ctx context.Context, datacenterID, volumeID string) (map[string]string, error) {
+	labels, httpResponse, err := s.client.LabelsApi.DatacentersVolumesLabelsGet(ctx, datacenterID, volumeID).Depth(1).Execute()
+	if nil != err {
+		return nil, newAPIError(httpResponse, err)
+	}
+
+	return GetLabelMap(labels), nil
+}
+
+// AddVolumeLabel adds a label to the volume with the ID given.
+//
+// PARAMETERS
+// ctx          context.Context Execution context
+// datacenterID string          Datacenter ID
+// volumeID     string          Volume ID
+// key          string          Label key
+// value        string          Label value
+func (s *clientSession) AddVolumeLabel(ctx context.Context, datacenterID, volumeID, key, value string) error {
+	label := ionossdk.LabelResource{Properties: &ionossdk.LabelResourceProperties{Key: &key, Value: &value}}
+
+	_, httpResponse, err := s.client.LabelsApi.DatacentersVolumesLabelsPost(ctx, datacenterID, volumeID).Depth(0).Label(label).Execute()
+	s.requests.track(httpResponse, getResourceKey(datacenterID, "volumes", volumeID))
+
+	return newAPIError(httpResponse, err)
+}
+
+// GetIPBlock returns the IP block with the ID given.
+//
+// PARAMETERS
+// ctx       context.Context Execution context
+// ipBlockID string          IP block ID
+func (s *clientSession) GetIPBlock(ctx context.Context, ipBlockID string) (ionossdk.IpBlock, error) {
+	ipBlock, httpResponse, err := s.client.IPBlocksApi.IpblocksFindById(ctx, ipBlockID).Execute()
+	return ipBlock, newAPIError(httpResponse, err)
+}
+
+// GetRequestStatus returns the status of the IONOS API request with the ID given.
+//
+// PARAMETERS
+// ctx       context.Context Execution context
+// requestID string          Request ID
+func (s *clientSession) GetRequestStatus(ctx context.Context, requestID string) (ionossdk.RequestStatus, error) {
+	requestStatus, httpResponse, err := s.client.RequestsApi.RequestsStatusGet(ctx, requestID).Execute()
+	return requestStatus, newAPIError(httpResponse, err)
+}
+
+// GetLabelMap returns the key and value pairs of the label resources given.
+//
+// PARAMETERS
+// labels ionossdk.LabelResources Label resources
+func GetLabelMap(labels ionossdk.LabelResources) map[string]string {
+	labelMap := map[string]string{}
+
+	if labels.HasItems() {
+		for _, label := range *labels.Items {
+			if label.HasProperties() && label.Properties.HasKey() && label.Properties.HasValue() {
+				labelMap[*label.Properties.Key] = *label.Properties.Value
+			}
+		}
+	}
+
+	return labelMap
+}
+
+// getServerDatacenterID returns the datacenter ID of the server resource URL given. An empty string is returned if
+// the URL does not reference a server.
+//
+// PARAMETERS
+// resourceHref string Server resource URL
+func getServerDatacenterID(resourceHref string) string {
+	resourceURL, err := url.Parse(resourceHref)
+	if nil != err {
+		return ""
+	}
+
+	segments := strings.Split(strings.Trim(resourceURL.Path, "/"), "/")
+
+	for index := 0; index+3 < len(segments); index++ {
+		if "datacenters" == segments[index] && "servers" == segments[index+2] {
+			return segments[index+1]
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,75 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package spi provides the session interface used to access the IONOS API
+package spi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClientSession", func() {
+	Describe("#getServerDatacenterID", func() {
+		DescribeTable("##table",
+			func(resourceHref, datacenterID string) {
+				Expect(getServerDatacenterID(resourceHref)).To(Equal(datacenterID))
+			},
+			Entry("returns the datacenter ID of server URLs", "https://api.ionos.com/cloudapi/v6/datacenters/dc/servers/server-1", "dc"),
+			Entry("returns the datacenter ID of server paths", "/datacenters/dc/servers/server-1", "dc"),
+			Entry("ignores volume URLs", "https://api.ionos.com/cloudapi/v6/datacenters/dc/volumes/volume-1", ""),
+			Entry("ignores datacenter URLs", "https://api.ionos.com/cloudapi/v6/datacenters/dc", ""),
+			Entry("ignores invalid URLs", "://datacenters/dc/servers/server-1", ""),
+		)
+	})
+
+	Describe("#ListServerLabels", func() {
+		It("should request all server labels once and only return the ones of the datacenter and keys given", func() {
+			var queries []string
+
+			mux := http.NewServeMux()
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			mux.HandleFunc("/cloudapi/v6/labels", func(res http.ResponseWriter, req *http.Request) {
+				queries = append(queries, req.URL.RawQuery)
+
+				res.Header().Set("Content-Type", "application/json")
+				_, _ = res.Write([]byte(`{"items": [
+					{"properties": {"key": "cluster", "value": "a", "resourceId": "server-1", "resourceHref": "` + server.URL + `/cloudapi/v6/datacenters/dc/servers/server-1"}},
+					{"properties": {"key": "role", "value": "node", "resourceId": "server-1", "resourceHref": "` + server.URL + `/cloudapi/v6/datacenters/dc/servers/server-1"}},
+					{"properties": {"key": "name", "value": "machine", "resourceId": "server-1", "resourceHref": "` + server.URL + `/cloudapi/v6/datacenters/dc/servers/server-1"}},
+					{"properties": {"key": "cluster", "value": "a", "resourceId": "server-2", "resourceHref": "` + server.URL + `/cloudapi/v6/datacenters/dc-2/servers/server-2"}},
+					{"properties": {"key": "cluster", "value": "a", "resourceId": "server-3", "resourceHref": "` + server.URL + `/cloudapi/v6/datacenters/dc-2/servers/dc/servers/server-3"}}
+				]}`))
+			})
+
+			session := NewClientSession(ionossdk.NewAPIClient(ionossdk.NewConfiguration("dummy-user", "dummy-password", "", server.URL)))
+
+			labels, err := session.ListServerLabels(context.Background(), "dc", "cluster", "role")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(labels).To(Equal(map[string]map[string]string{"server-1": {"cluster": "a", "role": "node"}}))
+			Expect(queries).To(HaveLen(1))
+			Expect(queries[0]).To(ContainSubstring("filter.resourceType=server"))
+			Expect(queries[0]).NotTo(ContainSubstring("filter.key"))
+		})
+	})
+})
@@ -14,13 +14,138 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
+// Package spi provides the session interface used to access the IONOS API
 package spi
 
+import (
+	"context"
+
+	"github.com/23technologies/machine-controller-manager-provider-ionos/pkg/ionos/apis"
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
+	corev1 "k8s.io/api/core/v1"
+)
+
 // SessionProviderInterface provides an interface to deal with cloud provider session
 type SessionProviderInterface interface {
-	// NewSession(*corev1.Secret, string) (*session.Session, error)
-	// NewEC2API(*session.Session) ec2iface.EC2API
+	// NewSession returns a session authenticated with the credentials of the secret given
+	NewSession(secret *corev1.Secret) (Session, error)
+}
+
+// Session provides the IONOS API operations used by the driver. Errors returned for failed API calls are of type
+// *APIError if the HTTP status code is known.
+type Session interface {
+	// GetDatacenter returns the datacenter with the ID given
+	GetDatacenter(ctx context.Context, datacenterID string) (ionossdk.Datacenter, error)
+
+	// GetImage returns the image with the ID given
+	GetImage(ctx context.Context, imageID string) (ionossdk.Image, error)
+	// ListImages returns all images accessible
+	ListImages(ctx context.Context) ([]ionossdk.Image, error)
+	// GetSnapshot returns the snapshot with the ID given
+	GetSnapshot(ctx context.Context, snapshotID string) (ionossdk.Snapshot, error)
+
+	// ListServers returns all servers of the datacenter given
+	ListServers(ctx context.Context, datacenterID string, depth int32) ([]ionossdk.Server, error)
+	// GetServer returns the server with the ID given
+	GetServer(ctx context.Context, datacenterID, serverID string, depth int32) (ionossdk.Server, error)
+	// CreateServer requests the creation of the server given
+	CreateServer(ctx context.Context, datacenterID string, server ionossdk.Server) (ionossdk.Server, error)
+	// DeleteServer requests the deletion of the server with the ID given
+	DeleteServer(ctx context.Context, datacenterID, serverID string) error
+	// StartServer requests to start the server with the ID given
+	StartServer(ctx context.Context, datacenterID, serverID string) error
+	// StopServer requests to stop the server with the ID given
+	StopServer(ctx context.Context, datacenterID, serverID string) error
+	// AttachVolume requests to attach the volume to the server with the IDs given
+	AttachVolume(ctx context.Context, datacenterID, serverID, volumeID string) error
+	// WaitForServer waits until all requested modifications of the server have been applied
+	WaitForServer(ctx context.Context, datacenterID, serverID string) (ionossdk.Server, error)
+
+	// ListVolumes returns all volumes of the datacenter given
+	ListVolumes(ctx context.Context, datacenterID string, depth int32) ([]ionossdk.Volume, error)
+	// CreateVolume requests the creation of the volume given
+	CreateVolume(ctx context.Context, datacenterID string, volume ionossdk.Volume) (ionossdk.Volume, error)
+	// DeleteVolume requests the deletion of the volume with the ID given
+	DeleteVolume(ctx context.Context, datacenterID, volumeID string) error
+	// WaitForVolume waits until all requested modifications of the volume have been applied
+	WaitForVolume(ctx context.Context, datacenterID, volumeID string) (ionossdk.Volume, error)
+
+	// CreateNic requests the creation of the NIC given
+	CreateNic(ctx context.Context, datacenterID, serverID string, nic ionossdk.Nic) (ionossdk.Nic, error)
+	// WaitForNic waits until all requested modifications of the NIC have been applied
+	WaitForNic(ctx context.Context, datacenterID, serverID, nicID string) (ionossdk.Nic, error)
+	// ListFirewallRules returns all firewall rules of the NIC given
+	ListFirewallRules(ctx context.Context, datacenterID, serverID, nicID string) ([]ionossdk.FirewallRule, error)
+	// CreateFirewallRule requests the creation of the firewall rule given
+	CreateFirewallRule(ctx context.Context, datacenterID, serverID, nicID string, rule ionossdk.FirewallRule) (ionossdk.FirewallRule, error)
+
+	// GetServerLabels returns the labels of the server with the ID given
+	GetServerLabels(ctx context.Context, datacenterID, serverID string) (map[string]string, error)
+	// ListServerLabels returns the labels with the keys given of all servers in the datacenter indexed by server ID
+	ListServerLabels(ctx context.Context, datacenterID string, keys ...string) (map[string]map[string]string, error)
+	// AddServerLabel adds a label to the server with the ID given
+	AddServerLabel(ctx context.Context, datacenterID, serverID, key, value string) error
+	// GetVolumeLabels returns the labels of the volume with the ID given
+	GetVolumeLabels(ctx context.Context, datacenterID, volumeID string) (map[string]string, error)
+	// AddVolumeLabel adds a label to the volume with the ID given
+	AddVolumeLabel(ctx context.Context, datacenterID, volumeID, key, value string) error
+
+	// GetIPBlock returns the IP block with the ID given
+	GetIPBlock(ctx context.Context, ipBlockID string) (ionossdk.IpBlock, error)
+
+	// GetRequestStatus returns the status of the IONOS API request with the ID given
+	GetRequestStatus(ctx context.Context, requestID string) (ionossdk.RequestStatus, error)
 }
 
 // PluginSPIImpl is the real implementation of SPI interface that makes the calls to the provider SDK.
-type PluginSPIImpl struct{}
+type PluginSPIImpl struct {
+	ClientOptions *ClientOptions
+}
+
+// NewSession returns a session authenticated with the credentials of the secret given. Client settings of the
+// secret take precedence over the ones configured for the plugin.
+//
+// PARAMETERS
+// secret *corev1.Secret Kubernetes secret that contains any sensitive data/credentials
+func (spi *PluginSPIImpl) NewSession(secret *corev1.Secret) (Session, error) {
+	credentials, err := apis.GetCredentialsFromSecret(secret)
+	if nil != err {
+		return nil, err
+	}
+
+	configuration := &clientConfiguration{Credentials: *credentials}
+
+	if nil != spi.ClientOptions {
+		settings, err := spi.ClientOptions.GetClientSettings()
+		if nil != err {
+			return nil, err
+		}
+
+		configuration.ClientSettings = *settings
+	}
+
+	secretSettings, err := apis.GetClientSettingsFromSecret(secret)
+	if nil != err {
+		return nil, err
+	}
+
+	if "" != secretSettings.APIURL {
+		configuration.APIURL = secretSettings.APIURL
+	}
+	if len(secretSettings.CABundle) > 0 {
+		configuration.CABundle = secretSettings.CABundle
+	}
+	if "" != secretSettings.ProxyURL {
+		configuration.ProxyURL = secretSettings.ProxyURL
+	}
+	if secretSettings.RequestTimeout > 0 {
+		configuration.RequestTimeout = secretSettings.RequestTimeout
+	}
+
+	client, err := ionosClientCache.get(getClientCacheOwner(secret, credentials), configuration)
+	if nil != err {
+		return nil, err
+	}
+
+	return NewClientSession(client), nil
+}
@@ -14,14 +14,17 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-// Package pkg is the main package for IONOS specific APIs
-package pkg
+// Package spi provides the session interface used to access the IONOS API
+package spi
 
 import (
-	"time"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
 )
 
-// Constant defaultOperationInterval is the time to wait between retries
-const defaultOperationInterval = 15 * time.Second
-// Constant defaultOperationRetries is the maximum number of retries
-const defaultOperationRetries = 40
+func TestSPI(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SPI Suite")
+}
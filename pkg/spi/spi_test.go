@@ -0,0 +1,199 @@
+/*
+Copyright (c) 2021 23 Technologies GmbH. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package spi provides the session interface used to access the IONOS API
+package spi
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	ionossdk "github.com/ionos-cloud/sdk-go/v6"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("PluginSPIImpl", func() {
+	var (
+		cache          *clientCache
+		configurations []*clientConfiguration
+		mutex          sync.Mutex
+	)
+
+	newSecret := func(name, user, password string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Data: map[string][]byte{
+				"user":     []byte(user),
+				"password": []byte(password),
+			},
+		}
+	}
+
+	var _ = BeforeEach(func() {
+		configurations = []*clientConfiguration{}
+
+		cache = newClientCache(func(configuration *clientConfiguration) (*ionossdk.APIClient, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			configurations = append(configurations, configuration)
+
+			return newClientForConfiguration(configuration)
+		})
+
+		ionosClientCache = cache
+	})
+
+	var _ = AfterEach(func() {
+		ionosClientCache = newClientCache(newClientForConfiguration)
+	})
+
+	Describe("#NewSession", func() {
+		It("should evict clients while secrets are rotated concurrently", func() {
+			sessionProvider := &PluginSPIImpl{}
+
+			var waitGroup sync.WaitGroup
+
+			for i := 0; i < 16; i++ {
+				waitGroup.Add(1)
+
+				go func(i int) {
+					defer GinkgoRecover()
+					defer waitGroup.Done()
+
+					session, err := sessionProvider.NewSession(newSecret("ionos", "dummy-user", fmt.Sprintf("password-%d", i%4)))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(session).NotTo(BeNil())
+				}(i)
+			}
+
+			waitGroup.Wait()
+
+			Expect(cache.clients).To(HaveLen(1))
+			Expect(cache.ownerKeys).To(HaveLen(1))
+		})
+
+		It("should prefer client settings of the secret over the configured ones", func() {
+			sessionProvider := &PluginSPIImpl{
+				ClientOptions: &ClientOptions{
+					APIURL:         "https://api.example.com",
+					ProxyURL:       "http://proxy.example.com:3128",
+					RequestTimeout: time.Minute,
+				},
+			}
+
+			secret := newSecret("ionos", "dummy-user", "dummy-password")
+			secret.Data["apiURL"] = []byte("https://api.example.org")
+			secret.Data["requestTimeout"] = []byte("30s")
+
+			_, err := sessionProvider.NewSession(secret)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(configurations).To(HaveLen(1))
+			Expect(configurations[0].APIURL).To(Equal("https://api.example.org"))
+			Expect(configurations[0].ProxyURL).To(Equal("http://proxy.example.com:3128"))
+			Expect(configurations[0].RequestTimeout).To(Equal(30 * time.Second))
+		})
+
+		It("should send requests to the API URL configured with the provider User-Agent", func() {
+			userAgent := ""
+
+			mux := http.NewServeMux()
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			mux.HandleFunc("/cloudapi/v6/images/dummy-image", func(res http.ResponseWriter, req *http.Request) {
+				userAgent = req.Header.Get("User-Agent")
+
+				res.Header().Set("Content-Type", "application/json")
+				res.WriteHeader(http.StatusOK)
+				_, _ = res.Write([]byte(`{"id": "dummy-image"}`))
+			})
+
+			sessionProvider := &PluginSPIImpl{ClientOptions: &ClientOptions{APIURL: server.URL}}
+
+			session, err := sessionProvider.NewSession(newSecret("ionos", "dummy-user", "dummy-password"))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = session.GetImage(context.Background(), "dummy-image")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(strings.HasPrefix(userAgent, userAgentProgramName+"/")).To(BeTrue())
+			Expect(userAgent).To(ContainSubstring("ionos-cloud-sdk-go"))
+		})
+
+		It("should return API errors containing the HTTP status code", func() {
+			server := httptest.NewServer(http.NotFoundHandler())
+			defer server.Close()
+
+			sessionProvider := &PluginSPIImpl{ClientOptions: &ClientOptions{APIURL: server.URL}}
+
+			session, err := sessionProvider.NewSession(newSecret("ionos", "dummy-user", "dummy-password"))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = session.GetImage(context.Background(), "dummy-image")
+			Expect(err).To(HaveOccurred())
+			Expect(GetErrorStatusCode(err)).To(Equal(http.StatusNotFound))
+			Expect(IsNotFoundError(err)).To(BeTrue())
+		})
+
+		It("should fail without credentials", func() {
+			_, err := (&PluginSPIImpl{}).NewSession(&corev1.Secret{})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should read the CA bundle configured only once", func() {
+			server := httptest.NewTLSServer(http.NotFoundHandler())
+			defer server.Close()
+
+			caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+			caBundleFile := filepath.Join(GinkgoT().TempDir(), "ca.pem")
+			Expect(os.WriteFile(caBundleFile, caBundle, 0600)).To(Succeed())
+
+			sessionProvider := &PluginSPIImpl{ClientOptions: &ClientOptions{CABundleFile: caBundleFile}}
+
+			_, err := sessionProvider.NewSession(newSecret("ionos", "dummy-user", "dummy-password"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.Remove(caBundleFile)).To(Succeed())
+
+			_, err = sessionProvider.NewSession(newSecret("ionos", "dummy-user", "rotated-password"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(configurations).To(HaveLen(2))
+			Expect(configurations[0].CABundle).To(Equal(caBundle))
+			Expect(configurations[1].CABundle).To(Equal(caBundle))
+		})
+
+		It("should fail if the CA bundle configured can not be read", func() {
+			sessionProvider := &PluginSPIImpl{ClientOptions: &ClientOptions{CABundleFile: "/nonexistent/ca.pem"}}
+
+			_, err := sessionProvider.NewSession(newSecret("ionos", "dummy-user", "dummy-password"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})